@@ -20,7 +20,25 @@ import (
 )
 
 func main() {
-	config.InitRedis()
+	if err := config.InitCache(); err != nil {
+		log.Fatalf("Unable to initialize cache: %v\n", err)
+	}
+
+	if err := config.InitKeyRing(); err != nil {
+		log.Fatalf("Unable to initialize token signing: %v\n", err)
+	}
+
+	if err := config.InitBlobStore(); err != nil {
+		log.Fatalf("Unable to initialize blob store: %v\n", err)
+	}
+
+	if err := config.InitNotifier(); err != nil {
+		log.Fatalf("Unable to initialize notifier: %v\n", err)
+	}
+
+	if err := config.InitMailer(); err != nil {
+		log.Fatalf("Unable to initialize mailer: %v\n", err)
+	}
 
 	port := os.Getenv("PORT")
 	if port == "" {
@@ -43,21 +61,30 @@ func main() {
 
 	router.Use(middlewares.CorsMiddleware)
 
-	router.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
-		w.WriteHeader(http.StatusOK)
-		w.Write([]byte("OK"))
-	}).Methods("GET")
-
+	routes.RegisterHealthRoutes(router, pool)
+	routes.RegisterJWKSRoutes(router)
 	routes.RegisterProductRoutes(router, pool)
 	routes.RegisterCartRoutes(router, pool)
 	routes.RegisterOrderRoutes(router, pool)
 	routes.RegisterUserRoutes(router, pool)
+	routes.RegisterReviewRoutes(router, pool)
+
+	// Serve uploaded files when they are stored on the local filesystem
+	if config.UploadsDir != "" {
+		router.PathPrefix(config.UploadsURLPath).Handler(
+			http.StripPrefix(config.UploadsURLPath, http.FileServer(http.Dir(config.UploadsDir))),
+		)
+	}
 
 	router.NotFoundHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusNotFound)
 		w.Write([]byte("404 Not Found"))
 	})
 
+	jobsCtx, stopJobs := context.WithCancel(context.Background())
+	defer stopJobs()
+	routes.StartBackgroundJobs(jobsCtx, pool)
+
 	srv := &http.Server{
 		Addr:    ":" + port,
 		Handler: router,
@@ -83,6 +110,7 @@ func main() {
 
 	<-done
 	log.Println("Server is shutting down...")
+	stopJobs()
 
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
@@ -0,0 +1,84 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/your-username/golang-ecommerce-app/utils"
+)
+
+var Cache utils.CacheProvider
+
+// CacheDriver is the configured CACHE_DRIVER
+var CacheDriver string
+
+// cacheBreaker guards the Redis-backed drivers; it is nil for the memory driver
+var cacheBreaker *utils.CircuitBreakerCache
+
+// InitCache configures the application cache. CACHE_DRIVER selects the backend:
+//   - "redis" (default) keeps everything in Redis
+//   - "memory" keeps everything in process and needs no Redis; instances do
+//     not see each other's writes, so it suits a single instance or tests
+//   - "tiered" keeps an in-process copy for CACHE_L1_TTL (default 30s) in front
+//     of Redis, invalidated across instances over CACHE_INVALIDATION_CHANNEL
+//
+// CACHE_MEMORY_MAX_ENTRIES (default 10000) bounds the in-process cache.
+//
+// The Redis-backed drivers start even when Redis is down. Calls go through a
+// circuit breaker that bypasses the cache after CACHE_BREAKER_THRESHOLD
+// (default 5) consecutive failures and probes Redis again after
+// CACHE_BREAKER_COOLDOWN (default 30s).
+func InitCache() error {
+	maxEntries := EnvInt("CACHE_MEMORY_MAX_ENTRIES", 10000)
+
+	var cache utils.CacheProvider
+	switch CacheDriver = os.Getenv("CACHE_DRIVER"); CacheDriver {
+	case "", "redis":
+		CacheDriver = "redis"
+		initRedisOrDegrade()
+		cache = utils.NewRedisCache(RedisClient)
+		log.Println("Cache: redis")
+	case "memory":
+		Cache = utils.NewMemoryCache(maxEntries)
+		log.Printf("Cache: in-process, up to %d entries", maxEntries)
+		return nil
+	case "tiered":
+		initRedisOrDegrade()
+		channel := os.Getenv("CACHE_INVALIDATION_CHANNEL")
+		if channel == "" {
+			channel = "cache:invalidate"
+		}
+		l1TTL := EnvDuration("CACHE_L1_TTL", 30*time.Second)
+		tiered := utils.NewTieredCache(RedisClient, utils.NewMemoryCache(maxEntries), l1TTL, channel)
+		go tiered.Listen(context.Background())
+		cache = tiered
+		log.Printf("Cache: in-process for %s in front of redis, invalidated on %s", l1TTL, channel)
+	default:
+		return fmt.Errorf("unknown CACHE_DRIVER: %s", CacheDriver)
+	}
+
+	cacheBreaker = utils.NewCircuitBreakerCache(
+		cache,
+		EnvInt("CACHE_BREAKER_THRESHOLD", 5),
+		EnvDuration("CACHE_BREAKER_COOLDOWN", 30*time.Second),
+	)
+	Cache = cacheBreaker
+	return nil
+}
+
+func initRedisOrDegrade() {
+	if err := InitRedis(); err != nil {
+		log.Printf("Redis unavailable, starting with the cache degraded: %v", err)
+	}
+}
+
+// CacheStatus reports the health of the cache. The memory driver is always closed.
+func CacheStatus() utils.CacheStatus {
+	if cacheBreaker == nil {
+		return utils.CacheStatus{State: "closed"}
+	}
+	return cacheBreaker.Status()
+}
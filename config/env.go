@@ -0,0 +1,62 @@
+package config
+
+import (
+	"log"
+	"os"
+	"strconv"
+	"time"
+)
+
+// EnvDuration reads a duration such as "30s" or "5m" from the environment,
+// falling back to def when the variable is unset or invalid
+func EnvDuration(key string, def time.Duration) time.Duration {
+	raw := os.Getenv(key)
+	if raw == "" {
+		return def
+	}
+	d, err := time.ParseDuration(raw)
+	if err != nil || d <= 0 {
+		log.Printf("Invalid duration for %s: %q, using %s", key, raw, def)
+		return def
+	}
+	return d
+}
+
+// EnvInt reads an integer from the environment, falling back to def when the
+// variable is unset or invalid
+func EnvInt(key string, def int) int {
+	raw := os.Getenv(key)
+	if raw == "" {
+		return def
+	}
+	n, err := strconv.Atoi(raw)
+	if err != nil {
+		log.Printf("Invalid integer for %s: %q, using %d", key, raw, def)
+		return def
+	}
+	return n
+}
+
+// EnvBool reads a boolean such as "true" or "1" from the environment, falling
+// back to def when the variable is unset or invalid
+func EnvBool(key string, def bool) bool {
+	raw := os.Getenv(key)
+	if raw == "" {
+		return def
+	}
+	b, err := strconv.ParseBool(raw)
+	if err != nil {
+		log.Printf("Invalid boolean for %s: %q, using %t", key, raw, def)
+		return def
+	}
+	return b
+}
+
+// EnvString reads a string from the environment, falling back to def when the
+// variable is unset
+func EnvString(key, def string) string {
+	if raw := os.Getenv(key); raw != "" {
+		return raw
+	}
+	return def
+}
@@ -0,0 +1,31 @@
+package config
+
+import (
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/your-username/golang-ecommerce-app/utils"
+)
+
+var KeyRing *utils.KeyRing
+
+// InitKeyRing loads the JWT signing keys from JWT_KEYS_DIR (default ./keys).
+// JWT_ACTIVE_KID picks the signing key; by default the newest by name signs.
+// Startup fails without a usable key rather than signing with an empty secret.
+func InitKeyRing() error {
+	dir := os.Getenv("JWT_KEYS_DIR")
+	if dir == "" {
+		dir = "./keys"
+	}
+
+	ring, err := utils.LoadKeyRing(dir, os.Getenv("JWT_ACTIVE_KID"))
+	if err != nil {
+		return fmt.Errorf("failed to load JWT keys: %w", err)
+	}
+
+	KeyRing = ring
+	utils.UseKeyRing(ring)
+	log.Printf("JWT keys loaded from %s, signing with %s", dir, ring.ActiveKid())
+	return nil
+}
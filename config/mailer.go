@@ -0,0 +1,44 @@
+package config
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/your-username/golang-ecommerce-app/utils"
+)
+
+var Mailer utils.Mailer
+
+// FrontendURL is the origin of the web app whose pages handle links in emails,
+// e.g. https://shop.example.com. It serves /verify-email and /reset-password,
+// which read the token from the query string and POST it to this API.
+var FrontendURL string
+
+// InitMailer configures how transactional email is sent. MAILER selects the
+// backend: "log" (default) or "smtp", which uses SMTP_ADDR (host:port),
+// SMTP_USERNAME, SMTP_PASSWORD and SMTP_FROM. FRONTEND_URL is the origin used
+// in emailed links. It has no default, since this API does not serve the pages
+// those links open; it is required for smtp, while the log mailer falls back to
+// bare paths.
+func InitMailer() error {
+	FrontendURL = strings.TrimSuffix(os.Getenv("FRONTEND_URL"), "/")
+
+	switch driver := os.Getenv("MAILER"); driver {
+	case "", "log":
+		Mailer = utils.NewLogMailer()
+		log.Println("Mailer: application log")
+	case "smtp":
+		addr := os.Getenv("SMTP_ADDR")
+		from := os.Getenv("SMTP_FROM")
+		if addr == "" || from == "" || FrontendURL == "" {
+			return fmt.Errorf("SMTP_ADDR, SMTP_FROM and FRONTEND_URL are required for the smtp mailer")
+		}
+		Mailer = utils.NewSMTPMailer(addr, os.Getenv("SMTP_USERNAME"), os.Getenv("SMTP_PASSWORD"), from)
+		log.Printf("Mailer: SMTP via %s", addr)
+	default:
+		return fmt.Errorf("unknown MAILER: %s", driver)
+	}
+	return nil
+}
@@ -0,0 +1,36 @@
+package config
+
+import (
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/your-username/golang-ecommerce-app/utils"
+)
+
+var Notifier utils.Notifier
+
+// InitNotifier configures how customer notifications are delivered.
+// NOTIFIER selects the backend: "log" (default) or "file", which appends JSON
+// lines to NOTIFIER_FILE (default ./notifications.jsonl).
+func InitNotifier() error {
+	switch driver := os.Getenv("NOTIFIER"); driver {
+	case "", "log":
+		Notifier = utils.NewLogNotifier()
+		log.Println("Notifier: application log")
+	case "file":
+		path := os.Getenv("NOTIFIER_FILE")
+		if path == "" {
+			path = "./notifications.jsonl"
+		}
+		n, err := utils.NewFileNotifier(path)
+		if err != nil {
+			return err
+		}
+		Notifier = n
+		log.Printf("Notifier: file %s", path)
+	default:
+		return fmt.Errorf("unknown NOTIFIER: %s", driver)
+	}
+	return nil
+}
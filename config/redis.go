@@ -2,6 +2,7 @@ package config
 
 import (
 	"context"
+	"fmt"
 	"log"
 	"time"
 
@@ -11,7 +12,9 @@ import (
 var RedisClient *redis.Client
 var Ctx = context.Background()
 
-func InitRedis() {
+// InitRedis creates the Redis client and checks that Redis is reachable. The
+// client is usable even when the check fails, since it reconnects on demand.
+func InitRedis() error {
 	RedisClient = redis.NewClient(&redis.Options{
         Addr:     "localhost:6379",
         Password: "",
@@ -22,8 +25,9 @@ func InitRedis() {
 
 	_, err := RedisClient.Ping(ctx).Result()
 	if err != nil {
-		log.Fatalf("Failed to connect to Redis: %v", err)
+		return fmt.Errorf("failed to connect to Redis: %w", err)
 	}
 
 	log.Println("Redis connected successfully")
-}
\ No newline at end of file
+	return nil
+}
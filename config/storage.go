@@ -0,0 +1,80 @@
+package config
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/your-username/golang-ecommerce-app/utils"
+)
+
+// UploadsURLPath is the route under which locally stored uploads are served
+const UploadsURLPath = "/uploads/"
+
+var (
+	BlobStore utils.BlobStore
+	// UploadsDir is set when blobs are kept on the local filesystem and served by this process
+	UploadsDir string
+)
+
+// InitBlobStore configures the store used for uploaded files.
+// BLOB_STORE selects the backend: "local" (default) or "s3".
+func InitBlobStore() error {
+	var err error
+
+	switch driver := os.Getenv("BLOB_STORE"); driver {
+	case "", "local":
+		dir := os.Getenv("BLOB_LOCAL_DIR")
+		if dir == "" {
+			dir = "./uploads"
+		}
+		BlobStore, err = utils.NewLocalBlobStore(dir, UploadsURLPath)
+		if err != nil {
+			return err
+		}
+		UploadsDir = dir
+		log.Printf("Blob store: local filesystem at %s", dir)
+	case "s3":
+		BlobStore, err = utils.NewS3BlobStore(utils.S3Config{
+			Endpoint:  os.Getenv("S3_ENDPOINT"),
+			Region:    os.Getenv("S3_REGION"),
+			Bucket:    os.Getenv("S3_BUCKET"),
+			AccessKey: os.Getenv("S3_ACCESS_KEY"),
+			SecretKey: os.Getenv("S3_SECRET_KEY"),
+			PathStyle: os.Getenv("S3_PATH_STYLE") == "true",
+			PublicURL: os.Getenv("S3_PUBLIC_URL"),
+		})
+		if err != nil {
+			return err
+		}
+		log.Printf("Blob store: s3 bucket %s", os.Getenv("S3_BUCKET"))
+	default:
+		return fmt.Errorf("unknown BLOB_STORE: %s", driver)
+	}
+
+	return nil
+}
+
+// ThumbnailWidths returns the widths, in pixels, that product image thumbnails are
+// generated at. PRODUCT_THUMBNAIL_WIDTHS takes a comma-separated list.
+func ThumbnailWidths() []int {
+	raw := os.Getenv("PRODUCT_THUMBNAIL_WIDTHS")
+	if raw == "" {
+		return []int{160, 320, 640, 1024}
+	}
+
+	var widths []int
+	for _, part := range strings.Split(raw, ",") {
+		width, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil || width <= 0 {
+			log.Printf("Ignoring invalid thumbnail width %q", part)
+			continue
+		}
+		widths = append(widths, width)
+	}
+	sort.Ints(widths)
+	return widths
+}
@@ -2,6 +2,7 @@ package controllers
 
 import (
 	"encoding/json"
+	"errors"
 	"io"
 	"log"
 	"net/http"
@@ -57,6 +58,11 @@ func (cc *CartController) AddToCart(w http.ResponseWriter, r *http.Request) {
 
 	cart, err := cc.cartService.AddToCartService(r.Context(), userID, bodyBytes)
 	if err != nil {
+		var serviceErr *services.ServiceError
+		if errors.As(err, &serviceErr) {
+			respondWithError(w, serviceErr.Status, serviceErr.Message)
+			return
+		}
 		log.Printf("Error adding to cart: %v", err)
 		respondWithError(w, http.StatusInternalServerError, "Failed to add to cart")
 		return
@@ -199,17 +205,10 @@ func (cc *CartController) UpdateCart(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
-func respondWithError(w http.ResponseWriter, code int, message string) {
-	respondWithJSON(w, code, map[string]interface{}{
-		"error":   true,
-		"message": message,
-	})
-}
-
 func respondWithJSON(w http.ResponseWriter, code int, payload interface{}) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(code)
 	if err := json.NewEncoder(w).Encode(payload); err != nil {
 		log.Printf("Error encoding JSON response: %v", err)
 	}
-}
\ No newline at end of file
+}
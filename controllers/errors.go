@@ -0,0 +1,34 @@
+package controllers
+
+import (
+	"errors"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/your-username/golang-ecommerce-app/services"
+	"github.com/your-username/golang-ecommerce-app/utils"
+)
+
+func respondWithError(w http.ResponseWriter, code int, message string) {
+	respondWithJSON(w, code, map[string]interface{}{
+		"error":   true,
+		"message": message,
+	})
+}
+
+// respondWithServiceError reports a *services.ServiceError with its own status and
+// message, and anything else as a 500 with the given fallback message
+func respondWithServiceError(w http.ResponseWriter, err error, fallback string) {
+	var serviceErr *services.ServiceError
+	if errors.As(err, &serviceErr) {
+		if serviceErr.RetryAfter > 0 {
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(serviceErr.RetryAfter.Seconds()))))
+		}
+		utils.RespondWithError(w, serviceErr.Status, serviceErr.Message)
+		return
+	}
+	log.Printf("%s: %v", fallback, err)
+	utils.RespondWithError(w, http.StatusInternalServerError, fallback)
+}
@@ -0,0 +1,161 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/your-username/golang-ecommerce-app/middlewares"
+	"github.com/your-username/golang-ecommerce-app/services"
+	"github.com/your-username/golang-ecommerce-app/utils"
+)
+
+type MFAController struct {
+	mfaService *services.MFAService
+}
+
+func NewMFAController(mfaService *services.MFAService) *MFAController {
+	return &MFAController{mfaService: mfaService}
+}
+
+func (mc *MFAController) GetStatus(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middlewares.GetUserFromContext(r.Context())
+	if !ok {
+		utils.RespondWithError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	status, err := mc.mfaService.Status(r.Context(), userID)
+	if err != nil {
+		respondWithServiceError(w, err, "Failed to fetch two-factor status")
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, status)
+}
+
+func (mc *MFAController) StartEnrollment(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middlewares.GetUserFromContext(r.Context())
+	if !ok {
+		utils.RespondWithError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	enrollment, err := mc.mfaService.StartEnrollment(r.Context(), userID)
+	if err != nil {
+		respondWithServiceError(w, err, "Failed to start two-factor enrollment")
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, enrollment)
+}
+
+func (mc *MFAController) ConfirmEnrollment(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middlewares.GetUserFromContext(r.Context())
+	if !ok {
+		utils.RespondWithError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	var body struct {
+		Code string `json:"code"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	codes, err := mc.mfaService.ConfirmEnrollment(r.Context(), userID, body.Code)
+	if err != nil {
+		respondWithServiceError(w, err, "Failed to enable two-factor authentication")
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, map[string]interface{}{
+		"message":       "Two-factor authentication enabled; store these recovery codes somewhere safe",
+		"recoveryCodes": codes,
+	})
+}
+
+func (mc *MFAController) Disable(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middlewares.GetUserFromContext(r.Context())
+	if !ok {
+		utils.RespondWithError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	var body struct {
+		Password string `json:"password"`
+		Code     string `json:"code"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if err := mc.mfaService.Disable(r.Context(), userID, body.Password, body.Code, utils.ClientIP(r)); err != nil {
+		respondWithServiceError(w, err, "Failed to disable two-factor authentication")
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, map[string]string{"message": "Two-factor authentication disabled"})
+}
+
+func (mc *MFAController) RegenerateRecoveryCodes(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middlewares.GetUserFromContext(r.Context())
+	if !ok {
+		utils.RespondWithError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	var body struct {
+		Password string `json:"password"`
+		Code     string `json:"code"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	codes, err := mc.mfaService.RegenerateRecoveryCodes(r.Context(), userID, body.Password, body.Code, utils.ClientIP(r))
+	if err != nil {
+		respondWithServiceError(w, err, "Failed to regenerate recovery codes")
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, map[string]interface{}{"recoveryCodes": codes})
+}
+
+func (mc *MFAController) GetPolicy(w http.ResponseWriter, r *http.Request) {
+	roles, err := mc.mfaService.GetPolicy(r.Context())
+	if err != nil {
+		respondWithServiceError(w, err, "Failed to fetch two-factor policy")
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, map[string]interface{}{"requiredRoles": roles})
+}
+
+// SetPolicy lets a superadmin choose which roles must use two-factor
+func (mc *MFAController) SetPolicy(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middlewares.GetUserFromContext(r.Context())
+	if !ok {
+		utils.RespondWithError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	var body struct {
+		RequiredRoles []string `json:"requiredRoles"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	roles, err := mc.mfaService.SetPolicy(r.Context(), userID, body.RequiredRoles)
+	if err != nil {
+		respondWithServiceError(w, err, "Failed to update two-factor policy")
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, map[string]interface{}{"requiredRoles": roles})
+}
@@ -2,6 +2,7 @@ package controllers
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 
 	"github.com/gorilla/mux"
@@ -26,6 +27,20 @@ func (oc *OrderController) CreateOrder(w http.ResponseWriter, r *http.Request) {
 
 	createdOrder, err := oc.orderService.CreateOrder(r.Context(), userId)
 	if err != nil {
+		var unavailableErr *services.UnavailableItemsError
+		if errors.As(err, &unavailableErr) {
+			respondWithJSON(w, http.StatusConflict, map[string]interface{}{
+				"error":            true,
+				"message":          "Some items in your cart are no longer available",
+				"unavailableItems": unavailableErr.Items,
+			})
+			return
+		}
+		var serviceErr *services.ServiceError
+		if errors.As(err, &serviceErr) {
+			respondWithError(w, serviceErr.Status, serviceErr.Message)
+			return
+		}
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
@@ -78,8 +93,19 @@ func (oc *OrderController) UpdateUserOrder(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
-	_, err := oc.orderService.UpdateUserOrder(r.Context(), userId, orderId, status)
+	actor, ok := middlewares.GetPrincipal(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "User authentication required")
+		return
+	}
+
+	_, err := oc.orderService.UpdateUserOrder(r.Context(), actor, userId, orderId, status)
 	if err != nil {
+		var serviceErr *services.ServiceError
+		if errors.As(err, &serviceErr) {
+			respondWithError(w, serviceErr.Status, serviceErr.Message)
+			return
+		}
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
@@ -0,0 +1,57 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/your-username/golang-ecommerce-app/services"
+	"github.com/your-username/golang-ecommerce-app/utils"
+)
+
+type PasswordController struct {
+	resetService *services.PasswordResetService
+}
+
+func NewPasswordController(resetService *services.PasswordResetService) *PasswordController {
+	return &PasswordController{resetService: resetService}
+}
+
+// ForgotPassword answers the same way whether or not the email belongs to an account
+func (pc *PasswordController) ForgotPassword(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Email string `json:"email"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if err := pc.resetService.RequestReset(r.Context(), body.Email); err != nil {
+		respondWithServiceError(w, err, "Failed to request password reset")
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusAccepted, map[string]string{
+		"message": "If an account exists for that email, a password reset link has been sent",
+	})
+}
+
+func (pc *PasswordController) ResetPassword(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Token    string `json:"token"`
+		Password string `json:"password"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if err := pc.resetService.ResetPassword(r.Context(), body.Token, body.Password); err != nil {
+		respondWithServiceError(w, err, "Failed to reset password")
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, map[string]string{"message": "Password has been reset; please log in again"})
+}
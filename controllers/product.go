@@ -1,22 +1,30 @@
 package controllers
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"log"
+	"mime"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gorilla/mux"
+	"github.com/your-username/golang-ecommerce-app/middlewares"
 	"github.com/your-username/golang-ecommerce-app/models"
 	"github.com/your-username/golang-ecommerce-app/services"
 	"github.com/your-username/golang-ecommerce-app/utils"
 )
 
 type ProductController struct {
-	productService *services.ProductService
+	productService        *services.ProductService
+	recentlyViewedService *services.RecentlyViewedService
 }
 
-func NewProductController(productService *services.ProductService) *ProductController {
-	return &ProductController{productService: productService}
+func NewProductController(productService *services.ProductService, recentlyViewedService *services.RecentlyViewedService) *ProductController {
+	return &ProductController{productService: productService, recentlyViewedService: recentlyViewedService}
 }
 
 func (pc *ProductController) GetAllProducts(w http.ResponseWriter, r *http.Request) {
@@ -61,6 +69,11 @@ func (pc *ProductController) GetProductById(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
+	// Signed-in views feed the recently viewed list without delaying the response
+	if userID, ok := middlewares.GetUserFromContext(r.Context()); ok {
+		go pc.recentlyViewedService.RecordView(context.WithoutCancel(r.Context()), userID, id)
+	}
+
 	utils.RespondWithJSON(w, http.StatusOK, product)
 }
 
@@ -78,7 +91,7 @@ func (pc *ProductController) CreateProduct(w http.ResponseWriter, r *http.Reques
 
 	createdProduct, err := pc.productService.CreateProduct(r.Context(), product)
 	if err != nil {
-		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to create product")
+		respondWithServiceError(w, err, "Failed to create product")
 		return
 	}
 
@@ -93,6 +106,13 @@ func (pc *ProductController) UpdateProduct(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
+	// If-Match is optional on a full replacement but honoured when sent
+	expectedVersion, ok := parseIfMatch(r)
+	if !ok {
+		utils.RespondWithError(w, http.StatusPreconditionFailed, "Invalid If-Match header")
+		return
+	}
+
 	var product models.Product
 	if err := json.NewDecoder(r.Body).Decode(&product); err != nil {
 		utils.RespondWithError(w, http.StatusBadRequest, "Invalid request payload")
@@ -104,9 +124,9 @@ func (pc *ProductController) UpdateProduct(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
-	updatedProduct, err := pc.productService.UpdateProduct(r.Context(), id, product)
+	updatedProduct, err := pc.productService.UpdateProduct(r.Context(), id, product, expectedVersion)
 	if err != nil {
-		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to update product")
+		respondWithServiceError(w, err, "Failed to update product")
 		return
 	}
 	if updatedProduct == nil {
@@ -114,9 +134,52 @@ func (pc *ProductController) UpdateProduct(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
+	w.Header().Set("ETag", productETag(updatedProduct))
 	utils.RespondWithJSON(w, http.StatusOK, updatedProduct)
 }
 
+// PatchProduct updates only the fields present in the body. The request must
+// carry the product's current ETag in If-Match so concurrent edits are not lost.
+func (pc *ProductController) PatchProduct(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil || id <= 0 {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid product ID")
+		return
+	}
+
+	if r.Header.Get("If-Match") == "" {
+		utils.RespondWithError(w, http.StatusPreconditionRequired, "If-Match header is required")
+		return
+	}
+	expectedVersion, ok := parseIfMatch(r)
+	if !ok {
+		utils.RespondWithError(w, http.StatusPreconditionFailed, "Invalid If-Match header")
+		return
+	}
+
+	var patch models.ProductPatch
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&patch); err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+
+	patchedProduct, err := pc.productService.PatchProduct(r.Context(), id, patch, expectedVersion)
+	if err != nil {
+		respondWithServiceError(w, err, "Failed to update product")
+		return
+	}
+	if patchedProduct == nil {
+		utils.RespondWithError(w, http.StatusNotFound, "Product not found")
+		return
+	}
+
+	w.Header().Set("ETag", productETag(patchedProduct))
+	utils.RespondWithJSON(w, http.StatusOK, patchedProduct)
+}
+
 func (pc *ProductController) DeleteProduct(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	id, err := strconv.Atoi(vars["id"])
@@ -140,3 +203,247 @@ func (pc *ProductController) DeleteProduct(w http.ResponseWriter, r *http.Reques
 		"deletedProduct": deletedProduct,
 	})
 }
+
+func (pc *ProductController) GetDeletedProducts(w http.ResponseWriter, r *http.Request) {
+	page, err := strconv.Atoi(r.URL.Query().Get("page"))
+	if err != nil || page <= 0 {
+		page = 1
+	}
+
+	limit, err := strconv.Atoi(r.URL.Query().Get("limit"))
+	if err != nil || limit <= 0 {
+		limit = 5
+	}
+
+	products, err := pc.productService.GetDeletedProducts(r.Context(), page, limit)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to fetch deleted products")
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, map[string]interface{}{
+		"data":  products,
+		"page":  page,
+		"limit": limit,
+	})
+}
+
+// GetCacheStats reports hit, miss and stale counts for cached product reads
+func (pc *ProductController) GetCacheStats(w http.ResponseWriter, r *http.Request) {
+	utils.RespondWithJSON(w, http.StatusOK, pc.productService.CacheStats())
+}
+
+func (pc *ProductController) RestoreProduct(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil || id <= 0 {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid product ID")
+		return
+	}
+
+	restoredProduct, err := pc.productService.RestoreProduct(r.Context(), id)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to restore product")
+		return
+	}
+	if restoredProduct == nil {
+		utils.RespondWithError(w, http.StatusNotFound, "Deleted product not found")
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, restoredProduct)
+}
+
+func (pc *ProductController) PurgeProduct(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil || id <= 0 {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid product ID")
+		return
+	}
+
+	purgedProduct, err := pc.productService.PurgeProduct(r.Context(), id)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to purge product")
+		return
+	}
+	if purgedProduct == nil {
+		utils.RespondWithError(w, http.StatusNotFound, "Deleted product not found; products must be deleted before they can be purged")
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, map[string]interface{}{
+		"message":       "Product purged successfully",
+		"purgedProduct": purgedProduct,
+	})
+}
+
+// ImportProducts upserts products by SKU from a CSV or JSON Lines request body.
+// The format comes from the "format" query parameter or the Content-Type header.
+func (pc *ProductController) ImportProducts(w http.ResponseWriter, r *http.Request) {
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+		switch mediaType {
+		case "text/csv":
+			format = services.ProductFormatCSV
+		case "application/x-ndjson", "application/jsonl", "application/x-jsonlines":
+			format = services.ProductFormatJSONL
+		default:
+			utils.RespondWithError(w, http.StatusBadRequest, "format must be csv or jsonl")
+			return
+		}
+	}
+	defer r.Body.Close()
+
+	report, err := pc.productService.ImportProducts(r.Context(), format, r.Body)
+	if err != nil && report == nil {
+		respondWithServiceError(w, err, "Failed to import products")
+		return
+	}
+	if err != nil {
+		// Rows before the failure are already saved, so report them with the error
+		status, message := http.StatusInternalServerError, "Import stopped before the end of the file"
+		var serviceErr *services.ServiceError
+		if errors.As(err, &serviceErr) {
+			status, message = serviceErr.Status, serviceErr.Message
+		} else {
+			log.Printf("Product import stopped after %d rows: %v", report.Processed, err)
+		}
+		utils.RespondWithJSON(w, status, map[string]interface{}{
+			"error":  message,
+			"report": report,
+		})
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, report)
+}
+
+// ExportProducts streams the full catalog as CSV (default) or JSON Lines
+func (pc *ProductController) ExportProducts(w http.ResponseWriter, r *http.Request) {
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = services.ProductFormatCSV
+	}
+
+	switch format {
+	case services.ProductFormatCSV:
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	case services.ProductFormatJSONL:
+		w.Header().Set("Content-Type", "application/x-ndjson")
+	default:
+		utils.RespondWithError(w, http.StatusBadRequest, "format must be csv or jsonl")
+		return
+	}
+	w.Header().Set("Content-Disposition", `attachment; filename="products.`+format+`"`)
+	w.WriteHeader(http.StatusOK)
+
+	// Headers are already sent, so a failure part way through can only be logged
+	if err := pc.productService.ExportProducts(r.Context(), format, w); err != nil {
+		log.Printf("Product export failed: %v", err)
+	}
+}
+
+// GetAdminProducts lists products in every publishing state, optionally filtered by ?status=
+func (pc *ProductController) GetAdminProducts(w http.ResponseWriter, r *http.Request) {
+	page, err := strconv.Atoi(r.URL.Query().Get("page"))
+	if err != nil || page <= 0 {
+		page = 1
+	}
+
+	limit, err := strconv.Atoi(r.URL.Query().Get("limit"))
+	if err != nil || limit <= 0 {
+		limit = 5
+	}
+
+	products, err := pc.productService.GetAdminProducts(r.Context(), r.URL.Query().Get("status"), page, limit)
+	if err != nil {
+		respondWithServiceError(w, err, "Failed to fetch products")
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, map[string]interface{}{
+		"data":  products,
+		"page":  page,
+		"limit": limit,
+	})
+}
+
+func (pc *ProductController) GetAdminProductById(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil || id <= 0 {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid product ID")
+		return
+	}
+
+	product, err := pc.productService.GetAdminProductByID(r.Context(), id)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to fetch product")
+		return
+	}
+	if product == nil {
+		utils.RespondWithError(w, http.StatusNotFound, "Product not found")
+		return
+	}
+
+	w.Header().Set("ETag", productETag(product))
+	utils.RespondWithJSON(w, http.StatusOK, product)
+}
+
+// SetProductStatus publishes, unpublishes, archives or schedules a product
+func (pc *ProductController) SetProductStatus(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil || id <= 0 {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid product ID")
+		return
+	}
+
+	var req struct {
+		Status    string     `json:"status"`
+		PublishAt *time.Time `json:"publishAt"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+
+	product, err := pc.productService.SetProductStatus(r.Context(), id, req.Status, req.PublishAt)
+	if err != nil {
+		respondWithServiceError(w, err, "Failed to update product status")
+		return
+	}
+	if product == nil {
+		utils.RespondWithError(w, http.StatusNotFound, "Product not found")
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, product)
+}
+
+// productETag renders a product's version as a strong entity tag
+func productETag(p *models.Product) string {
+	return `"` + strconv.Itoa(p.Version) + `"`
+}
+
+// parseIfMatch extracts the product version from an If-Match header. A missing
+// header or "*" yields 0, which matches any version. ok is false when the header
+// is not a single strong product ETag, since If-Match never matches weak tags.
+func parseIfMatch(r *http.Request) (version int, ok bool) {
+	header := strings.TrimSpace(r.Header.Get("If-Match"))
+	if header == "" || header == "*" {
+		return 0, true
+	}
+
+	tag := header
+	if len(tag) < 2 || tag[0] != '"' || tag[len(tag)-1] != '"' {
+		return 0, false
+	}
+	version, err := strconv.Atoi(tag[1 : len(tag)-1])
+	if err != nil || version <= 0 {
+		return 0, false
+	}
+	return version, true
+}
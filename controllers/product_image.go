@@ -0,0 +1,155 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/your-username/golang-ecommerce-app/services"
+	"github.com/your-username/golang-ecommerce-app/utils"
+)
+
+type ProductImageController struct {
+	imageService *services.ProductImageService
+}
+
+func NewProductImageController(imageService *services.ProductImageService) *ProductImageController {
+	return &ProductImageController{imageService: imageService}
+}
+
+func (ic *ProductImageController) GetProductImages(w http.ResponseWriter, r *http.Request) {
+	productID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil || productID <= 0 {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid product ID")
+		return
+	}
+
+	images, err := ic.imageService.GetProductImages(r.Context(), productID)
+	if err != nil {
+		respondWithServiceError(w, err, "Failed to fetch product images")
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, images)
+}
+
+// UploadProductImage accepts a multipart form with an "image" file and optional
+// "position" and "primary" fields
+func (ic *ProductImageController) UploadProductImage(w http.ResponseWriter, r *http.Request) {
+	productID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil || productID <= 0 {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid product ID")
+		return
+	}
+
+	// Leave headroom for the multipart envelope and the other form fields
+	r.Body = http.MaxBytesReader(w, r.Body, services.MaxProductImageSize+1<<20)
+	if err := r.ParseMultipartForm(services.MaxProductImageSize); err != nil {
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			utils.RespondWithError(w, http.StatusRequestEntityTooLarge, "Image is too large")
+			return
+		}
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid multipart form")
+		return
+	}
+	defer r.MultipartForm.RemoveAll()
+
+	file, _, err := r.FormFile("image")
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "image file is required")
+		return
+	}
+	defer file.Close()
+
+	position := -1
+	if v := r.FormValue("position"); v != "" {
+		position, err = strconv.Atoi(v)
+		if err != nil || position < 0 {
+			utils.RespondWithError(w, http.StatusBadRequest, "position must be a non-negative integer")
+			return
+		}
+	}
+	primary, _ := strconv.ParseBool(r.FormValue("primary"))
+
+	image, err := ic.imageService.UploadImage(r.Context(), productID, file, position, primary)
+	if err != nil {
+		respondWithServiceError(w, err, "Failed to upload image")
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusCreated, image)
+}
+
+func (ic *ProductImageController) SetPrimaryImage(w http.ResponseWriter, r *http.Request) {
+	productID, imageID, ok := parseImageVars(w, r)
+	if !ok {
+		return
+	}
+
+	if err := ic.imageService.SetPrimaryImage(r.Context(), productID, imageID); err != nil {
+		respondWithServiceError(w, err, "Failed to set primary image")
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, map[string]string{"message": "Primary image updated"})
+}
+
+func (ic *ProductImageController) ReorderImages(w http.ResponseWriter, r *http.Request) {
+	productID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil || productID <= 0 {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid product ID")
+		return
+	}
+
+	var body struct {
+		ImageIDs []int `json:"imageIds"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil || len(body.ImageIDs) == 0 {
+		utils.RespondWithError(w, http.StatusBadRequest, "imageIds is required")
+		return
+	}
+
+	images, err := ic.imageService.ReorderImages(r.Context(), productID, body.ImageIDs)
+	if err != nil {
+		respondWithServiceError(w, err, "Failed to reorder images")
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, images)
+}
+
+func (ic *ProductImageController) DeleteImage(w http.ResponseWriter, r *http.Request) {
+	productID, imageID, ok := parseImageVars(w, r)
+	if !ok {
+		return
+	}
+
+	image, err := ic.imageService.DeleteImage(r.Context(), productID, imageID)
+	if err != nil {
+		respondWithServiceError(w, err, "Failed to delete image")
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, map[string]interface{}{
+		"message":      "Image deleted successfully",
+		"deletedImage": image,
+	})
+}
+
+func parseImageVars(w http.ResponseWriter, r *http.Request) (int, int, bool) {
+	vars := mux.Vars(r)
+	productID, err := strconv.Atoi(vars["id"])
+	if err != nil || productID <= 0 {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid product ID")
+		return 0, 0, false
+	}
+	imageID, err := strconv.Atoi(vars["imageId"])
+	if err != nil || imageID <= 0 {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid image ID")
+		return 0, 0, false
+	}
+	return productID, imageID, true
+}
@@ -0,0 +1,100 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/your-username/golang-ecommerce-app/models"
+	"github.com/your-username/golang-ecommerce-app/services"
+	"github.com/your-username/golang-ecommerce-app/utils"
+)
+
+type ProductPriceController struct {
+	priceService *services.ProductPriceService
+}
+
+func NewProductPriceController(priceService *services.ProductPriceService) *ProductPriceController {
+	return &ProductPriceController{priceService: priceService}
+}
+
+func (pc *ProductPriceController) GetPriceHistory(w http.ResponseWriter, r *http.Request) {
+	productID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil || productID <= 0 {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid product ID")
+		return
+	}
+
+	prices, err := pc.priceService.GetPriceHistory(r.Context(), productID)
+	if err != nil {
+		respondWithServiceError(w, err, "Failed to fetch price history")
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, prices)
+}
+
+// SchedulePrice accepts {"kind", "price", "effectiveFrom", "effectiveTo"}. Omitting
+// effectiveFrom applies the price immediately.
+func (pc *ProductPriceController) SchedulePrice(w http.ResponseWriter, r *http.Request) {
+	productID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil || productID <= 0 {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid product ID")
+		return
+	}
+
+	var req struct {
+		Kind          string     `json:"kind"`
+		Price         float64    `json:"price"`
+		EffectiveFrom *time.Time `json:"effectiveFrom"`
+		EffectiveTo   *time.Time `json:"effectiveTo"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+
+	price := models.ProductPrice{
+		Kind:        req.Kind,
+		Price:       req.Price,
+		EffectiveTo: req.EffectiveTo,
+	}
+	if req.EffectiveFrom != nil {
+		price.EffectiveFrom = *req.EffectiveFrom
+	}
+
+	created, err := pc.priceService.SchedulePrice(r.Context(), productID, price)
+	if err != nil {
+		respondWithServiceError(w, err, "Failed to schedule price")
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusCreated, created)
+}
+
+func (pc *ProductPriceController) CancelPrice(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	productID, err := strconv.Atoi(vars["id"])
+	if err != nil || productID <= 0 {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid product ID")
+		return
+	}
+	priceID, err := strconv.Atoi(vars["priceId"])
+	if err != nil || priceID <= 0 {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid price ID")
+		return
+	}
+
+	price, err := pc.priceService.CancelPrice(r.Context(), productID, priceID)
+	if err != nil {
+		respondWithServiceError(w, err, "Failed to cancel price")
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, map[string]interface{}{
+		"message":        "Price cancelled successfully",
+		"cancelledPrice": price,
+	})
+}
@@ -0,0 +1,105 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/your-username/golang-ecommerce-app/middlewares"
+	"github.com/your-username/golang-ecommerce-app/services"
+	"github.com/your-username/golang-ecommerce-app/utils"
+)
+
+type ProfileController struct {
+	profileService *services.ProfileService
+}
+
+func NewProfileController(profileService *services.ProfileService) *ProfileController {
+	return &ProfileController{profileService: profileService}
+}
+
+func (pc *ProfileController) GetProfile(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middlewares.GetUserFromContext(r.Context())
+	if !ok {
+		utils.RespondWithError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	profile, err := pc.profileService.GetProfile(r.Context(), userID)
+	if err != nil {
+		respondWithServiceError(w, err, "Failed to fetch profile")
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, profile)
+}
+
+func (pc *ProfileController) UpdateProfile(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middlewares.GetUserFromContext(r.Context())
+	if !ok {
+		utils.RespondWithError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	var body services.ProfileUpdate
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	profile, err := pc.profileService.UpdateProfile(r.Context(), userID, body)
+	if err != nil {
+		respondWithServiceError(w, err, "Failed to update profile")
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, profile)
+}
+
+func (pc *ProfileController) ChangePassword(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middlewares.GetUserFromContext(r.Context())
+	if !ok {
+		utils.RespondWithError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+	sessionID, _ := middlewares.GetSessionFromContext(r.Context())
+
+	var body struct {
+		CurrentPassword string `json:"currentPassword"`
+		NewPassword     string `json:"newPassword"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if err := pc.profileService.ChangePassword(r.Context(), userID, sessionID, body.CurrentPassword, body.NewPassword); err != nil {
+		respondWithServiceError(w, err, "Failed to change password")
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, map[string]string{"message": "Password changed successfully"})
+}
+
+// CloseAccount deletes the caller's account; the body must confirm the password
+func (pc *ProfileController) CloseAccount(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middlewares.GetUserFromContext(r.Context())
+	if !ok {
+		utils.RespondWithError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	var body struct {
+		Password string `json:"password"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if err := pc.profileService.CloseAccount(r.Context(), userID, body.Password); err != nil {
+		respondWithServiceError(w, err, "Failed to close account")
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, map[string]string{"message": "Account closed successfully"})
+}
@@ -0,0 +1,34 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/your-username/golang-ecommerce-app/middlewares"
+	"github.com/your-username/golang-ecommerce-app/services"
+	"github.com/your-username/golang-ecommerce-app/utils"
+)
+
+type RecentlyViewedController struct {
+	recentlyViewedService *services.RecentlyViewedService
+}
+
+func NewRecentlyViewedController(recentlyViewedService *services.RecentlyViewedService) *RecentlyViewedController {
+	return &RecentlyViewedController{recentlyViewedService: recentlyViewedService}
+}
+
+// GetRecentlyViewed lists the signed-in user's recently viewed products, newest first
+func (rc *RecentlyViewedController) GetRecentlyViewed(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middlewares.GetUserFromContext(r.Context())
+	if !ok {
+		utils.RespondWithError(w, http.StatusUnauthorized, "User authentication required")
+		return
+	}
+
+	products, err := rc.recentlyViewedService.GetRecentlyViewed(r.Context(), userID)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Failed to fetch recently viewed products")
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, products)
+}
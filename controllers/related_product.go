@@ -0,0 +1,39 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/your-username/golang-ecommerce-app/services"
+	"github.com/your-username/golang-ecommerce-app/utils"
+)
+
+type RelatedProductController struct {
+	relatedService *services.RelatedProductService
+}
+
+func NewRelatedProductController(relatedService *services.RelatedProductService) *RelatedProductController {
+	return &RelatedProductController{relatedService: relatedService}
+}
+
+func (rc *RelatedProductController) GetRelatedProducts(w http.ResponseWriter, r *http.Request) {
+	productID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil || productID <= 0 {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid product ID")
+		return
+	}
+
+	limit, err := strconv.Atoi(r.URL.Query().Get("limit"))
+	if err != nil || limit <= 0 {
+		limit = 5
+	}
+
+	related, err := rc.relatedService.GetRelatedProducts(r.Context(), productID, limit)
+	if err != nil {
+		respondWithServiceError(w, err, "Failed to fetch related products")
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, related)
+}
@@ -0,0 +1,141 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/your-username/golang-ecommerce-app/middlewares"
+	"github.com/your-username/golang-ecommerce-app/models"
+	"github.com/your-username/golang-ecommerce-app/services"
+	"github.com/your-username/golang-ecommerce-app/utils"
+)
+
+type ReviewController struct {
+	reviewService *services.ReviewService
+}
+
+func NewReviewController(reviewService *services.ReviewService) *ReviewController {
+	return &ReviewController{reviewService: reviewService}
+}
+
+func (rc *ReviewController) GetProductReviews(w http.ResponseWriter, r *http.Request) {
+	productID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil || productID <= 0 {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid product ID")
+		return
+	}
+
+	page, err := strconv.Atoi(r.URL.Query().Get("page"))
+	if err != nil || page <= 0 {
+		page = 1
+	}
+
+	limit, err := strconv.Atoi(r.URL.Query().Get("limit"))
+	if err != nil || limit <= 0 {
+		limit = 5
+	}
+
+	reviews, err := rc.reviewService.GetProductReviews(r.Context(), productID, page, limit)
+	if err != nil {
+		respondWithServiceError(w, err, "Failed to fetch reviews")
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, reviews)
+}
+
+// CreateReview accepts {"rating", "title", "body"} from a customer who has ordered the product
+func (rc *ReviewController) CreateReview(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middlewares.GetUserFromContext(r.Context())
+	if !ok {
+		utils.RespondWithError(w, http.StatusUnauthorized, "User authentication required")
+		return
+	}
+
+	productID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil || productID <= 0 {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid product ID")
+		return
+	}
+
+	var req struct {
+		Rating int    `json:"rating"`
+		Title  string `json:"title"`
+		Body   string `json:"body"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+
+	review, err := rc.reviewService.CreateReview(r.Context(), userID, productID, models.Review{
+		Rating: req.Rating,
+		Title:  req.Title,
+		Body:   req.Body,
+	})
+	if err != nil {
+		respondWithServiceError(w, err, "Failed to create review")
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusCreated, review)
+}
+
+// GetReviewsForModeration lists reviews by ?status=, defaulting to the pending queue
+func (rc *ReviewController) GetReviewsForModeration(w http.ResponseWriter, r *http.Request) {
+	page, err := strconv.Atoi(r.URL.Query().Get("page"))
+	if err != nil || page <= 0 {
+		page = 1
+	}
+
+	limit, err := strconv.Atoi(r.URL.Query().Get("limit"))
+	if err != nil || limit <= 0 {
+		limit = 5
+	}
+
+	actor, ok := middlewares.GetPrincipal(r.Context())
+	if !ok {
+		utils.RespondWithError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	reviews, err := rc.reviewService.GetReviewsForModeration(r.Context(), actor, r.URL.Query().Get("status"), page, limit)
+	if err != nil {
+		respondWithServiceError(w, err, "Failed to fetch reviews")
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, reviews)
+}
+
+func (rc *ReviewController) ModerateReview(w http.ResponseWriter, r *http.Request) {
+	actor, ok := middlewares.GetPrincipal(r.Context())
+	if !ok {
+		utils.RespondWithError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	reviewID, err := strconv.Atoi(mux.Vars(r)["reviewId"])
+	if err != nil || reviewID <= 0 {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid review ID")
+		return
+	}
+
+	var req struct {
+		Status string `json:"status"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+
+	review, err := rc.reviewService.ModerateReview(r.Context(), actor, reviewID, req.Status)
+	if err != nil {
+		respondWithServiceError(w, err, "Failed to moderate review")
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, review)
+}
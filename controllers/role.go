@@ -0,0 +1,74 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/your-username/golang-ecommerce-app/middlewares"
+	"github.com/your-username/golang-ecommerce-app/models"
+	"github.com/your-username/golang-ecommerce-app/services"
+	"github.com/your-username/golang-ecommerce-app/utils"
+)
+
+type RoleController struct {
+	roleService *services.RoleService
+}
+
+func NewRoleController(roleService *services.RoleService) *RoleController {
+	return &RoleController{roleService: roleService}
+}
+
+func (rc *RoleController) GetRoles(w http.ResponseWriter, r *http.Request) {
+	roles, err := rc.roleService.GetRoles(r.Context())
+	if err != nil {
+		respondWithServiceError(w, err, "Failed to fetch roles")
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, roles)
+}
+
+// GetPermissions lists the permissions roles can be granted
+func (rc *RoleController) GetPermissions(w http.ResponseWriter, r *http.Request) {
+	utils.RespondWithJSON(w, http.StatusOK, models.Permissions)
+}
+
+// SaveRole creates the role named in the path or replaces its permissions
+func (rc *RoleController) SaveRole(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Description string   `json:"description"`
+		Permissions []string `json:"permissions"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	actor, ok := middlewares.GetPrincipal(r.Context())
+	if !ok {
+		utils.RespondWithError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	role, err := rc.roleService.SaveRole(r.Context(), actor, models.Role{
+		Name:        mux.Vars(r)["name"],
+		Description: body.Description,
+		Permissions: body.Permissions,
+	})
+	if err != nil {
+		respondWithServiceError(w, err, "Failed to save role")
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, role)
+}
+
+func (rc *RoleController) DeleteRole(w http.ResponseWriter, r *http.Request) {
+	if err := rc.roleService.DeleteRole(r.Context(), mux.Vars(r)["name"]); err != nil {
+		respondWithServiceError(w, err, "Failed to delete role")
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, map[string]string{"message": "Role deleted successfully"})
+}
@@ -0,0 +1,50 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/your-username/golang-ecommerce-app/middlewares"
+	"github.com/your-username/golang-ecommerce-app/services"
+	"github.com/your-username/golang-ecommerce-app/utils"
+)
+
+type SessionController struct {
+	sessionService *services.SessionService
+}
+
+func NewSessionController(sessionService *services.SessionService) *SessionController {
+	return &SessionController{sessionService: sessionService}
+}
+
+func (sc *SessionController) GetMySessions(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middlewares.GetUserFromContext(r.Context())
+	if !ok {
+		utils.RespondWithError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+	currentID, _ := middlewares.GetSessionFromContext(r.Context())
+
+	sessions, err := sc.sessionService.ListSessions(r.Context(), userID, currentID)
+	if err != nil {
+		respondWithServiceError(w, err, "Failed to fetch sessions")
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, sessions)
+}
+
+func (sc *SessionController) RevokeMySession(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middlewares.GetUserFromContext(r.Context())
+	if !ok {
+		utils.RespondWithError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	if err := sc.sessionService.RevokeSession(r.Context(), userID, mux.Vars(r)["id"]); err != nil {
+		respondWithServiceError(w, err, "Failed to revoke session")
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, map[string]string{"message": "Session revoked successfully"})
+}
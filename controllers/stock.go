@@ -0,0 +1,92 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/your-username/golang-ecommerce-app/middlewares"
+	"github.com/your-username/golang-ecommerce-app/services"
+	"github.com/your-username/golang-ecommerce-app/utils"
+)
+
+type StockController struct {
+	stockService *services.StockService
+}
+
+func NewStockController(stockService *services.StockService) *StockController {
+	return &StockController{stockService: stockService}
+}
+
+// UpdateStock accepts {"stock": n}
+func (sc *StockController) UpdateStock(w http.ResponseWriter, r *http.Request) {
+	productID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil || productID <= 0 {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid product ID")
+		return
+	}
+
+	var req struct {
+		Stock *int `json:"stock"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Stock == nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "stock is required")
+		return
+	}
+
+	product, err := sc.stockService.UpdateStock(r.Context(), productID, *req.Stock)
+	if err != nil {
+		respondWithServiceError(w, err, "Failed to update stock")
+		return
+	}
+	if product == nil {
+		utils.RespondWithError(w, http.StatusNotFound, "Product not found")
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, product)
+}
+
+func (sc *StockController) Subscribe(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middlewares.GetUserFromContext(r.Context())
+	if !ok {
+		utils.RespondWithError(w, http.StatusUnauthorized, "User authentication required")
+		return
+	}
+
+	productID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil || productID <= 0 {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid product ID")
+		return
+	}
+
+	subscription, err := sc.stockService.Subscribe(r.Context(), productID, userID)
+	if err != nil {
+		respondWithServiceError(w, err, "Failed to subscribe")
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusCreated, subscription)
+}
+
+func (sc *StockController) Unsubscribe(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middlewares.GetUserFromContext(r.Context())
+	if !ok {
+		utils.RespondWithError(w, http.StatusUnauthorized, "User authentication required")
+		return
+	}
+
+	productID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil || productID <= 0 {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid product ID")
+		return
+	}
+
+	if err := sc.stockService.Unsubscribe(r.Context(), productID, userID); err != nil {
+		respondWithServiceError(w, err, "Failed to unsubscribe")
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, map[string]string{"message": "Unsubscribed successfully"})
+}
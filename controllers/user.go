@@ -6,17 +6,19 @@ import (
 	"time"
 
 	"github.com/gorilla/mux"
+	"github.com/your-username/golang-ecommerce-app/middlewares"
 	"github.com/your-username/golang-ecommerce-app/models"
 	"github.com/your-username/golang-ecommerce-app/services"
 	"github.com/your-username/golang-ecommerce-app/utils"
 )
 
 type UserController struct {
-	userService *services.UserService
+	userService         *services.UserService
+	verificationService *services.EmailVerificationService
 }
 
-func NewUserController(userService *services.UserService) *UserController {
-	return &UserController{userService: userService}
+func NewUserController(userService *services.UserService, verificationService *services.EmailVerificationService) *UserController {
+	return &UserController{userService: userService, verificationService: verificationService}
 }
 
 func (uc *UserController) SignupUser(w http.ResponseWriter, r *http.Request) {
@@ -56,6 +58,7 @@ func (uc *UserController) LoginUser(w http.ResponseWriter, r *http.Request) {
 	var body struct {
 		UserId   string `json:"userId"`
 		Password string `json:"password"`
+		Device   string `json:"device"`
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
@@ -68,15 +71,135 @@ func (uc *UserController) LoginUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	result, err := uc.userService.Login(r.Context(), body.UserId, body.Password)
+	result, err := uc.userService.Login(r.Context(), body.UserId, body.Password, models.ClientInfo{
+		IP:        utils.ClientIP(r),
+		UserAgent: r.UserAgent(),
+		Device:    body.Device,
+	})
 	if err != nil {
-		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		respondWithServiceError(w, err, "Failed to log in")
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, result)
+}
+
+// LoginMFA finishes a login for an account with two-factor enabled
+func (uc *UserController) LoginMFA(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		MFAToken string `json:"mfaToken"`
+		Code     string `json:"code"`
+		Device   string `json:"device"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if body.MFAToken == "" || body.Code == "" {
+		utils.RespondWithError(w, http.StatusBadRequest, "mfaToken and code are required")
+		return
+	}
+
+	result, err := uc.userService.CompleteMFALogin(r.Context(), body.MFAToken, body.Code, models.ClientInfo{
+		IP:        utils.ClientIP(r),
+		UserAgent: r.UserAgent(),
+		Device:    body.Device,
+	})
+	if err != nil {
+		respondWithServiceError(w, err, "Failed to log in")
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, result)
+}
+
+func (uc *UserController) RefreshToken(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		RefreshToken string `json:"refreshToken"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if body.RefreshToken == "" {
+		utils.RespondWithError(w, http.StatusBadRequest, "refreshToken is required")
+		return
+	}
+
+	result, err := uc.userService.RefreshTokens(r.Context(), body.RefreshToken)
+	if err != nil {
+		respondWithServiceError(w, err, "Failed to refresh token")
 		return
 	}
 
 	utils.RespondWithJSON(w, http.StatusOK, result)
 }
 
+func (uc *UserController) Logout(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		RefreshToken string `json:"refreshToken"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if body.RefreshToken == "" {
+		utils.RespondWithError(w, http.StatusBadRequest, "refreshToken is required")
+		return
+	}
+
+	if err := uc.userService.Logout(r.Context(), body.RefreshToken); err != nil {
+		respondWithServiceError(w, err, "Failed to log out")
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, map[string]string{"message": "Logged out successfully"})
+}
+
+func (uc *UserController) VerifyEmail(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Token string `json:"token"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if body.Token == "" {
+		utils.RespondWithError(w, http.StatusBadRequest, "token is required")
+		return
+	}
+
+	if err := uc.verificationService.VerifyEmail(r.Context(), body.Token); err != nil {
+		respondWithServiceError(w, err, "Failed to verify email")
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, map[string]string{"message": "Email verified successfully"})
+}
+
+func (uc *UserController) ResendVerificationEmail(w http.ResponseWriter, r *http.Request) {
+	userId, ok := middlewares.GetUserFromContext(r.Context())
+	if !ok {
+		utils.RespondWithError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	if err := uc.verificationService.ResendVerification(r.Context(), userId); err != nil {
+		respondWithServiceError(w, err, "Failed to send verification email")
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, map[string]string{"message": "Verification email sent"})
+}
+
 func (uc *UserController) UpdateUser(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	userId := vars["userId"]
@@ -92,9 +215,15 @@ func (uc *UserController) UpdateUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	result, err := uc.userService.UpdateUserService(r.Context(), userId, updates)
+	actor, ok := middlewares.GetPrincipal(r.Context())
+	if !ok {
+		utils.RespondWithError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	result, err := uc.userService.UpdateUserService(r.Context(), actor, userId, updates)
 	if err != nil {
-		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		respondWithServiceError(w, err, "Failed to update user")
 		return
 	}
 
@@ -110,8 +239,14 @@ func (uc *UserController) DeleteUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := uc.userService.DeleteUserService(r.Context(), userId); err != nil {
-		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+	actor, ok := middlewares.GetPrincipal(r.Context())
+	if !ok {
+		utils.RespondWithError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	if err := uc.userService.DeleteUserService(r.Context(), actor, userId); err != nil {
+		respondWithServiceError(w, err, "Failed to delete user")
 		return
 	}
 
@@ -127,14 +262,68 @@ func (uc *UserController) DeleteUserAllAccess(w http.ResponseWriter, r *http.Req
 		return
 	}
 
-	if err := uc.userService.DeleteUserServiceAllAccess(r.Context(), userId); err != nil {
-		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+	actor, ok := middlewares.GetPrincipal(r.Context())
+	if !ok {
+		utils.RespondWithError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	if err := uc.userService.DeleteUserServiceAllAccess(r.Context(), actor, userId); err != nil {
+		respondWithServiceError(w, err, "Failed to delete user")
 		return
 	}
 
 	utils.RespondWithJSON(w, http.StatusOK, map[string]string{"message": "User deleted successfully"})
 }
 
+// UnlockUser lets an admin lift a login lockout early
+func (uc *UserController) UnlockUser(w http.ResponseWriter, r *http.Request) {
+	userId := mux.Vars(r)["userId"]
+	if userId == "" {
+		utils.RespondWithError(w, http.StatusBadRequest, "userId is required")
+		return
+	}
+
+	actor, ok := middlewares.GetPrincipal(r.Context())
+	if !ok {
+		utils.RespondWithError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	if err := uc.userService.UnlockUserService(r.Context(), actor, userId); err != nil {
+		respondWithServiceError(w, err, "Failed to unlock user")
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, map[string]string{"message": "User unlocked successfully"})
+}
+
+// RevokeUserSessions lets an admin sign a user out of every device
+func (uc *UserController) RevokeUserSessions(w http.ResponseWriter, r *http.Request) {
+	userId := mux.Vars(r)["userId"]
+	if userId == "" {
+		utils.RespondWithError(w, http.StatusBadRequest, "userId is required")
+		return
+	}
+
+	actor, ok := middlewares.GetPrincipal(r.Context())
+	if !ok {
+		utils.RespondWithError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	count, err := uc.userService.RevokeUserSessionsService(r.Context(), actor, userId)
+	if err != nil {
+		respondWithServiceError(w, err, "Failed to revoke sessions")
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, map[string]interface{}{
+		"message": "Sessions revoked successfully",
+		"revoked": count,
+	})
+}
+
 // updateUserRole updates the role of a user
 func (uc *UserController) UpdateUserRole(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
@@ -159,9 +348,15 @@ func (uc *UserController) UpdateUserRole(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	result, err := uc.userService.UpdateUserRoleService(r.Context(), userId, body.Role)
+	actor, ok := middlewares.GetPrincipal(r.Context())
+	if !ok {
+		utils.RespondWithError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	result, err := uc.userService.UpdateUserRoleService(r.Context(), actor, userId, body.Role)
 	if err != nil {
-		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		respondWithServiceError(w, err, "Failed to update user role")
 		return
 	}
 
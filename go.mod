@@ -3,6 +3,7 @@ module github.com/your-username/golang-ecommerce-app
 go 1.24.3
 
 require (
+	github.com/HugoSmits86/nativewebp v0.9.3
 	github.com/golang-jwt/jwt/v5 v5.2.2
 	github.com/golang-migrate/migrate/v4 v4.18.3
 	github.com/gorilla/mux v1.8.1
@@ -10,6 +11,8 @@ require (
 	github.com/joho/godotenv v1.5.1
 	github.com/redis/go-redis/v9 v9.8.0
 	golang.org/x/crypto v0.38.0
+	golang.org/x/image v0.24.0
+	golang.org/x/sync v0.14.0
 )
 
 require (
@@ -22,6 +25,5 @@ require (
 	github.com/jackc/puddle/v2 v2.2.2 // indirect
 	github.com/lib/pq v1.10.9 // indirect
 	go.uber.org/atomic v1.7.0 // indirect
-	golang.org/x/sync v0.14.0 // indirect
 	golang.org/x/text v0.25.0 // indirect
 )
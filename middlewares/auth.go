@@ -6,99 +6,202 @@ import (
 	"net/http"
 	"strings"
 
+	"github.com/your-username/golang-ecommerce-app/models"
 	"github.com/your-username/golang-ecommerce-app/utils"
 )
 
 type contextKey string
 
-const UserContextKey contextKey = "user"
+// PrincipalContextKey holds the *models.Principal of an authenticated request
+const PrincipalContextKey contextKey = "principal"
 
-func AuthenticateToken(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		authHeader := r.Header.Get("Authorization")
-		if authHeader == "" {
-			respondWithError(w, http.StatusUnauthorized, "Authorization header required")
-			return
-		}
+// SessionValidator reports whether a token's session is still active
+type SessionValidator func(ctx context.Context, userID, sessionID string) error
 
-		parts := strings.Split(authHeader, " ")
-		if len(parts) != 2 || strings.ToLower(parts[0]) != "bearer" {
-			respondWithError(w, http.StatusUnauthorized, "Authorization header format must be 'Bearer {token}'")
-			return
-		}
+var sessionValidator SessionValidator
 
-		tokenString := parts[1]
-		user, err := utils.VerifyToken(tokenString)
-		if err != nil {
-			respondWithError(w, http.StatusUnauthorized, "Invalid or expired token: "+err.Error())
-			return
-		}
+// UseSessionValidator installs the check that lets revoked sessions' tokens be
+// refused before they expire. Without one, any validly signed token is accepted.
+func UseSessionValidator(v SessionValidator) {
+	sessionValidator = v
+}
 
-		ctx := context.WithValue(r.Context(), UserContextKey, user.UserId)
-		next.ServeHTTP(w, r.WithContext(ctx))
-	})
+// PermissionResolver lists the permissions granted by any of roles
+type PermissionResolver func(ctx context.Context, roles []string) []string
+
+var permissionResolver PermissionResolver
+
+// UsePermissionResolver installs the role lookup that fills in a principal's
+// permissions. Until one is installed principals have none.
+func UsePermissionResolver(p PermissionResolver) {
+	permissionResolver = p
 }
 
-// AuthenticateToken for admin role
-func AuthenticateAdminToken(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		authHeader := r.Header.Get("Authorization")
-		if authHeader == "" {
-			respondWithError(w, http.StatusUnauthorized, "Authorization header required")
-			return
-		}
+// MFAPolicy reports whether members of role must sign in with a second factor
+type MFAPolicy func(ctx context.Context, role string) bool
 
-		parts := strings.Split(authHeader, " ")
-		if len(parts) != 2 || strings.ToLower(parts[0]) != "bearer" {
-			respondWithError(w, http.StatusUnauthorized, "Authorization header format must be 'Bearer {token}'")
-			return
-		}
+var mfaPolicy MFAPolicy
 
-		tokenString := parts[1]
+// UseMFAPolicy installs the two-factor policy RequirePermission enforces.
+// Other routes stay open so users can still reach their own enrollment.
+func UseMFAPolicy(p MFAPolicy) {
+	mfaPolicy = p
+}
 
-		// Verify token with admin or superadmin role
-		user, err := utils.VerifyTokenWithRoles(tokenString, []string{"admin", "superadmin"})
+// missingMFA reports whether the caller's role requires a second factor their login did not use
+func missingMFA(r *http.Request, principal *models.Principal) bool {
+	return mfaPolicy != nil && !principal.MFAVerified && mfaPolicy(r.Context(), principal.Role)
+}
 
-		if err != nil {
-			respondWithError(w, http.StatusUnauthorized, "Not Authorized: "+err.Error())
-			return
+// newPrincipal resolves a verified token's claims into the caller they describe
+func newPrincipal(ctx context.Context, claims *utils.Claims) *models.Principal {
+	principal := &models.Principal{
+		UserID:      claims.UserId,
+		Role:        claims.Role,
+		Roles:       claims.RoleNames(),
+		Permissions: []string{},
+		SessionID:   claims.SessionID,
+		MFAVerified: claims.HasAMR(utils.AMRMFA),
+	}
+	if claims.ExpiresAt != nil {
+		principal.ExpiresAt = claims.ExpiresAt.Time
+	}
+	if permissionResolver != nil {
+		principal.Permissions = permissionResolver(ctx, principal.Roles)
+	}
+	return principal
+}
+
+// withClaims checks the token's session and stores the caller in the request context
+func withClaims(r *http.Request, claims *utils.Claims) (*http.Request, *models.Principal, error) {
+	if sessionValidator != nil {
+		if err := sessionValidator(r.Context(), claims.UserId, claims.SessionID); err != nil {
+			return nil, nil, err
 		}
+	}
 
-		ctx := context.WithValue(r.Context(), UserContextKey, user.UserId)
-		next.ServeHTTP(w, r.WithContext(ctx))
-	})
+	principal := newPrincipal(r.Context(), claims)
+	return r.WithContext(WithPrincipal(r.Context(), principal)), principal, nil
 }
 
-// AuthenticateToken for super admin role
-func AuthenticateSuperAdminToken(next http.Handler) http.Handler {
+// authenticate verifies the request's bearer token and its session. On failure
+// it writes the response and returns ok false.
+func authenticate(w http.ResponseWriter, r *http.Request) (*http.Request, *models.Principal, bool) {
+	authHeader := r.Header.Get("Authorization")
+	if authHeader == "" {
+		respondWithError(w, http.StatusUnauthorized, "Authorization header required")
+		return nil, nil, false
+	}
+
+	parts := strings.Split(authHeader, " ")
+	if len(parts) != 2 || strings.ToLower(parts[0]) != "bearer" {
+		respondWithError(w, http.StatusUnauthorized, "Authorization header format must be 'Bearer {token}'")
+		return nil, nil, false
+	}
+
+	tokenString := parts[1]
+	user, err := utils.VerifyToken(tokenString)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Invalid or expired token: "+err.Error())
+		return nil, nil, false
+	}
+
+	r, principal, err := withClaims(r, user)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Session is no longer valid")
+		return nil, nil, false
+	}
+
+	return r, principal, true
+}
+
+func AuthenticateToken(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		authHeader := r.Header.Get("Authorization")
-		if authHeader == "" {
-			respondWithError(w, http.StatusUnauthorized, "Authorization header required")
+		r, _, ok := authenticate(w, r)
+		if !ok {
 			return
 		}
 
-		parts := strings.Split(authHeader, " ")
+		next.ServeHTTP(w, r)
+	})
+}
+
+// RequirePermission authenticates the request and lets it through only when
+// the caller's roles grant every one of permissions
+func RequirePermission(permissions ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			r, principal, ok := authenticate(w, r)
+			if !ok {
+				return
+			}
+
+			if missingMFA(r, principal) {
+				respondWithError(w, http.StatusForbidden, "Two-factor authentication is required for your role; enable it and log in again")
+				return
+			}
+
+			for _, permission := range permissions {
+				if !principal.Can(permission) {
+					respondWithError(w, http.StatusForbidden, "You do not have permission to perform this action")
+					return
+				}
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// OptionalAuthenticateToken identifies the caller when a valid bearer token is
+// sent but lets anonymous requests, and requests with a bad token, through unauthenticated
+func OptionalAuthenticateToken(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		parts := strings.Split(r.Header.Get("Authorization"), " ")
 		if len(parts) != 2 || strings.ToLower(parts[0]) != "bearer" {
-			respondWithError(w, http.StatusUnauthorized, "Authorization header format must be 'Bearer {token}'")
+			next.ServeHTTP(w, r)
 			return
 		}
 
-		tokenString := parts[1]
-		user, err := utils.VerifyTokenWithRoles(tokenString, []string{"superadmin"})
+		user, err := utils.VerifyToken(parts[1])
 		if err != nil {
-			respondWithError(w, http.StatusUnauthorized, "Not Authorized: "+err.Error())
+			next.ServeHTTP(w, r)
 			return
 		}
 
-		ctx := context.WithValue(r.Context(), UserContextKey, user.UserId)
-		next.ServeHTTP(w, r.WithContext(ctx))
+		if authed, _, err := withClaims(r, user); err == nil {
+			r = authed
+		}
+		next.ServeHTTP(w, r)
 	})
 }
 
+// WithPrincipal returns a copy of ctx carrying principal as the caller
+func WithPrincipal(ctx context.Context, principal *models.Principal) context.Context {
+	return context.WithValue(ctx, PrincipalContextKey, principal)
+}
+
+// GetPrincipal returns the caller of an authenticated request
+func GetPrincipal(ctx context.Context) (*models.Principal, bool) {
+	principal, ok := ctx.Value(PrincipalContextKey).(*models.Principal)
+	return principal, ok && principal != nil
+}
+
 func GetUserFromContext(ctx context.Context) (string, bool) {
-	user, ok := ctx.Value(UserContextKey).(string)
-	return user, ok
+	principal, ok := GetPrincipal(ctx)
+	if !ok {
+		return "", false
+	}
+	return principal.UserID, true
+}
+
+// GetSessionFromContext returns the session ID of the authenticated request
+func GetSessionFromContext(ctx context.Context) (string, bool) {
+	principal, ok := GetPrincipal(ctx)
+	if !ok {
+		return "", false
+	}
+	return principal.SessionID, principal.SessionID != ""
 }
 
 func respondWithError(w http.ResponseWriter, code int, message string) {
@@ -0,0 +1,2 @@
+-- Down migration: Drops product_images table and related indexes
+DROP TABLE IF EXISTS product_images CASCADE;
\ No newline at end of file
@@ -0,0 +1,18 @@
+-- Up migration: Creates product_images table
+CREATE TABLE product_images (
+    "imageId" SERIAL PRIMARY KEY,
+    "productId" INTEGER NOT NULL REFERENCES products("productId") ON DELETE CASCADE,
+    "objectKey" VARCHAR(255) NOT NULL,
+    url TEXT NOT NULL,
+    "contentType" VARCHAR(50) NOT NULL,
+    size BIGINT NOT NULL,
+    position INTEGER NOT NULL DEFAULT 0,
+    "isPrimary" BOOLEAN NOT NULL DEFAULT FALSE,
+    "createdAt" TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Create index for listing a product's images in display order
+CREATE INDEX idx_product_images_productId ON product_images("productId", position);
+
+-- A product can have at most one primary image
+CREATE UNIQUE INDEX idx_product_images_primary ON product_images("productId") WHERE "isPrimary";
@@ -0,0 +1,5 @@
+-- Down migration: Removes image dimensions and thumbnail variants
+ALTER TABLE product_images
+    DROP COLUMN IF EXISTS variants,
+    DROP COLUMN IF EXISTS height,
+    DROP COLUMN IF EXISTS width;
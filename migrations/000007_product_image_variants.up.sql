@@ -0,0 +1,5 @@
+-- Up migration: Adds dimensions and generated thumbnail variants to product_images
+ALTER TABLE product_images
+    ADD COLUMN width INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN height INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN variants JSONB NOT NULL DEFAULT '[]'::jsonb;
@@ -0,0 +1,3 @@
+-- Down migration: Removes product SKU and its index
+DROP INDEX IF EXISTS idx_products_sku;
+ALTER TABLE products DROP COLUMN IF EXISTS sku;
@@ -0,0 +1,5 @@
+-- Up migration: Adds a unique SKU to products for bulk import/export
+ALTER TABLE products ADD COLUMN sku VARCHAR(64);
+
+-- Unique index used as the upsert key for imports (NULL SKUs are allowed to repeat)
+CREATE UNIQUE INDEX idx_products_sku ON products(sku);
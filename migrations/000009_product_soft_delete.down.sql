@@ -0,0 +1,3 @@
+-- Down migration: Removes product soft delete support
+DROP INDEX IF EXISTS idx_products_live;
+ALTER TABLE products DROP COLUMN IF EXISTS "deletedAt";
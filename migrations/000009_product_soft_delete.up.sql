@@ -0,0 +1,5 @@
+-- Up migration: Adds soft delete support to products
+ALTER TABLE products ADD COLUMN "deletedAt" TIMESTAMP;
+
+-- Most queries only look at products that have not been deleted
+CREATE INDEX idx_products_live ON products("productId") WHERE "deletedAt" IS NULL;
@@ -0,0 +1,6 @@
+-- Down migration: Removes the product publishing workflow
+DROP INDEX IF EXISTS idx_products_scheduled;
+ALTER TABLE products DROP CONSTRAINT IF EXISTS chk_products_status;
+ALTER TABLE products
+    DROP COLUMN IF EXISTS "publishAt",
+    DROP COLUMN IF EXISTS status;
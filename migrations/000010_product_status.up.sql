@@ -0,0 +1,14 @@
+-- Up migration: Adds a draft/scheduled/published/archived workflow to products
+-- Existing products were already public, so they start out published
+ALTER TABLE products
+    ADD COLUMN status VARCHAR(20) NOT NULL DEFAULT 'published',
+    ADD COLUMN "publishAt" TIMESTAMP;
+
+ALTER TABLE products
+    ADD CONSTRAINT chk_products_status CHECK (status IN ('draft', 'scheduled', 'published', 'archived'));
+
+-- New products start as drafts
+ALTER TABLE products ALTER COLUMN status SET DEFAULT 'draft';
+
+-- Create index for the scheduler's lookup of products due to go live
+CREATE INDEX idx_products_scheduled ON products("publishAt") WHERE status = 'scheduled';
@@ -0,0 +1,4 @@
+-- Down migration: Removes product modification tracking
+ALTER TABLE products
+    DROP COLUMN IF EXISTS version,
+    DROP COLUMN IF EXISTS "updatedAt";
@@ -0,0 +1,7 @@
+-- Up migration: Tracks product modifications for optimistic concurrency
+ALTER TABLE products
+    ADD COLUMN "updatedAt" TIMESTAMP NOT NULL DEFAULT NOW(),
+    ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
+
+-- Products have not been touched since they were last written
+UPDATE products SET "updatedAt" = "createdAt";
@@ -0,0 +1,5 @@
+-- Down migration: Drops product price history and sale pricing
+ALTER TABLE products
+    DROP COLUMN IF EXISTS "saleEndsAt",
+    DROP COLUMN IF EXISTS "salePrice";
+DROP TABLE IF EXISTS product_prices;
@@ -0,0 +1,26 @@
+-- Up migration: Creates product_prices history and adds sale pricing to products
+CREATE TABLE product_prices (
+    "priceId" SERIAL PRIMARY KEY,
+    "productId" INTEGER NOT NULL REFERENCES products("productId") ON DELETE CASCADE,
+    kind VARCHAR(10) NOT NULL CHECK (kind IN ('regular', 'sale')),
+    price NUMERIC(10, 2) NOT NULL CHECK (price > 0),
+    "effectiveFrom" TIMESTAMP NOT NULL,
+    "effectiveTo" TIMESTAMP,
+    "appliedAt" TIMESTAMP,
+    "createdAt" TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CHECK ("effectiveTo" IS NULL OR "effectiveTo" >= "effectiveFrom")
+);
+
+-- Create index for listing a product's price history
+CREATE INDEX idx_product_prices_productId ON product_prices("productId", "effectiveFrom");
+
+-- Create index for the scheduler's lookup of regular prices due to take effect
+CREATE INDEX idx_product_prices_pending ON product_prices("effectiveFrom") WHERE kind = 'regular' AND "appliedAt" IS NULL;
+
+ALTER TABLE products
+    ADD COLUMN "salePrice" NUMERIC(10, 2),
+    ADD COLUMN "saleEndsAt" TIMESTAMP;
+
+-- Seed the history with each product's current price
+INSERT INTO product_prices ("productId", kind, price, "effectiveFrom", "appliedAt")
+SELECT "productId", 'regular', price, "createdAt", "createdAt" FROM products;
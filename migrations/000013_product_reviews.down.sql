@@ -0,0 +1,5 @@
+-- Down migration: Drops product reviews and ratings
+ALTER TABLE products
+    DROP COLUMN IF EXISTS "ratingCount",
+    DROP COLUMN IF EXISTS "ratingAverage";
+DROP TABLE IF EXISTS product_reviews;
@@ -0,0 +1,25 @@
+-- Up migration: Creates product_reviews table and denormalized ratings on products
+CREATE TABLE product_reviews (
+    "reviewId" SERIAL PRIMARY KEY,
+    "productId" INTEGER NOT NULL REFERENCES products("productId") ON DELETE CASCADE,
+    "userId" VARCHAR(100) NOT NULL REFERENCES users("userId") ON DELETE CASCADE,
+    "orderId" INTEGER NOT NULL,
+    rating SMALLINT NOT NULL CHECK (rating BETWEEN 1 AND 5),
+    title VARCHAR(200) NOT NULL DEFAULT '',
+    body TEXT NOT NULL DEFAULT '',
+    status VARCHAR(20) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'approved', 'rejected', 'hidden')),
+    "moderatedBy" VARCHAR(100),
+    "moderatedAt" TIMESTAMP,
+    "createdAt" TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE ("productId", "userId")
+);
+
+-- Create index for listing a product's approved reviews, newest first
+CREATE INDEX idx_product_reviews_product ON product_reviews("productId", status, "createdAt" DESC);
+
+-- Create index for the moderation queue
+CREATE INDEX idx_product_reviews_status ON product_reviews(status, "createdAt");
+
+ALTER TABLE products
+    ADD COLUMN "ratingAverage" NUMERIC(3, 2) NOT NULL DEFAULT 0,
+    ADD COLUMN "ratingCount" INTEGER NOT NULL DEFAULT 0;
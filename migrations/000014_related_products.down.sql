@@ -0,0 +1,4 @@
+-- Down migration: Drops related products and product categories
+DROP TABLE IF EXISTS product_related;
+DROP INDEX IF EXISTS idx_products_category;
+ALTER TABLE products DROP COLUMN IF EXISTS category;
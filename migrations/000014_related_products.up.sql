@@ -0,0 +1,14 @@
+-- Up migration: Adds product categories and precomputed related products
+ALTER TABLE products ADD COLUMN category VARCHAR(100);
+
+-- Create index for same-category lookups
+CREATE INDEX idx_products_category ON products(category) WHERE "deletedAt" IS NULL;
+
+-- Products most often bought together with each product, rebuilt periodically from orders
+CREATE TABLE product_related (
+    "productId" INTEGER NOT NULL REFERENCES products("productId") ON DELETE CASCADE,
+    "relatedProductId" INTEGER NOT NULL REFERENCES products("productId") ON DELETE CASCADE,
+    score INTEGER NOT NULL,
+    "computedAt" TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY ("productId", "relatedProductId")
+);
@@ -0,0 +1,2 @@
+-- Down migration: Drops recently_viewed table
+DROP TABLE IF EXISTS recently_viewed;
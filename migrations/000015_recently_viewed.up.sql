@@ -0,0 +1,10 @@
+-- Up migration: Creates recently_viewed table, the durable copy of each user's viewing history
+CREATE TABLE recently_viewed (
+    "userId" VARCHAR(100) NOT NULL REFERENCES users("userId") ON DELETE CASCADE,
+    "productId" INTEGER NOT NULL REFERENCES products("productId") ON DELETE CASCADE,
+    "viewedAt" TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY ("userId", "productId")
+);
+
+-- Create index for listing a user's views, newest first
+CREATE INDEX idx_recently_viewed_user ON recently_viewed("userId", "viewedAt" DESC);
@@ -0,0 +1,3 @@
+-- Down migration: Drops back-in-stock subscriptions and product stock levels
+DROP TABLE IF EXISTS stock_subscriptions;
+ALTER TABLE products DROP COLUMN IF EXISTS stock;
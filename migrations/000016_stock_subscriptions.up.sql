@@ -0,0 +1,13 @@
+-- Up migration: Adds product stock levels and back-in-stock subscriptions
+ALTER TABLE products ADD COLUMN stock INTEGER NOT NULL DEFAULT 0 CHECK (stock >= 0);
+
+CREATE TABLE stock_subscriptions (
+    "subscriptionId" SERIAL PRIMARY KEY,
+    "productId" INTEGER NOT NULL REFERENCES products("productId") ON DELETE CASCADE,
+    "userId" VARCHAR(100) NOT NULL REFERENCES users("userId") ON DELETE CASCADE,
+    "createdAt" TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    "notifiedAt" TIMESTAMP
+);
+
+-- A user has at most one pending subscription per product
+CREATE UNIQUE INDEX idx_stock_subscriptions_pending ON stock_subscriptions("productId", "userId") WHERE "notifiedAt" IS NULL;
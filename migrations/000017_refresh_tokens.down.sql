@@ -0,0 +1,2 @@
+-- Down migration: Drops refresh tokens
+DROP TABLE IF EXISTS refresh_tokens;
@@ -0,0 +1,14 @@
+-- Up migration: Adds rotating refresh tokens, grouped into families that share a session
+CREATE TABLE refresh_tokens (
+    "tokenId" SERIAL PRIMARY KEY,
+    "tokenHash" VARCHAR(64) NOT NULL UNIQUE,
+    "familyId" VARCHAR(64) NOT NULL,
+    "userId" VARCHAR(100) NOT NULL REFERENCES users("userId") ON DELETE CASCADE,
+    "expiresAt" TIMESTAMP NOT NULL,
+    "usedAt" TIMESTAMP,
+    "revokedAt" TIMESTAMP,
+    "createdAt" TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_refresh_tokens_family ON refresh_tokens("familyId");
+CREATE INDEX idx_refresh_tokens_user ON refresh_tokens("userId");
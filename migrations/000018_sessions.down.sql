@@ -0,0 +1,2 @@
+-- Down migration: Drops login sessions
+DROP TABLE IF EXISTS sessions;
@@ -0,0 +1,13 @@
+-- Up migration: Adds login sessions. A session's ID is the familyId of its refresh tokens.
+CREATE TABLE sessions (
+    "sessionId" VARCHAR(64) PRIMARY KEY,
+    "userId" VARCHAR(100) NOT NULL REFERENCES users("userId") ON DELETE CASCADE,
+    device VARCHAR(200) NOT NULL DEFAULT '',
+    ip VARCHAR(64) NOT NULL DEFAULT '',
+    "userAgent" TEXT NOT NULL DEFAULT '',
+    "createdAt" TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    "lastSeenAt" TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    "revokedAt" TIMESTAMP
+);
+
+CREATE INDEX idx_sessions_active_user ON sessions("userId") WHERE "revokedAt" IS NULL;
@@ -0,0 +1,4 @@
+-- Down migration: Drops email verification and action tokens
+DROP TABLE IF EXISTS used_action_tokens;
+ALTER TABLE users DROP COLUMN IF EXISTS "emailVerifiedAt";
+ALTER TABLE users DROP COLUMN IF EXISTS "emailVerified";
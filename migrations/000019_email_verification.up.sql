@@ -0,0 +1,16 @@
+-- Up migration: Adds email verification and single-use action tokens
+ALTER TABLE users ADD COLUMN "emailVerified" BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE users ADD COLUMN "emailVerifiedAt" TIMESTAMP;
+
+-- Accounts created before verification existed keep working
+UPDATE users SET "emailVerified" = TRUE, "emailVerifiedAt" = NOW();
+
+-- IDs of signed one-time tokens (email verification, password reset) that have been used
+CREATE TABLE used_action_tokens (
+    jti VARCHAR(64) PRIMARY KEY,
+    purpose VARCHAR(50) NOT NULL,
+    "expiresAt" TIMESTAMP NOT NULL,
+    "usedAt" TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_used_action_tokens_expires ON used_action_tokens("expiresAt");
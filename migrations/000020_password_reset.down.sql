@@ -0,0 +1,2 @@
+-- Down migration: Drops password reset tokens
+DROP TABLE IF EXISTS password_reset_tokens;
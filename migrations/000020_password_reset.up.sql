@@ -0,0 +1,11 @@
+-- Up migration: Adds single-use password reset tokens, stored only as hashes
+CREATE TABLE password_reset_tokens (
+    "tokenId" SERIAL PRIMARY KEY,
+    "tokenHash" VARCHAR(64) NOT NULL UNIQUE,
+    "userId" VARCHAR(100) NOT NULL REFERENCES users("userId") ON DELETE CASCADE,
+    "expiresAt" TIMESTAMP NOT NULL,
+    "usedAt" TIMESTAMP,
+    "createdAt" TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_password_reset_tokens_user ON password_reset_tokens("userId");
@@ -0,0 +1,3 @@
+-- Down migration: Drops self-service profile fields
+ALTER TABLE users DROP COLUMN IF EXISTS phone;
+ALTER TABLE users DROP COLUMN IF EXISTS "displayName";
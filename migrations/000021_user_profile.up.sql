@@ -0,0 +1,3 @@
+-- Up migration: Adds self-service profile fields to users
+ALTER TABLE users ADD COLUMN "displayName" VARCHAR(100) NOT NULL DEFAULT '';
+ALTER TABLE users ADD COLUMN phone VARCHAR(32) NOT NULL DEFAULT '';
@@ -0,0 +1,5 @@
+-- Down migration: Drops TOTP two-factor authentication
+ALTER TABLE sessions DROP COLUMN IF EXISTS "mfaVerified";
+DROP TABLE IF EXISTS mfa_required_roles;
+DROP TABLE IF EXISTS mfa_recovery_codes;
+DROP TABLE IF EXISTS user_totp;
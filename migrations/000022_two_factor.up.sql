@@ -0,0 +1,27 @@
+-- Up migration: Adds TOTP two-factor authentication
+CREATE TABLE user_totp (
+    "userId" VARCHAR(100) PRIMARY KEY REFERENCES users("userId") ON DELETE CASCADE,
+    secret VARCHAR(64) NOT NULL,
+    "enabledAt" TIMESTAMP,
+    -- Time step of the last accepted code, so a code cannot be replayed
+    "lastUsedStep" BIGINT NOT NULL DEFAULT 0,
+    "createdAt" TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE mfa_recovery_codes (
+    "codeId" SERIAL PRIMARY KEY,
+    "userId" VARCHAR(100) NOT NULL REFERENCES users("userId") ON DELETE CASCADE,
+    "codeHash" VARCHAR(64) NOT NULL,
+    "usedAt" TIMESTAMP,
+    "createdAt" TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE ("userId", "codeHash")
+);
+
+-- Roles whose members must sign in with a second factor to use their privileges
+CREATE TABLE mfa_required_roles (
+    role VARCHAR(50) PRIMARY KEY,
+    "createdAt" TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Whether a session was started with a second factor
+ALTER TABLE sessions ADD COLUMN "mfaVerified" BOOLEAN NOT NULL DEFAULT FALSE;
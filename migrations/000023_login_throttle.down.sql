@@ -0,0 +1,2 @@
+-- Down migration: Drops failed login tracking
+DROP TABLE IF EXISTS login_failures;
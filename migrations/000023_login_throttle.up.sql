@@ -0,0 +1,9 @@
+-- Up migration: Adds failed login tracking per user ID and per IP address
+CREATE TABLE login_failures (
+    -- "user:<userId>" or "ip:<address>"
+    subject VARCHAR(200) PRIMARY KEY,
+    "failedCount" INT NOT NULL DEFAULT 0,
+    "lastFailedAt" TIMESTAMP NOT NULL
+);
+
+CREATE INDEX idx_login_failures_last_failed ON login_failures("lastFailedAt");
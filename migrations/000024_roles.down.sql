@@ -0,0 +1,4 @@
+-- Down migration: Drops roles and permissions
+ALTER TABLE users DROP CONSTRAINT IF EXISTS fk_users_role;
+DROP TABLE IF EXISTS role_permissions;
+DROP TABLE IF EXISTS roles;
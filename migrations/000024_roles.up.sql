@@ -0,0 +1,35 @@
+-- Up migration: Adds roles and the permissions they grant
+CREATE TABLE roles (
+    name VARCHAR(50) PRIMARY KEY,
+    description VARCHAR(255) NOT NULL DEFAULT '',
+    -- Built-in roles cannot be deleted
+    "builtIn" BOOLEAN NOT NULL DEFAULT FALSE,
+    "createdAt" TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    "updatedAt" TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE role_permissions (
+    role VARCHAR(50) NOT NULL REFERENCES roles(name) ON DELETE CASCADE,
+    permission VARCHAR(100) NOT NULL,
+    PRIMARY KEY (role, permission)
+);
+
+INSERT INTO roles (name, description, "builtIn") VALUES
+    ('user', 'Customer account', TRUE),
+    ('admin', 'Store staff', TRUE),
+    ('superadmin', 'Full access', TRUE);
+
+-- Admins keep what the admin-only routes allowed them before
+INSERT INTO role_permissions (role, permission) VALUES
+    ('admin', 'products:read'),
+    ('admin', 'products:write'),
+    ('admin', 'stock:write'),
+    ('admin', 'orders:update'),
+    ('admin', 'reviews:moderate'),
+    ('admin', 'users:write'),
+    ('superadmin', '*');
+
+-- Keep any other role already assigned to a user so the foreign key holds
+INSERT INTO roles (name) SELECT DISTINCT role FROM users ON CONFLICT (name) DO NOTHING;
+
+ALTER TABLE users ADD CONSTRAINT fk_users_role FOREIGN KEY (role) REFERENCES roles(name) ON UPDATE CASCADE;
@@ -0,0 +1,2 @@
+-- Down migration: Stores publishAt as a wall-clock time again
+ALTER TABLE products ALTER COLUMN "publishAt" TYPE TIMESTAMP;
@@ -0,0 +1,4 @@
+-- Up migration: Stores publishAt as an instant so a client's UTC offset is kept
+-- Existing values are read in the session time zone, which is what the app
+-- wrote when it ran in the same zone as the database
+ALTER TABLE products ALTER COLUMN "publishAt" TYPE TIMESTAMPTZ;
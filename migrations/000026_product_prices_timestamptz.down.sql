@@ -0,0 +1,7 @@
+-- Down migration: Stores price schedules as wall-clock times again
+ALTER TABLE products ALTER COLUMN "saleEndsAt" TYPE TIMESTAMP;
+
+ALTER TABLE product_prices
+    ALTER COLUMN "appliedAt" TYPE TIMESTAMP,
+    ALTER COLUMN "effectiveTo" TYPE TIMESTAMP,
+    ALTER COLUMN "effectiveFrom" TYPE TIMESTAMP;
@@ -0,0 +1,9 @@
+-- Up migration: Stores price schedules as instants so a client's UTC offset is kept
+-- Existing values are read in the session time zone, which is what the app
+-- wrote when it ran in the same zone as the database
+ALTER TABLE product_prices
+    ALTER COLUMN "effectiveFrom" TYPE TIMESTAMPTZ,
+    ALTER COLUMN "effectiveTo" TYPE TIMESTAMPTZ,
+    ALTER COLUMN "appliedAt" TYPE TIMESTAMPTZ;
+
+ALTER TABLE products ALTER COLUMN "saleEndsAt" TYPE TIMESTAMPTZ;
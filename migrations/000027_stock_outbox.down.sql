@@ -0,0 +1,12 @@
+-- Down migration: Drops the notification outbox and makes stock required again
+DROP INDEX IF EXISTS idx_stock_subscriptions_outbox;
+ALTER TABLE stock_subscriptions
+    DROP COLUMN IF EXISTS "lastError",
+    DROP COLUMN IF EXISTS "nextAttemptAt",
+    DROP COLUMN IF EXISTS attempts,
+    DROP COLUMN IF EXISTS "queuedAt";
+
+UPDATE products SET stock = 0 WHERE stock IS NULL;
+ALTER TABLE products
+    ALTER COLUMN stock SET DEFAULT 0,
+    ALTER COLUMN stock SET NOT NULL;
@@ -0,0 +1,26 @@
+-- Up migration: Makes stock optional and queues back-in-stock notifications durably
+-- Stock was added with a default of 0, which marked every existing product as
+-- sold out. NULL now means stock is not tracked for the product.
+ALTER TABLE products
+    ALTER COLUMN stock DROP NOT NULL,
+    ALTER COLUMN stock DROP DEFAULT;
+
+-- Only zeros that may still be the old default become untracked. Customers can
+-- only subscribe to a sold-out product, and a notified subscription means the
+-- product was restocked through the stock API, so a product with any
+-- subscription is tracked and its zero is kept.
+UPDATE products p SET stock = NULL
+WHERE p.stock = 0
+  AND NOT EXISTS (SELECT 1 FROM stock_subscriptions s WHERE s."productId" = p."productId");
+
+-- A restock queues its pending subscriptions in the same transaction; they are
+-- marked notified only once delivery has succeeded
+ALTER TABLE stock_subscriptions
+    ADD COLUMN "queuedAt" TIMESTAMPTZ,
+    ADD COLUMN attempts INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN "nextAttemptAt" TIMESTAMPTZ,
+    ADD COLUMN "lastError" TEXT;
+
+-- Create index for the delivery worker's lookup of queued notifications
+CREATE INDEX idx_stock_subscriptions_outbox ON stock_subscriptions("nextAttemptAt")
+    WHERE "queuedAt" IS NOT NULL AND "notifiedAt" IS NULL;
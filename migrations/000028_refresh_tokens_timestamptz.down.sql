@@ -0,0 +1,6 @@
+-- Down migration: Stores refresh token times as wall-clock times again
+ALTER TABLE refresh_tokens
+    ALTER COLUMN "createdAt" TYPE TIMESTAMP,
+    ALTER COLUMN "revokedAt" TYPE TIMESTAMP,
+    ALTER COLUMN "usedAt" TYPE TIMESTAMP,
+    ALTER COLUMN "expiresAt" TYPE TIMESTAMP;
@@ -0,0 +1,9 @@
+-- Up migration: Stores refresh token times as instants, so expiry checks in the
+-- app and NOW() in SQL agree whatever time zone either runs in
+-- Existing values are read in the session time zone, which is what the app
+-- wrote when it ran in the same zone as the database
+ALTER TABLE refresh_tokens
+    ALTER COLUMN "expiresAt" TYPE TIMESTAMPTZ,
+    ALTER COLUMN "usedAt" TYPE TIMESTAMPTZ,
+    ALTER COLUMN "revokedAt" TYPE TIMESTAMPTZ,
+    ALTER COLUMN "createdAt" TYPE TIMESTAMPTZ;
@@ -0,0 +1,4 @@
+-- Down migration: Stores used action token times as wall-clock times again
+ALTER TABLE used_action_tokens
+    ALTER COLUMN "usedAt" TYPE TIMESTAMP,
+    ALTER COLUMN "expiresAt" TYPE TIMESTAMP;
@@ -0,0 +1,7 @@
+-- Up migration: Stores used action token times as instants, so pruning with
+-- NOW() agrees with the expiry the app writes whatever time zone either runs in
+-- Existing values are read in the session time zone, which is what the app
+-- wrote when it ran in the same zone as the database
+ALTER TABLE used_action_tokens
+    ALTER COLUMN "expiresAt" TYPE TIMESTAMPTZ,
+    ALTER COLUMN "usedAt" TYPE TIMESTAMPTZ;
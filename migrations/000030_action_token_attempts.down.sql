@@ -0,0 +1,2 @@
+-- Down migration: Removes action token attempt counters
+DROP TABLE IF EXISTS action_token_attempts;
@@ -0,0 +1,9 @@
+-- Up migration: Counts attempts against signed one-time tokens, such as MFA
+-- login challenges, so their limit holds even when the cache is down
+CREATE TABLE action_token_attempts (
+    jti VARCHAR(64) PRIMARY KEY,
+    attempts INTEGER NOT NULL,
+    "expiresAt" TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX idx_action_token_attempts_expires ON action_token_attempts("expiresAt");
@@ -0,0 +1,3 @@
+-- Down migration: Stores the last failed login as a wall-clock time again
+ALTER TABLE login_failures
+    ALTER COLUMN "lastFailedAt" TYPE TIMESTAMP;
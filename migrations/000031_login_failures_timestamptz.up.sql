@@ -0,0 +1,6 @@
+-- Up migration: Stores the last failed login as an instant, so backoff and the
+-- reset window agree with the times the app writes whatever time zone either runs in
+-- Existing values are read in the session time zone, which is what the app
+-- wrote when it ran in the same zone as the database
+ALTER TABLE login_failures
+    ALTER COLUMN "lastFailedAt" TYPE TIMESTAMPTZ;
@@ -0,0 +1,10 @@
+package models
+
+import "time"
+
+// LoginFailures counts recent failed logins for a user ID or an IP address
+type LoginFailures struct {
+	Subject      string    `json:"subject"`
+	FailedCount  int       `json:"failedCount"`
+	LastFailedAt time.Time `json:"lastFailedAt"`
+}
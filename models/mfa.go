@@ -0,0 +1,37 @@
+package models
+
+import "time"
+
+// TOTPSecret is a user's authenticator secret. It is pending until the user
+// proves their app is set up by entering a code.
+type TOTPSecret struct {
+	UserID       string     `json:"userId"`
+	Secret       string     `json:"-"`
+	EnabledAt    *time.Time `json:"enabledAt,omitempty"`
+	LastUsedStep int64      `json:"-"`
+	CreatedAt    time.Time  `json:"createdAt"`
+}
+
+// TOTPEnrollment is what a user needs to add the account to an authenticator app
+type TOTPEnrollment struct {
+	Secret string `json:"secret"`
+	URI    string `json:"otpauthUri"`
+}
+
+// MFAStatus describes a user's two-factor setup
+type MFAStatus struct {
+	Enabled                bool       `json:"enabled"`
+	EnabledAt              *time.Time `json:"enabledAt,omitempty"`
+	Required               bool       `json:"required"`
+	RecoveryCodesRemaining int        `json:"recoveryCodesRemaining"`
+}
+
+// LoginResult is the response to a password login. It holds tokens, or, when
+// the account has two-factor authentication, a challenge token to exchange
+// together with a code at /users/login/mfa.
+type LoginResult struct {
+	*AuthTokens
+	MFARequired  bool   `json:"mfaRequired,omitempty"`
+	MFAToken     string `json:"mfaToken,omitempty"`
+	MFAExpiresIn int    `json:"mfaExpiresIn,omitempty"`
+}
@@ -0,0 +1,41 @@
+package models
+
+import "time"
+
+// Principal is the authenticated caller of a request, built from a verified
+// access token with its roles already resolved to permissions
+type Principal struct {
+	UserID      string
+	Role        string
+	Roles       []string
+	Permissions []string
+	SessionID   string
+	// MFAVerified is set when the login completed a second factor
+	MFAVerified bool
+	ExpiresAt   time.Time
+}
+
+// HasRole reports whether the caller holds role
+func (p *Principal) HasRole(role string) bool {
+	for _, r := range p.Roles {
+		if r == role {
+			return true
+		}
+	}
+	return p.Role == role
+}
+
+// Can reports whether the caller's roles grant permission
+func (p *Principal) Can(permission string) bool {
+	for _, granted := range p.Permissions {
+		if granted == permission || granted == PermAll {
+			return true
+		}
+	}
+	return false
+}
+
+// Is reports whether the caller is the user userID
+func (p *Principal) Is(userID string) bool {
+	return p.UserID != "" && p.UserID == userID
+}
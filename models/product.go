@@ -2,19 +2,86 @@ package models
 
 import "time"
 
+// Product publishing states. Only published products are visible to customers.
+const (
+	ProductStatusDraft     = "draft"
+	ProductStatusScheduled = "scheduled"
+	ProductStatusPublished = "published"
+	ProductStatusArchived  = "archived"
+)
+
 type Product struct {
-	ProductID   int       `json:"id"`
-	Name        string    `json:"name"`
-	Description string    `json:"description"`
-	Image       string    `json:"image"`
-	Price       float64   `json:"price"`
-	CreatedAt   time.Time `json:"createdAt"`
+	ProductID   int     `json:"id"`
+	SKU         string  `json:"sku,omitempty"`
+	Name        string  `json:"name"`
+	Description string  `json:"description"`
+	Category    string  `json:"category,omitempty"`
+	Image       string  `json:"image"`
+	Price       float64 `json:"price"`
+	// Stock is nil when it is not tracked for the product
+	Stock      *int       `json:"stock"`
+	SalePrice  *float64   `json:"salePrice,omitempty"`
+	SaleEndsAt *time.Time `json:"saleEndsAt,omitempty"`
+	// Rating aggregates cover approved reviews only
+	RatingAverage float64    `json:"ratingAverage"`
+	RatingCount   int        `json:"ratingCount"`
+	Status        string     `json:"status"`
+	PublishAt     *time.Time `json:"publishAt,omitempty"`
+	CreatedAt     time.Time  `json:"createdAt"`
+	UpdatedAt     time.Time  `json:"updatedAt"`
+	Version       int        `json:"version"`
+	DeletedAt     *time.Time `json:"deletedAt,omitempty"`
+
+	Images []ProductImage    `json:"images,omitempty"`
+	SrcSet map[string]string `json:"srcset,omitempty"`
 }
 
+// EffectivePrice returns the price a customer pays at the given time: the sale
+// price while a sale is running, otherwise the regular price
+func (p *Product) EffectivePrice(now time.Time) float64 {
+	if p.SalePrice != nil && (p.SaleEndsAt == nil || now.Before(*p.SaleEndsAt)) {
+		return *p.SalePrice
+	}
+	return p.Price
+}
+
+// ProductPatch carries a partial product update. Nil fields are left unchanged;
+// an empty category removes the product from its category.
+type ProductPatch struct {
+	SKU         *string  `json:"sku"`
+	Name        *string  `json:"name"`
+	Description *string  `json:"description"`
+	Category    *string  `json:"category"`
+	Image       *string  `json:"image"`
+	Price       *float64 `json:"price"`
+}
 
 type PaginatedProductResponse struct {
 	Products []Product `json:"products"`
 	Total    int       `json:"total"`
 	Page     int       `json:"page"`
 	Limit    int       `json:"limit"`
-}
\ No newline at end of file
+}
+
+// ProductImportReport summarizes a bulk product import
+type ProductImportReport struct {
+	Processed int                  `json:"processed"`
+	Created   int                  `json:"created"`
+	Updated   int                  `json:"updated"`
+	Failed    int                  `json:"failed"`
+	Errors    []ProductImportError `json:"errors"`
+}
+
+// ProductImportError describes why a single import row was rejected
+type ProductImportError struct {
+	Row   int    `json:"row"`
+	SKU   string `json:"sku,omitempty"`
+	Error string `json:"error"`
+}
+
+// RelatedProductsResponse lists cross-sell suggestions. Source is "purchases"
+// when they come from co-purchase history and "category" for the fallback.
+type RelatedProductsResponse struct {
+	Source   string    `json:"source"`
+	Products []Product `json:"products"`
+}
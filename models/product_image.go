@@ -0,0 +1,30 @@
+package models
+
+import "time"
+
+type ProductImage struct {
+	ImageID     int            `json:"id"`
+	ProductID   int            `json:"productId"`
+	ObjectKey   string         `json:"-"`
+	URL         string         `json:"url"`
+	ContentType string         `json:"contentType"`
+	Size        int64          `json:"size"`
+	Width       int            `json:"width"`
+	Height      int            `json:"height"`
+	Position    int            `json:"position"`
+	IsPrimary   bool           `json:"isPrimary"`
+	Variants    []ImageVariant `json:"variants"`
+	CreatedAt   time.Time      `json:"createdAt"`
+
+	// SrcSet maps a content type to a srcset attribute value, e.g. "a.webp 320w, b.webp 640w"
+	SrcSet map[string]string `json:"srcset,omitempty"`
+}
+
+// ImageVariant is a server-generated thumbnail of a product image
+type ImageVariant struct {
+	URL         string `json:"url"`
+	Width       int    `json:"width"`
+	Height      int    `json:"height"`
+	ContentType string `json:"contentType"`
+	Size        int64  `json:"size"`
+}
@@ -0,0 +1,24 @@
+package models
+
+import "time"
+
+// Product price kinds. A sale price temporarily overrides the regular price.
+const (
+	PriceKindRegular = "regular"
+	PriceKindSale    = "sale"
+)
+
+// ProductPrice is one entry in a product's price history. A regular price is
+// in force from EffectiveFrom until the next regular price replaces it; AppliedAt
+// records when it was copied onto the product. A sale price runs from
+// EffectiveFrom to EffectiveTo.
+type ProductPrice struct {
+	PriceID       int        `json:"id"`
+	ProductID     int        `json:"productId"`
+	Kind          string     `json:"kind"`
+	Price         float64    `json:"price"`
+	EffectiveFrom time.Time  `json:"effectiveFrom"`
+	EffectiveTo   *time.Time `json:"effectiveTo,omitempty"`
+	AppliedAt     *time.Time `json:"appliedAt,omitempty"`
+	CreatedAt     time.Time  `json:"createdAt"`
+}
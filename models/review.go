@@ -0,0 +1,35 @@
+package models
+
+import "time"
+
+// Review moderation states. Only approved reviews are public and counted in ratings.
+const (
+	ReviewStatusPending  = "pending"
+	ReviewStatusApproved = "approved"
+	ReviewStatusRejected = "rejected"
+	ReviewStatusHidden   = "hidden"
+)
+
+type Review struct {
+	ReviewID    int        `json:"id"`
+	ProductID   int        `json:"productId"`
+	UserID      string     `json:"userId,omitempty"`
+	AuthorName  string     `json:"authorName"`
+	OrderID     int        `json:"orderId,omitempty"`
+	Rating      int        `json:"rating"`
+	Title       string     `json:"title"`
+	Body        string     `json:"body"`
+	Status      string     `json:"status,omitempty"`
+	ModeratedBy string     `json:"moderatedBy,omitempty"`
+	ModeratedAt *time.Time `json:"moderatedAt,omitempty"`
+	CreatedAt   time.Time  `json:"createdAt"`
+}
+
+type PaginatedReviewResponse struct {
+	Reviews       []Review `json:"reviews"`
+	Total         int      `json:"total"`
+	Page          int      `json:"page"`
+	Limit         int      `json:"limit"`
+	RatingAverage float64  `json:"ratingAverage"`
+	RatingCount   int      `json:"ratingCount"`
+}
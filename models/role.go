@@ -0,0 +1,62 @@
+package models
+
+import "time"
+
+// Permissions checked by RequirePermission. A role holding PermAll has every
+// permission, including ones added later.
+const (
+	PermAll             = "*"
+	PermProductsRead    = "products:read"
+	PermProductsWrite   = "products:write"
+	PermProductsPurge   = "products:purge"
+	PermStockWrite      = "stock:write"
+	PermOrdersUpdate    = "orders:update"
+	PermReviewsModerate = "reviews:moderate"
+	PermUsersWrite      = "users:write"
+	PermUsersDelete     = "users:delete"
+	PermUsersAssignRole = "users:assign-role"
+	PermSecurityManage  = "security:manage"
+	PermRolesManage     = "roles:manage"
+)
+
+// PermissionInfo describes a permission for the role editor
+type PermissionInfo struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+// Permissions lists every permission a role can be granted
+var Permissions = []PermissionInfo{
+	{PermAll, "Every permission, including ones added later"},
+	{PermProductsRead, "View products in the admin catalog, exports, deleted products and price history"},
+	{PermProductsWrite, "Create, edit, delete and restore products, their images and prices"},
+	{PermProductsPurge, "Permanently delete products"},
+	{PermStockWrite, "Update product stock"},
+	{PermOrdersUpdate, "Update orders"},
+	{PermReviewsModerate, "Moderate product reviews"},
+	{PermUsersWrite, "Edit and delete customer accounts, sign them out and lift lockouts"},
+	{PermUsersDelete, "Delete any account except superadmins"},
+	{PermUsersAssignRole, "Change the role of a user"},
+	{PermSecurityManage, "Manage security policies such as required two-factor authentication"},
+	{PermRolesManage, "Create, edit and delete roles"},
+}
+
+// IsPermission reports whether name is a known permission
+func IsPermission(name string) bool {
+	for _, p := range Permissions {
+		if p.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Role is a named set of permissions assigned to users
+type Role struct {
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	Permissions []string  `json:"permissions"`
+	BuiltIn     bool      `json:"builtIn"`
+	CreatedAt   time.Time `json:"createdAt"`
+	UpdatedAt   time.Time `json:"updatedAt"`
+}
@@ -0,0 +1,26 @@
+package models
+
+import "time"
+
+// Session is a login on one device. Access tokens carry its ID in the sid claim
+// and stop working as soon as it is revoked.
+type Session struct {
+	SessionID string `json:"sessionId"`
+	UserID    string `json:"userId"`
+	Device    string `json:"device"`
+	IP        string `json:"ip"`
+	UserAgent string `json:"userAgent"`
+	// MFAVerified is set when the login completed a second factor
+	MFAVerified bool       `json:"mfaVerified"`
+	CreatedAt   time.Time  `json:"createdAt"`
+	LastSeenAt  time.Time  `json:"lastSeenAt"`
+	RevokedAt   *time.Time `json:"revokedAt,omitempty"`
+	Current     bool       `json:"current"`
+}
+
+// ClientInfo describes where a request came from
+type ClientInfo struct {
+	IP        string
+	UserAgent string
+	Device    string
+}
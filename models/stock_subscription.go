@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+// StockSubscription asks for a notification when a product is back in stock
+type StockSubscription struct {
+	SubscriptionID int        `json:"id"`
+	ProductID      int        `json:"productId"`
+	UserID         string     `json:"userId"`
+	Email          string     `json:"-"`
+	CreatedAt      time.Time  `json:"createdAt"`
+	NotifiedAt     *time.Time `json:"notifiedAt,omitempty"`
+}
+
+// BackInStockNotification is a queued subscription claimed for delivery
+type BackInStockNotification struct {
+	StockSubscription
+	ProductName string
+	Stock       int
+	Attempts    int
+}
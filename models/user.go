@@ -5,11 +5,39 @@ import (
 )
 
 type User struct {
-	UserId    string    `json:"userId"`
-	Email     string    `json:"email"`
-	Password  string    `json:"password"`
-	Role      string    `json:"role"`
-	CreatedAt time.Time `json:"createdAt"`
+	UserId          string     `json:"userId"`
+	Email           string     `json:"email"`
+	Password        string     `json:"password"`
+	Role            string     `json:"role"`
+	DisplayName     string     `json:"displayName"`
+	Phone           string     `json:"phone"`
+	EmailVerified   bool       `json:"emailVerified"`
+	EmailVerifiedAt *time.Time `json:"emailVerifiedAt,omitempty"`
+	CreatedAt       time.Time  `json:"createdAt"`
+}
+
+// Profile is the part of a user account its owner can see at /users/me
+type Profile struct {
+	UserId        string    `json:"userId"`
+	Email         string    `json:"email"`
+	EmailVerified bool      `json:"emailVerified"`
+	DisplayName   string    `json:"displayName"`
+	Phone         string    `json:"phone"`
+	Role          string    `json:"role"`
+	CreatedAt     time.Time `json:"createdAt"`
+}
+
+// Profile returns the user's profile, leaving out the password hash
+func (u *User) Profile() Profile {
+	return Profile{
+		UserId:        u.UserId,
+		Email:         u.Email,
+		EmailVerified: u.EmailVerified,
+		DisplayName:   u.DisplayName,
+		Phone:         u.Phone,
+		Role:          u.Role,
+		CreatedAt:     u.CreatedAt,
+	}
 }
 
 type UserEvent struct {
@@ -20,3 +48,25 @@ type UserEvent struct {
 	Action    string `json:"action"`
 	UserId    string `json:"userId"`
 }
+
+// AuthTokens is returned on login and refresh. The refresh token is opaque and
+// can be exchanged once at /users/token/refresh for a new pair.
+type AuthTokens struct {
+	AccessToken  string `json:"accessToken"`
+	RefreshToken string `json:"refreshToken"`
+	TokenType    string `json:"tokenType"`
+	ExpiresIn    int    `json:"expiresIn"`
+}
+
+// RefreshToken is a stored refresh token. Only the SHA-256 hash of the token is
+// kept; every token issued by rotating another shares its familyId.
+type RefreshToken struct {
+	TokenID   int        `json:"tokenId"`
+	TokenHash string     `json:"-"`
+	FamilyID  string     `json:"familyId"`
+	UserID    string     `json:"userId"`
+	ExpiresAt time.Time  `json:"expiresAt"`
+	UsedAt    *time.Time `json:"usedAt,omitempty"`
+	RevokedAt *time.Time `json:"revokedAt,omitempty"`
+	CreatedAt time.Time  `json:"createdAt"`
+}
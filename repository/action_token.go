@@ -0,0 +1,62 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// ActionTokenRepository remembers which signed one-time tokens have been used
+type ActionTokenRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewActionTokenRepository(pool *pgxpool.Pool) *ActionTokenRepository {
+	return &ActionTokenRepository{pool: pool}
+}
+
+// ConsumeActionToken marks a token ID as used. It reports false when the token
+// had already been used. Expired IDs are pruned along the way, since their
+// tokens are refused on expiry before they ever reach this table.
+func (r *ActionTokenRepository) ConsumeActionToken(ctx context.Context, jti, purpose string, expiresAt time.Time) (bool, error) {
+	if _, err := r.pool.Exec(ctx, `DELETE FROM used_action_tokens WHERE "expiresAt" < NOW()`); err != nil {
+		log.Printf("Database error: pruning used action tokens failed: %v", err)
+	}
+
+	tag, err := r.pool.Exec(ctx, `
+		INSERT INTO used_action_tokens (jti, purpose, "expiresAt")
+		VALUES ($1, $2, $3)
+		ON CONFLICT (jti) DO NOTHING`,
+		jti, purpose, expiresAt,
+	)
+	if err != nil {
+		log.Printf("Database error: ConsumeActionToken(%s) failed: %v", jti, err)
+		return false, fmt.Errorf("failed to use token: %w", err)
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+// RecordActionTokenAttempt counts an attempt against a token ID and reports
+// whether it is within limit. The count lives as long as the token does.
+func (r *ActionTokenRepository) RecordActionTokenAttempt(ctx context.Context, jti string, expiresAt time.Time, limit int) (bool, error) {
+	if _, err := r.pool.Exec(ctx, `DELETE FROM action_token_attempts WHERE "expiresAt" < NOW()`); err != nil {
+		log.Printf("Database error: pruning action token attempts failed: %v", err)
+	}
+
+	var attempts int
+	err := r.pool.QueryRow(ctx, `
+		INSERT INTO action_token_attempts (jti, attempts, "expiresAt")
+		VALUES ($1, 1, $2)
+		ON CONFLICT (jti) DO UPDATE SET attempts = action_token_attempts.attempts + 1
+		RETURNING attempts`,
+		jti, expiresAt,
+	).Scan(&attempts)
+	if err != nil {
+		log.Printf("Database error: RecordActionTokenAttempt(%s) failed: %v", jti, err)
+		return false, fmt.Errorf("failed to record token attempt: %w", err)
+	}
+	return attempts <= limit, nil
+}
@@ -10,6 +10,7 @@ import (
 
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/your-username/golang-ecommerce-app/models"
 )
 
 type Cart struct {
@@ -17,6 +18,9 @@ type Cart struct {
 	UserID      string          `json:"userId"`
 	ProductInfo json.RawMessage `json:"productInfo"`
 	UpdatedAt   time.Time       `json:"updatedAt"`
+
+	// UnavailableItems lists cart entries whose product has since been removed
+	UnavailableItems []models.CartProduct `json:"unavailableItems,omitempty"`
 }
 
 type CartRepository struct {
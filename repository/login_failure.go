@@ -0,0 +1,120 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/your-username/golang-ecommerce-app/models"
+)
+
+// LoginFailureRepository persists failed login counts so throttling survives
+// restarts and cache flushes
+type LoginFailureRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewLoginFailureRepository(pool *pgxpool.Pool) *LoginFailureRepository {
+	return &LoginFailureRepository{pool: pool}
+}
+
+// CountLoginAttempt counts a login attempt for subject at now as a failure,
+// unless blocked says the subject must wait first. The subject's row stays
+// locked while blocked decides, so concurrent attempts are counted one after
+// another and each sees the ones before it.
+//
+// It returns the count before the attempt, nil when none is recorded or the
+// last failure is older than resetAfter, and the count including the attempt,
+// nil when blocked refused it.
+func (r *LoginFailureRepository) CountLoginAttempt(
+	ctx context.Context,
+	subject string,
+	now time.Time,
+	resetAfter time.Duration,
+	blocked func(previous *models.LoginFailures) bool,
+) (*models.LoginFailures, *models.LoginFailures, error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	// A zero row gives concurrent first attempts a row to wait on
+	_, err = tx.Exec(ctx, `
+		INSERT INTO login_failures (subject, "failedCount", "lastFailedAt")
+		VALUES ($1, 0, $2)
+		ON CONFLICT (subject) DO NOTHING`,
+		subject, now,
+	)
+	if err != nil {
+		log.Printf("Database error: CountLoginAttempt(%s) failed: %v", subject, err)
+		return nil, nil, fmt.Errorf("failed to count login attempt: %w", err)
+	}
+
+	previous := &models.LoginFailures{Subject: subject}
+	err = tx.QueryRow(ctx,
+		`SELECT "failedCount", "lastFailedAt" FROM login_failures WHERE subject = $1 FOR UPDATE`,
+		subject,
+	).Scan(&previous.FailedCount, &previous.LastFailedAt)
+	if err != nil {
+		log.Printf("Database error: CountLoginAttempt(%s) failed: %v", subject, err)
+		return nil, nil, fmt.Errorf("failed to count login attempt: %w", err)
+	}
+	if previous.FailedCount == 0 || previous.LastFailedAt.Before(now.Add(-resetAfter)) {
+		previous = nil
+	}
+	if blocked(previous) {
+		return previous, nil, nil
+	}
+
+	current := &models.LoginFailures{Subject: subject, FailedCount: 1, LastFailedAt: now}
+	if previous != nil {
+		current.FailedCount = previous.FailedCount + 1
+	}
+	_, err = tx.Exec(ctx,
+		`UPDATE login_failures SET "failedCount" = $2, "lastFailedAt" = $3 WHERE subject = $1`,
+		subject, current.FailedCount, now,
+	)
+	if err != nil {
+		log.Printf("Database error: CountLoginAttempt(%s) failed: %v", subject, err)
+		return nil, nil, fmt.Errorf("failed to count login attempt: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return previous, current, nil
+}
+
+// ReleaseLoginAttempt takes back one attempt counted by CountLoginAttempt
+// that turned out not to be a failure
+func (r *LoginFailureRepository) ReleaseLoginAttempt(ctx context.Context, subject string) error {
+	_, err := r.pool.Exec(ctx,
+		`UPDATE login_failures SET "failedCount" = "failedCount" - 1 WHERE subject = $1 AND "failedCount" > 0`,
+		subject,
+	)
+	if err != nil {
+		log.Printf("Database error: ReleaseLoginAttempt(%s) failed: %v", subject, err)
+		return fmt.Errorf("failed to release login attempt: %w", err)
+	}
+	return nil
+}
+
+// ClearLoginFailures forgets the failures of subject. It reports whether any were recorded.
+func (r *LoginFailureRepository) ClearLoginFailures(ctx context.Context, subject string) (bool, error) {
+	var cleared bool
+	err := r.pool.QueryRow(ctx, `
+		WITH deleted AS (
+			DELETE FROM login_failures WHERE subject = $1 RETURNING "failedCount"
+		)
+		SELECT COALESCE(MAX("failedCount"), 0) > 0 FROM deleted`,
+		subject,
+	).Scan(&cleared)
+	if err != nil {
+		log.Printf("Database error: ClearLoginFailures(%s) failed: %v", subject, err)
+		return false, fmt.Errorf("failed to clear login failures: %w", err)
+	}
+	return cleared, nil
+}
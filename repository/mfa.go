@@ -0,0 +1,228 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/your-username/golang-ecommerce-app/models"
+)
+
+type MFARepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewMFARepository(pool *pgxpool.Pool) *MFARepository {
+	return &MFARepository{pool: pool}
+}
+
+// GetTOTPSecret fetches the user's authenticator secret, enabled or pending
+func (r *MFARepository) GetTOTPSecret(ctx context.Context, userID string) (*models.TOTPSecret, error) {
+	var t models.TOTPSecret
+	err := r.pool.QueryRow(ctx, `
+		SELECT "userId", secret, "enabledAt", "lastUsedStep", "createdAt"
+		FROM user_totp WHERE "userId" = $1`,
+		userID,
+	).Scan(&t.UserID, &t.Secret, &t.EnabledAt, &t.LastUsedStep, &t.CreatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		log.Printf("Database error: GetTOTPSecret(%s) failed: %v", userID, err)
+		return nil, fmt.Errorf("failed to fetch TOTP secret: %w", err)
+	}
+	return &t, nil
+}
+
+// SavePendingTOTPSecret stores a secret awaiting confirmation, replacing any
+// earlier pending one. It reports false if two-factor is already enabled.
+func (r *MFARepository) SavePendingTOTPSecret(ctx context.Context, userID, secret string) (bool, error) {
+	tag, err := r.pool.Exec(ctx, `
+		INSERT INTO user_totp ("userId", secret) VALUES ($1, $2)
+		ON CONFLICT ("userId") DO UPDATE
+		SET secret = EXCLUDED.secret, "lastUsedStep" = 0, "createdAt" = NOW()
+		WHERE user_totp."enabledAt" IS NULL`,
+		userID, secret,
+	)
+	if err != nil {
+		log.Printf("Database error: SavePendingTOTPSecret(%s) failed: %v", userID, err)
+		return false, fmt.Errorf("failed to save TOTP secret: %w", err)
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+// EnableTOTP turns on two-factor for the user, recording the step of the code
+// that confirmed it, and replaces their recovery codes
+func (r *MFARepository) EnableTOTP(ctx context.Context, userID string, step int64, codeHashes []string) (bool, error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	tag, err := tx.Exec(ctx, `
+		UPDATE user_totp SET "enabledAt" = NOW(), "lastUsedStep" = $2
+		WHERE "userId" = $1 AND "enabledAt" IS NULL`,
+		userID, step,
+	)
+	if err != nil {
+		log.Printf("Database error: EnableTOTP(%s) failed: %v", userID, err)
+		return false, fmt.Errorf("failed to enable TOTP: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return false, nil
+	}
+
+	if err := replaceRecoveryCodes(ctx, tx, userID, codeHashes); err != nil {
+		return false, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return false, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return true, nil
+}
+
+// UseTOTPStep records that a code from step was accepted. It reports false
+// when a code from that step or a later one was already used.
+func (r *MFARepository) UseTOTPStep(ctx context.Context, userID string, step int64) (bool, error) {
+	tag, err := r.pool.Exec(ctx, `
+		UPDATE user_totp SET "lastUsedStep" = $2
+		WHERE "userId" = $1 AND "enabledAt" IS NOT NULL AND "lastUsedStep" < $2`,
+		userID, step,
+	)
+	if err != nil {
+		log.Printf("Database error: UseTOTPStep(%s) failed: %v", userID, err)
+		return false, fmt.Errorf("failed to record TOTP code: %w", err)
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+// DisableTOTP removes the user's secret and recovery codes
+func (r *MFARepository) DisableTOTP(ctx context.Context, userID string) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `DELETE FROM user_totp WHERE "userId" = $1`, userID); err != nil {
+		log.Printf("Database error: DisableTOTP(%s) failed: %v", userID, err)
+		return fmt.Errorf("failed to disable TOTP: %w", err)
+	}
+	if err := replaceRecoveryCodes(ctx, tx, userID, nil); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// ReplaceRecoveryCodes discards the user's recovery codes in favour of new ones
+func (r *MFARepository) ReplaceRecoveryCodes(ctx context.Context, userID string, codeHashes []string) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if err := replaceRecoveryCodes(ctx, tx, userID, codeHashes); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+func replaceRecoveryCodes(ctx context.Context, db Tx, userID string, codeHashes []string) error {
+	if _, err := db.Exec(ctx, `DELETE FROM mfa_recovery_codes WHERE "userId" = $1`, userID); err != nil {
+		log.Printf("Database error: replaceRecoveryCodes(%s) delete failed: %v", userID, err)
+		return fmt.Errorf("failed to replace recovery codes: %w", err)
+	}
+	for _, hash := range codeHashes {
+		if _, err := db.Exec(ctx,
+			`INSERT INTO mfa_recovery_codes ("userId", "codeHash") VALUES ($1, $2)`,
+			userID, hash,
+		); err != nil {
+			log.Printf("Database error: replaceRecoveryCodes(%s) insert failed: %v", userID, err)
+			return fmt.Errorf("failed to store recovery codes: %w", err)
+		}
+	}
+	return nil
+}
+
+// UseRecoveryCode marks an unused recovery code as used. It reports false when
+// the user has no such unused code.
+func (r *MFARepository) UseRecoveryCode(ctx context.Context, userID, codeHash string) (bool, error) {
+	tag, err := r.pool.Exec(ctx, `
+		UPDATE mfa_recovery_codes SET "usedAt" = NOW()
+		WHERE "userId" = $1 AND "codeHash" = $2 AND "usedAt" IS NULL`,
+		userID, codeHash,
+	)
+	if err != nil {
+		log.Printf("Database error: UseRecoveryCode(%s) failed: %v", userID, err)
+		return false, fmt.Errorf("failed to use recovery code: %w", err)
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+// CountRecoveryCodes returns how many unused recovery codes the user has left
+func (r *MFARepository) CountRecoveryCodes(ctx context.Context, userID string) (int, error) {
+	var count int
+	err := r.pool.QueryRow(ctx,
+		`SELECT COUNT(*) FROM mfa_recovery_codes WHERE "userId" = $1 AND "usedAt" IS NULL`,
+		userID,
+	).Scan(&count)
+	if err != nil {
+		log.Printf("Database error: CountRecoveryCodes(%s) failed: %v", userID, err)
+		return 0, fmt.Errorf("failed to count recovery codes: %w", err)
+	}
+	return count, nil
+}
+
+// GetRequiredRoles lists the roles that must use two-factor authentication
+func (r *MFARepository) GetRequiredRoles(ctx context.Context) ([]string, error) {
+	rows, err := r.pool.Query(ctx, `SELECT role FROM mfa_required_roles ORDER BY role`)
+	if err != nil {
+		log.Printf("Database error: GetRequiredRoles failed: %v", err)
+		return nil, fmt.Errorf("failed to fetch two-factor policy: %w", err)
+	}
+	roles, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		log.Printf("Row scan error in GetRequiredRoles: %v", err)
+		return nil, fmt.Errorf("failed to fetch two-factor policy: %w", err)
+	}
+	return roles, nil
+}
+
+// SetRequiredRoles replaces the roles that must use two-factor authentication
+func (r *MFARepository) SetRequiredRoles(ctx context.Context, roles []string) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `DELETE FROM mfa_required_roles`); err != nil {
+		log.Printf("Database error: SetRequiredRoles delete failed: %v", err)
+		return fmt.Errorf("failed to update two-factor policy: %w", err)
+	}
+	for _, role := range roles {
+		if _, err := tx.Exec(ctx, `INSERT INTO mfa_required_roles (role) VALUES ($1)`, role); err != nil {
+			log.Printf("Database error: SetRequiredRoles insert failed: %v", err)
+			return fmt.Errorf("failed to update two-factor policy: %w", err)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,97 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type PasswordResetRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewPasswordResetRepository(pool *pgxpool.Pool) *PasswordResetRepository {
+	return &PasswordResetRepository{pool: pool}
+}
+
+// CreateResetToken stores the hash of a new reset token for the user. Any
+// earlier unused tokens stop working, so only the latest email is valid.
+func (r *PasswordResetRepository) CreateResetToken(ctx context.Context, userID, tokenHash string, expiresAt time.Time) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx,
+		`DELETE FROM password_reset_tokens WHERE "userId" = $1 AND ("usedAt" IS NULL OR "expiresAt" < NOW())`,
+		userID,
+	); err != nil {
+		log.Printf("Database error: CreateResetToken cleanup for %s failed: %v", userID, err)
+		return fmt.Errorf("failed to replace reset tokens: %w", err)
+	}
+
+	if _, err := tx.Exec(ctx, `
+		INSERT INTO password_reset_tokens ("tokenHash", "userId", "expiresAt")
+		VALUES ($1, $2, $3)`,
+		tokenHash, userID, expiresAt,
+	); err != nil {
+		log.Printf("Database error: CreateResetToken for %s failed: %v", userID, err)
+		return fmt.Errorf("failed to store reset token: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// ResetPassword uses up the reset token with the given hash, sets the owner's
+// password to passwordHash and signs them out of every session in one
+// transaction. It returns the user's ID, or "" when the token is unknown,
+// expired or already used, along with the IDs of the revoked sessions.
+func (r *PasswordResetRepository) ResetPassword(ctx context.Context, tokenHash, passwordHash string, now time.Time) (string, []string, error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	var userID string
+	err = tx.QueryRow(ctx, `
+		UPDATE password_reset_tokens SET "usedAt" = $2
+		WHERE "tokenHash" = $1 AND "usedAt" IS NULL AND "expiresAt" > $2
+		RETURNING "userId"`,
+		tokenHash, now,
+	).Scan(&userID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return "", nil, nil
+		}
+		log.Printf("Database error: ResetPassword token lookup failed: %v", err)
+		return "", nil, fmt.Errorf("failed to use reset token: %w", err)
+	}
+
+	if _, err := tx.Exec(ctx,
+		`UPDATE users SET password = $2, "updatedAt" = NOW() WHERE "userId" = $1`,
+		userID, passwordHash,
+	); err != nil {
+		log.Printf("Database error: ResetPassword(%s) failed: %v", userID, err)
+		return "", nil, fmt.Errorf("failed to reset password: %w", err)
+	}
+
+	sessionIDs, err := revokeUserSessions(ctx, tx, userID, "")
+	if err != nil {
+		return "", nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return "", nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return userID, sessionIDs, nil
+}
@@ -5,12 +5,20 @@ import (
 	"errors"
 	"fmt"
 	"log"
+	"time"
 
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/your-username/golang-ecommerce-app/models"
 )
 
+// ErrDuplicateSKU is returned when a write would give two products the same SKU
+var ErrDuplicateSKU = errors.New("a product with this SKU already exists")
+
+// ErrVersionConflict is returned when a product was modified after the version the caller based its change on
+var ErrVersionConflict = errors.New("product has been modified since it was last read")
+
 type ProductRepository struct {
 	pool *pgxpool.Pool
 }
@@ -19,9 +27,46 @@ func NewProductRepository(pool *pgxpool.Pool) *ProductRepository {
 	return &ProductRepository{pool: pool}
 }
 
-// GetAllProducts fetches all products
+// productColumns is the column list scanned by scanProduct
+const productColumns = `"productId", COALESCE(sku, ''), name, description, COALESCE(category, ''), image, price, stock, "salePrice", "saleEndsAt", "ratingAverage", "ratingCount", status, "publishAt", "createdAt", "updatedAt", version, "deletedAt"`
+
+// touchProduct is the SET clause every modification of a product row includes,
+// so the version seen by If-Match always reflects the latest write
+const touchProduct = `version = products.version + 1, "updatedAt" = NOW()`
+
+// publicProductFilter restricts a query to products customers may see
+const publicProductFilter = `"deletedAt" IS NULL AND status = 'published'`
+
+func scanProduct(row pgx.Row, p *models.Product) error {
+	return row.Scan(productScanDest(p)...)
+}
+
+func productScanDest(p *models.Product) []any {
+	return []any{
+		&p.ProductID,
+		&p.SKU,
+		&p.Name,
+		&p.Description,
+		&p.Category,
+		&p.Image,
+		&p.Price,
+		&p.Stock,
+		&p.SalePrice,
+		&p.SaleEndsAt,
+		&p.RatingAverage,
+		&p.RatingCount,
+		&p.Status,
+		&p.PublishAt,
+		&p.CreatedAt,
+		&p.UpdatedAt,
+		&p.Version,
+		&p.DeletedAt,
+	}
+}
+
+// GetAllProducts fetches all products that have not been deleted
 func (r *ProductRepository) GetAllProducts(ctx context.Context) ([]models.Product, error) {
-	query := `SELECT "productId", name, description, image, price, "createdAt" FROM products`
+	query := `SELECT ` + productColumns + ` FROM products WHERE "deletedAt" IS NULL`
 
 	rows, err := r.pool.Query(ctx, query)
 	if err != nil {
@@ -33,14 +78,7 @@ func (r *ProductRepository) GetAllProducts(ctx context.Context) ([]models.Produc
 	var products []models.Product
 	for rows.Next() {
 		var p models.Product
-		if err := rows.Scan(
-			&p.ProductID,
-			&p.Name,
-			&p.Description,
-			&p.Image,
-			&p.Price,
-			&p.CreatedAt,
-		); err != nil {
+		if err := scanProduct(rows, &p); err != nil {
 			log.Printf("Row scan error in GetAllProducts: %v", err)
 			return nil, fmt.Errorf("failed to scan product row: %w", err)
 		}
@@ -54,10 +92,40 @@ func (r *ProductRepository) GetAllProducts(ctx context.Context) ([]models.Produc
 	return products, nil
 }
 
-// GetPaginatedProducts fetches products by limit and offset
+// StreamProducts calls fn for every live product, whatever its status, in ID order without buffering the
+// whole catalog in memory. Iteration stops at the first error returned by fn.
+func (r *ProductRepository) StreamProducts(ctx context.Context, fn func(models.Product) error) error {
+	query := `SELECT ` + productColumns + ` FROM products WHERE "deletedAt" IS NULL ORDER BY "productId"`
+
+	rows, err := r.pool.Query(ctx, query)
+	if err != nil {
+		log.Printf("Database error: StreamProducts failed: %v", err)
+		return fmt.Errorf("failed to stream products: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var p models.Product
+		if err := scanProduct(rows, &p); err != nil {
+			log.Printf("Row scan error in StreamProducts: %v", err)
+			return fmt.Errorf("failed to scan product row: %w", err)
+		}
+		if err := fn(p); err != nil {
+			return err
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("rows error in StreamProducts: %w", err)
+	}
+
+	return nil
+}
+
+// GetPaginatedProducts fetches published products by limit and offset
 func (r *ProductRepository) GetPaginatedProducts(ctx context.Context, limit, offset int) ([]models.Product, error) {
-	query := `SELECT "productId", name, description, image, price, "createdAt" 
-	          FROM products ORDER BY "productId" LIMIT $1 OFFSET $2`
+	query := `SELECT ` + productColumns + ` 
+	          FROM products WHERE ` + publicProductFilter + ` ORDER BY "productId" LIMIT $1 OFFSET $2`
 
 	rows, err := r.pool.Query(ctx, query, limit, offset)
 	if err != nil {
@@ -69,14 +137,7 @@ func (r *ProductRepository) GetPaginatedProducts(ctx context.Context, limit, off
 	var products []models.Product
 	for rows.Next() {
 		var p models.Product
-		if err := rows.Scan(
-			&p.ProductID,
-			&p.Name,
-			&p.Description,
-			&p.Image,
-			&p.Price,
-			&p.CreatedAt,
-		); err != nil {
+		if err := scanProduct(rows, &p); err != nil {
 			log.Printf("Row scan error in GetPaginatedProducts: %v", err)
 			return nil, fmt.Errorf("failed to scan product row: %w", err)
 		}
@@ -90,9 +151,9 @@ func (r *ProductRepository) GetPaginatedProducts(ctx context.Context, limit, off
 	return products, nil
 }
 
-// GetTotalProductCount returns total count of products
+// GetTotalProductCount returns total count of published products
 func (r *ProductRepository) GetTotalProductCount(ctx context.Context) (int, error) {
-	query := `SELECT COUNT(*) FROM products`
+	query := `SELECT COUNT(*) FROM products WHERE ` + publicProductFilter
 
 	var count int
 	err := r.pool.QueryRow(ctx, query).Scan(&count)
@@ -104,20 +165,13 @@ func (r *ProductRepository) GetTotalProductCount(ctx context.Context) (int, erro
 	return count, nil
 }
 
-// GetProductByID fetches a single product by its ID
+// GetProductByID fetches a single published product by its ID
 func (r *ProductRepository) GetProductByID(ctx context.Context, id int) (*models.Product, error) {
-	query := `SELECT "productId", name, description, image, price, "createdAt"
-	          FROM products WHERE "productId" = $1`
+	query := `SELECT ` + productColumns + `
+	          FROM products WHERE "productId" = $1 AND ` + publicProductFilter
 
 	var p models.Product
-	err := r.pool.QueryRow(ctx, query, id).Scan(
-		&p.ProductID,
-		&p.Name,
-		&p.Description,
-		&p.Image,
-		&p.Price,
-		&p.CreatedAt,
-	)
+	err := scanProduct(r.pool.QueryRow(ctx, query, id), &p)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, nil
@@ -129,88 +183,383 @@ func (r *ProductRepository) GetProductByID(ctx context.Context, id int) (*models
 	return &p, nil
 }
 
+// GetAnyProductByID fetches a product by its ID whatever its status, ignoring deleted products
+func (r *ProductRepository) GetAnyProductByID(ctx context.Context, id int) (*models.Product, error) {
+	query := `SELECT ` + productColumns + `
+	          FROM products WHERE "productId" = $1 AND "deletedAt" IS NULL`
+
+	var p models.Product
+	err := scanProduct(r.pool.QueryRow(ctx, query, id), &p)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		log.Printf("Database error: GetAnyProductByID(%d) failed: %v", id, err)
+		return nil, fmt.Errorf("failed to fetch product by ID: %w", err)
+	}
+
+	return &p, nil
+}
+
+// GetProductsByStatus fetches live products in any state for admins. An empty
+// status matches every state.
+func (r *ProductRepository) GetProductsByStatus(ctx context.Context, status string, limit, offset int) ([]models.Product, int, error) {
+	query := `SELECT ` + productColumns + `
+	          FROM products WHERE "deletedAt" IS NULL AND ($1 = '' OR status = $1)
+	          ORDER BY "productId" LIMIT $2 OFFSET $3`
+
+	rows, err := r.pool.Query(ctx, query, status, limit, offset)
+	if err != nil {
+		log.Printf("Database error: GetProductsByStatus failed: %v", err)
+		return nil, 0, fmt.Errorf("failed to fetch products: %w", err)
+	}
+	defer rows.Close()
+
+	products := []models.Product{}
+	for rows.Next() {
+		var p models.Product
+		if err := scanProduct(rows, &p); err != nil {
+			log.Printf("Row scan error in GetProductsByStatus: %v", err)
+			return nil, 0, fmt.Errorf("failed to scan product row: %w", err)
+		}
+		products = append(products, p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("rows error in GetProductsByStatus: %w", err)
+	}
+
+	var total int
+	err = r.pool.QueryRow(ctx,
+		`SELECT COUNT(*) FROM products WHERE "deletedAt" IS NULL AND ($1 = '' OR status = $1)`,
+		status,
+	).Scan(&total)
+	if err != nil {
+		log.Printf("Database error: GetProductsByStatus count failed: %v", err)
+		return nil, 0, fmt.Errorf("failed to count products: %w", err)
+	}
+
+	return products, total, nil
+}
+
 // CreateProduct inserts a new product
 func (r *ProductRepository) CreateProduct(ctx context.Context, product models.Product) (*models.Product, error) {
 	query := `
-		INSERT INTO products (name, description, image, price)
-		VALUES ($1, $2, $3, $4)
-		RETURNING "productId", name, description, image, price, "createdAt"
-	`
+		INSERT INTO products (sku, name, description, image, price, status, "publishAt", category)
+		VALUES (NULLIF($1, ''), $2, $3, $4, $5, $6, $7, NULLIF($8, ''))
+		RETURNING ` + productColumns
+
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
 
 	var p models.Product
-	err := r.pool.QueryRow(ctx, query,
+	err = scanProduct(tx.QueryRow(ctx, query,
+		product.SKU,
 		product.Name,
 		product.Description,
 		product.Image,
 		product.Price,
-	).Scan(
-		&p.ProductID,
-		&p.Name,
-		&p.Description,
-		&p.Image,
-		&p.Price,
-		&p.CreatedAt,
-	)
+		product.Status,
+		product.PublishAt,
+		product.Category,
+	), &p)
 	if err != nil {
+		if isUniqueViolation(err) {
+			return nil, ErrDuplicateSKU
+		}
 		log.Printf("Database error: CreateProduct failed: %v", err)
 		return nil, fmt.Errorf("failed to create product: %w", err)
 	}
 
+	if err := commitWithPriceHistory(ctx, tx, &p); err != nil {
+		return nil, err
+	}
 	return &p, nil
 }
 
+// commitWithPriceHistory records the product's price in its price history, if
+// it changed, and commits the transaction that wrote the product
+func commitWithPriceHistory(ctx context.Context, tx pgx.Tx, p *models.Product) error {
+	if err := recordRegularPrice(ctx, tx, p.ProductID, p.Price, time.Now()); err != nil {
+		return err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// UpsertProductBySKU inserts a product or, if one with the same SKU exists,
+// overwrites its fields and restores it if it was deleted. An empty status
+// creates a draft or leaves an existing product's status unchanged; an empty
+// category leaves the existing category unchanged. created reports whether a
+// new row was inserted.
+func (r *ProductRepository) UpsertProductBySKU(ctx context.Context, product models.Product) (p *models.Product, created bool, err error) {
+	query := `
+		INSERT INTO products (sku, name, description, image, price, status, category)
+		VALUES ($1, $2, $3, $4, $5, COALESCE(NULLIF($6, ''), 'draft'), NULLIF($7, ''))
+		ON CONFLICT (sku) DO UPDATE SET
+			name = EXCLUDED.name,
+			description = EXCLUDED.description,
+			category = COALESCE(EXCLUDED.category, products.category),
+			image = EXCLUDED.image,
+			price = EXCLUDED.price,
+			status = CASE WHEN $6 = '' THEN products.status ELSE EXCLUDED.status END,
+			"publishAt" = CASE WHEN $6 = '' THEN products."publishAt" ELSE NULL END,
+			` + touchProduct + `,
+			"deletedAt" = NULL
+		RETURNING ` + productColumns + `, (xmax = 0)`
+
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	p = &models.Product{}
+	err = tx.QueryRow(ctx, query,
+		product.SKU,
+		product.Name,
+		product.Description,
+		product.Image,
+		product.Price,
+		product.Status,
+		product.Category,
+	).Scan(append(productScanDest(p), &created)...)
+	if err != nil {
+		log.Printf("Database error: UpsertProductBySKU(%s) failed: %v", product.SKU, err)
+		return nil, false, fmt.Errorf("failed to upsert product: %w", err)
+	}
+
+	if err := commitWithPriceHistory(ctx, tx, p); err != nil {
+		return nil, false, err
+	}
+	return p, created, nil
+}
+
 // UpdateProduct updates an existing product
-func (r *ProductRepository) UpdateProduct(ctx context.Context, id int, product models.Product) (*models.Product, error) {
+func (r *ProductRepository) UpdateProduct(ctx context.Context, id int, product models.Product, expectedVersion int) (*models.Product, error) {
 	query := `
 		UPDATE products
-		SET name = $1, description = $2, image = $3, price = $4, "createdAt" = NOW()
-		WHERE "productId" = $5
-		RETURNING "productId", name, description, image, price, "createdAt"
-	`
+		SET name = $1, description = $2, image = $3, price = $4,
+			sku = COALESCE(NULLIF($6, ''), sku), category = COALESCE(NULLIF($8, ''), category),
+			` + touchProduct + `
+		WHERE "productId" = $5 AND "deletedAt" IS NULL AND ($7 = 0 OR version = $7)
+		RETURNING ` + productColumns
+
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
 
 	var p models.Product
-	err := r.pool.QueryRow(ctx, query,
+	err = scanProduct(tx.QueryRow(ctx, query,
 		product.Name,
 		product.Description,
 		product.Image,
 		product.Price,
 		id,
-	).Scan(
-		&p.ProductID,
-		&p.Name,
-		&p.Description,
-		&p.Image,
-		&p.Price,
-		&p.CreatedAt,
-	)
+		product.SKU,
+		expectedVersion,
+		product.Category,
+	), &p)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
-			return nil, nil
+			return nil, r.versionMismatch(ctx, id, expectedVersion)
+		}
+		if isUniqueViolation(err) {
+			return nil, ErrDuplicateSKU
 		}
 		log.Printf("Database error: UpdateProduct(%d) failed: %v", id, err)
 		return nil, fmt.Errorf("failed to update product: %w", err)
 	}
 
+	if err := commitWithPriceHistory(ctx, tx, &p); err != nil {
+		return nil, err
+	}
 	return &p, nil
 }
 
-// DeleteProduct deletes a product by ID
+// PatchProduct updates only the fields set in patch, provided the product is
+// still at expectedVersion. A zero expectedVersion skips the check.
+func (r *ProductRepository) PatchProduct(ctx context.Context, id int, patch models.ProductPatch, expectedVersion int) (*models.Product, error) {
+	query := `
+		UPDATE products
+		SET sku = COALESCE($2, sku),
+			name = COALESCE($3, name),
+			description = COALESCE($4, description),
+			image = COALESCE($5, image),
+			price = COALESCE($6, price),
+			category = CASE WHEN $8::text IS NULL THEN category ELSE NULLIF($8, '') END,
+			` + touchProduct + `
+		WHERE "productId" = $1 AND "deletedAt" IS NULL AND ($7 = 0 OR version = $7)
+		RETURNING ` + productColumns
+
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	var p models.Product
+	err = scanProduct(tx.QueryRow(ctx, query,
+		id,
+		patch.SKU,
+		patch.Name,
+		patch.Description,
+		patch.Image,
+		patch.Price,
+		expectedVersion,
+		patch.Category,
+	), &p)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, r.versionMismatch(ctx, id, expectedVersion)
+		}
+		if isUniqueViolation(err) {
+			return nil, ErrDuplicateSKU
+		}
+		log.Printf("Database error: PatchProduct(%d) failed: %v", id, err)
+		return nil, fmt.Errorf("failed to patch product: %w", err)
+	}
+
+	if err := commitWithPriceHistory(ctx, tx, &p); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// versionMismatch explains why a versioned update matched no row: either the
+// product is gone (nil) or it has moved past expectedVersion
+func (r *ProductRepository) versionMismatch(ctx context.Context, id, expectedVersion int) error {
+	if expectedVersion == 0 {
+		return nil
+	}
+
+	var exists bool
+	err := r.pool.QueryRow(ctx,
+		`SELECT EXISTS (SELECT 1 FROM products WHERE "productId" = $1 AND "deletedAt" IS NULL)`, id,
+	).Scan(&exists)
+	if err != nil {
+		log.Printf("Database error: versionMismatch(%d) failed: %v", id, err)
+		return fmt.Errorf("failed to check product: %w", err)
+	}
+	if exists {
+		return ErrVersionConflict
+	}
+	return nil
+}
+
+// UpdateProductStatus moves a live product to a new publishing state
+func (r *ProductRepository) UpdateProductStatus(ctx context.Context, id int, status string, publishAt *time.Time) (*models.Product, error) {
+	query := `
+		UPDATE products SET status = $2, "publishAt" = $3, ` + touchProduct + `
+		WHERE "productId" = $1 AND "deletedAt" IS NULL
+		RETURNING ` + productColumns
+
+	var p models.Product
+	err := scanProduct(r.pool.QueryRow(ctx, query, id, status, publishAt), &p)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		log.Printf("Database error: UpdateProductStatus(%d) failed: %v", id, err)
+		return nil, fmt.Errorf("failed to update product status: %w", err)
+	}
+
+	return &p, nil
+}
+
+// SetProductStock sets a live product's stock level. When the product comes
+// back into stock, its pending back-in-stock subscriptions are queued for
+// delivery in the same transaction. It returns nil if the product does not
+// exist, and the number of notifications queued.
+func (r *ProductRepository) SetProductStock(ctx context.Context, id, stock int) (*models.Product, int64, error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	query := `
+		UPDATE products SET stock = $2, ` + touchProduct + `
+		FROM (
+			SELECT "productId" AS id, stock AS "previousStock" FROM products
+			WHERE "productId" = $1 AND "deletedAt" IS NULL
+			FOR UPDATE
+		) old
+		WHERE products."productId" = old.id
+		RETURNING ` + productColumns + `, old."previousStock"`
+
+	var p models.Product
+	var previous *int
+	err = tx.QueryRow(ctx, query, id, stock).Scan(append(productScanDest(&p), &previous)...)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, 0, nil
+		}
+		log.Printf("Database error: SetProductStock(%d) failed: %v", id, err)
+		return nil, 0, fmt.Errorf("failed to update product stock: %w", err)
+	}
+
+	// Untracked stock counts as sold out here, since only sold-out products take subscriptions
+	var queued int64
+	if (previous == nil || *previous <= 0) && stock > 0 {
+		if queued, err = queueBackInStock(ctx, tx, id); err != nil {
+			return nil, 0, err
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return &p, queued, nil
+}
+
+// PublishDueProducts publishes every scheduled product whose publishAt is at or
+// before now and returns their IDs
+func (r *ProductRepository) PublishDueProducts(ctx context.Context, now time.Time) ([]int, error) {
+	query := `
+		UPDATE products SET status = 'published', ` + touchProduct + `
+		WHERE status = 'scheduled' AND "publishAt" <= $1 AND "deletedAt" IS NULL
+		RETURNING "productId"`
+
+	rows, err := r.pool.Query(ctx, query, now)
+	if err != nil {
+		log.Printf("Database error: PublishDueProducts failed: %v", err)
+		return nil, fmt.Errorf("failed to publish scheduled products: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan product ID: %w", err)
+		}
+		ids = append(ids, id)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error in PublishDueProducts: %w", err)
+	}
+
+	return ids, nil
+}
+
+// DeleteProduct soft-deletes a product by ID. The row is kept so historical
+// references to it stay valid; it can be restored or purged later.
 func (r *ProductRepository) DeleteProduct(ctx context.Context, id int) (*models.Product, error) {
 	query := `
-		DELETE FROM products 
-		WHERE "productId" = $1 
-		RETURNING "productId", name, description, image, price, "createdAt"
-	`
+		UPDATE products SET "deletedAt" = NOW(), ` + touchProduct + `
+		WHERE "productId" = $1 AND "deletedAt" IS NULL
+		RETURNING ` + productColumns
 
 	var p models.Product
-	err := r.pool.QueryRow(ctx, query, id).Scan(
-		&p.ProductID,
-		&p.Name,
-		&p.Description,
-		&p.Image,
-		&p.Price,
-		&p.CreatedAt,
-	)
+	err := scanProduct(r.pool.QueryRow(ctx, query, id), &p)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, nil
@@ -220,4 +569,145 @@ func (r *ProductRepository) DeleteProduct(ctx context.Context, id int) (*models.
 	}
 
 	return &p, nil
-}
\ No newline at end of file
+}
+
+// GetDeletedProducts fetches soft-deleted products, most recently deleted first
+func (r *ProductRepository) GetDeletedProducts(ctx context.Context, limit, offset int) ([]models.Product, error) {
+	query := `SELECT ` + productColumns + `
+	          FROM products WHERE "deletedAt" IS NOT NULL
+	          ORDER BY "deletedAt" DESC LIMIT $1 OFFSET $2`
+
+	rows, err := r.pool.Query(ctx, query, limit, offset)
+	if err != nil {
+		log.Printf("Database error: GetDeletedProducts failed: %v", err)
+		return nil, fmt.Errorf("failed to fetch deleted products: %w", err)
+	}
+	defer rows.Close()
+
+	products := []models.Product{}
+	for rows.Next() {
+		var p models.Product
+		if err := scanProduct(rows, &p); err != nil {
+			log.Printf("Row scan error in GetDeletedProducts: %v", err)
+			return nil, fmt.Errorf("failed to scan product row: %w", err)
+		}
+		products = append(products, p)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error in GetDeletedProducts: %w", err)
+	}
+
+	return products, nil
+}
+
+// RestoreProduct clears the deletion mark on a soft-deleted product
+func (r *ProductRepository) RestoreProduct(ctx context.Context, id int) (*models.Product, error) {
+	query := `
+		UPDATE products SET "deletedAt" = NULL, ` + touchProduct + `
+		WHERE "productId" = $1 AND "deletedAt" IS NOT NULL
+		RETURNING ` + productColumns
+
+	var p models.Product
+	err := scanProduct(r.pool.QueryRow(ctx, query, id), &p)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		log.Printf("Database error: RestoreProduct(%d) failed: %v", id, err)
+		return nil, fmt.Errorf("failed to restore product: %w", err)
+	}
+
+	return &p, nil
+}
+
+// PurgeProduct permanently deletes a product that has already been soft-deleted
+func (r *ProductRepository) PurgeProduct(ctx context.Context, id int) (*models.Product, error) {
+	query := `
+		DELETE FROM products 
+		WHERE "productId" = $1 AND "deletedAt" IS NOT NULL
+		RETURNING ` + productColumns
+
+	var p models.Product
+	err := scanProduct(r.pool.QueryRow(ctx, query, id), &p)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		log.Printf("Database error: PurgeProduct(%d) failed: %v", id, err)
+		return nil, fmt.Errorf("failed to purge product: %w", err)
+	}
+
+	return &p, nil
+}
+
+// GetProductsByIDs fetches the published products among ids, in the order the IDs were given
+func (r *ProductRepository) GetProductsByIDs(ctx context.Context, ids []int) ([]models.Product, error) {
+	query := `SELECT ` + productColumns + `
+	          FROM products
+	          JOIN unnest($1::int[]) WITH ORDINALITY AS wanted(id, ord) ON wanted.id = products."productId"
+	          WHERE ` + publicProductFilter + `
+	          ORDER BY wanted.ord`
+
+	rows, err := r.pool.Query(ctx, query, ids)
+	if err != nil {
+		log.Printf("Database error: GetProductsByIDs failed: %v", err)
+		return nil, fmt.Errorf("failed to fetch products: %w", err)
+	}
+	defer rows.Close()
+
+	products := []models.Product{}
+	for rows.Next() {
+		var p models.Product
+		if err := scanProduct(rows, &p); err != nil {
+			log.Printf("Row scan error in GetProductsByIDs: %v", err)
+			return nil, fmt.Errorf("failed to scan product row: %w", err)
+		}
+		products = append(products, p)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error in GetProductsByIDs: %w", err)
+	}
+
+	return products, nil
+}
+
+// FindUnavailableProductIDs returns the IDs from ids that do not refer to a
+// published, undeleted product
+func (r *ProductRepository) FindUnavailableProductIDs(ctx context.Context, ids []int) ([]int, error) {
+	query := `
+		SELECT id FROM unnest($1::int[]) AS id
+		WHERE NOT EXISTS (
+			SELECT 1 FROM products p
+			WHERE p."productId" = id AND p."deletedAt" IS NULL AND p.status = 'published'
+		)`
+
+	rows, err := r.pool.Query(ctx, query, ids)
+	if err != nil {
+		log.Printf("Database error: FindUnavailableProductIDs failed: %v", err)
+		return nil, fmt.Errorf("failed to check product availability: %w", err)
+	}
+	defer rows.Close()
+
+	var missing []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan product ID: %w", err)
+		}
+		missing = append(missing, id)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error in FindUnavailableProductIDs: %w", err)
+	}
+
+	return missing, nil
+}
+
+// isUniqueViolation reports whether err is a Postgres unique constraint violation
+func isUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23505"
+}
@@ -0,0 +1,303 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/your-username/golang-ecommerce-app/models"
+)
+
+// ErrImageSetMismatch is returned when a reorder request does not list every image of the product exactly once
+var ErrImageSetMismatch = errors.New("image order must list every product image exactly once")
+
+type ProductImageRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewProductImageRepository(pool *pgxpool.Pool) *ProductImageRepository {
+	return &ProductImageRepository{pool: pool}
+}
+
+const productImageColumns = `"imageId", "productId", "objectKey", url, "contentType", size, width, height, position, "isPrimary", variants, "createdAt"`
+
+func scanProductImage(row pgx.Row, img *models.ProductImage) error {
+	return row.Scan(
+		&img.ImageID,
+		&img.ProductID,
+		&img.ObjectKey,
+		&img.URL,
+		&img.ContentType,
+		&img.Size,
+		&img.Width,
+		&img.Height,
+		&img.Position,
+		&img.IsPrimary,
+		&img.Variants,
+		&img.CreatedAt,
+	)
+}
+
+// GetImagesByProductID fetches a product's images in display order
+func (r *ProductImageRepository) GetImagesByProductID(ctx context.Context, productID int) ([]models.ProductImage, error) {
+	query := `SELECT ` + productImageColumns + `
+	          FROM product_images WHERE "productId" = $1 ORDER BY position, "imageId"`
+
+	rows, err := r.pool.Query(ctx, query, productID)
+	if err != nil {
+		log.Printf("Database error: GetImagesByProductID(%d) failed: %v", productID, err)
+		return nil, fmt.Errorf("failed to fetch product images: %w", err)
+	}
+	defer rows.Close()
+
+	images := []models.ProductImage{}
+	for rows.Next() {
+		var img models.ProductImage
+		if err := scanProductImage(rows, &img); err != nil {
+			log.Printf("Row scan error in GetImagesByProductID: %v", err)
+			return nil, fmt.Errorf("failed to scan product image row: %w", err)
+		}
+		images = append(images, img)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error in GetImagesByProductID: %w", err)
+	}
+
+	return images, nil
+}
+
+// GetPrimaryImages fetches the primary image of each given product, keyed by product ID
+func (r *ProductImageRepository) GetPrimaryImages(ctx context.Context, productIDs []int) (map[int]models.ProductImage, error) {
+	query := `SELECT ` + productImageColumns + `
+	          FROM product_images WHERE "productId" = ANY($1) AND "isPrimary"`
+
+	rows, err := r.pool.Query(ctx, query, productIDs)
+	if err != nil {
+		log.Printf("Database error: GetPrimaryImages failed: %v", err)
+		return nil, fmt.Errorf("failed to fetch primary images: %w", err)
+	}
+	defer rows.Close()
+
+	images := make(map[int]models.ProductImage, len(productIDs))
+	for rows.Next() {
+		var img models.ProductImage
+		if err := scanProductImage(rows, &img); err != nil {
+			log.Printf("Row scan error in GetPrimaryImages: %v", err)
+			return nil, fmt.Errorf("failed to scan product image row: %w", err)
+		}
+		images[img.ProductID] = img
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error in GetPrimaryImages: %w", err)
+	}
+
+	return images, nil
+}
+
+// GetImageByID fetches a single image belonging to a product
+func (r *ProductImageRepository) GetImageByID(ctx context.Context, productID, imageID int) (*models.ProductImage, error) {
+	query := `SELECT ` + productImageColumns + `
+	          FROM product_images WHERE "productId" = $1 AND "imageId" = $2`
+
+	var img models.ProductImage
+	err := scanProductImage(r.pool.QueryRow(ctx, query, productID, imageID), &img)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		log.Printf("Database error: GetImageByID(%d, %d) failed: %v", productID, imageID, err)
+		return nil, fmt.Errorf("failed to fetch product image: %w", err)
+	}
+
+	return &img, nil
+}
+
+// AddImage inserts a new image for a product. A negative position appends the image
+// after the existing ones. The first image of a product always becomes its primary image.
+func (r *ProductImageRepository) AddImage(ctx context.Context, image models.ProductImage) (*models.ProductImage, error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	query := `
+		INSERT INTO product_images ("productId", "objectKey", url, "contentType", size, width, height, variants, position)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8,
+			CASE WHEN $9::int >= 0 THEN $9::int
+			     ELSE (SELECT COALESCE(MAX(position) + 1, 0) FROM product_images WHERE "productId" = $1)
+			END)
+		RETURNING ` + productImageColumns
+
+	var img models.ProductImage
+	err = scanProductImage(tx.QueryRow(ctx, query,
+		image.ProductID,
+		image.ObjectKey,
+		image.URL,
+		image.ContentType,
+		image.Size,
+		image.Width,
+		image.Height,
+		image.Variants,
+		image.Position,
+	), &img)
+	if err != nil {
+		log.Printf("Database error: AddImage failed: %v", err)
+		return nil, fmt.Errorf("failed to add product image: %w", err)
+	}
+
+	var hasPrimary bool
+	err = tx.QueryRow(ctx,
+		`SELECT EXISTS (SELECT 1 FROM product_images WHERE "productId" = $1 AND "isPrimary")`,
+		img.ProductID,
+	).Scan(&hasPrimary)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check primary image: %w", err)
+	}
+
+	if image.IsPrimary || !hasPrimary {
+		if err := r.setPrimary(ctx, tx, img.ProductID, img.ImageID); err != nil {
+			return nil, err
+		}
+		img.IsPrimary = true
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return &img, nil
+}
+
+// SetPrimaryImage marks an image as the product's primary image
+func (r *ProductImageRepository) SetPrimaryImage(ctx context.Context, productID, imageID int) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if err := r.setPrimary(ctx, tx, productID, imageID); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// setPrimary flips the primary flag and mirrors the image URL onto products.image
+// so clients reading the single image field keep working.
+func (r *ProductImageRepository) setPrimary(ctx context.Context, db Tx, productID, imageID int) error {
+	if _, err := db.Exec(ctx,
+		`UPDATE product_images SET "isPrimary" = FALSE WHERE "productId" = $1 AND "isPrimary"`,
+		productID,
+	); err != nil {
+		log.Printf("Database error: setPrimary(%d, %d) failed: %v", productID, imageID, err)
+		return fmt.Errorf("failed to clear primary image: %w", err)
+	}
+
+	tag, err := db.Exec(ctx, `
+		WITH img AS (
+			UPDATE product_images SET "isPrimary" = TRUE
+			WHERE "productId" = $1 AND "imageId" = $2
+			RETURNING url
+		)
+		UPDATE products SET image = img.url, `+touchProduct+` FROM img WHERE "productId" = $1
+	`, productID, imageID)
+	if err != nil {
+		log.Printf("Database error: setPrimary(%d, %d) failed: %v", productID, imageID, err)
+		return fmt.Errorf("failed to set primary image: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
+
+// ReorderImages sets image positions to match the order of imageIDs, which must
+// list every image of the product exactly once
+func (r *ProductImageRepository) ReorderImages(ctx context.Context, productID int, imageIDs []int) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	var total int
+	if err := tx.QueryRow(ctx,
+		`SELECT COUNT(*) FROM product_images WHERE "productId" = $1`, productID,
+	).Scan(&total); err != nil {
+		return fmt.Errorf("failed to count product images: %w", err)
+	}
+
+	tag, err := tx.Exec(ctx, `
+		UPDATE product_images pi
+		SET position = o.ord - 1
+		FROM unnest($2::int[]) WITH ORDINALITY AS o(id, ord)
+		WHERE pi."productId" = $1 AND pi."imageId" = o.id
+	`, productID, imageIDs)
+	if err != nil {
+		log.Printf("Database error: ReorderImages(%d) failed: %v", productID, err)
+		return fmt.Errorf("failed to reorder product images: %w", err)
+	}
+	if int(tag.RowsAffected()) != total || len(imageIDs) != total {
+		return ErrImageSetMismatch
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// DeleteImage removes an image. If it was the primary image, the next image in
+// display order is promoted.
+func (r *ProductImageRepository) DeleteImage(ctx context.Context, productID, imageID int) (*models.ProductImage, error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	query := `DELETE FROM product_images WHERE "productId" = $1 AND "imageId" = $2
+	          RETURNING ` + productImageColumns
+
+	var img models.ProductImage
+	if err := scanProductImage(tx.QueryRow(ctx, query, productID, imageID), &img); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		log.Printf("Database error: DeleteImage(%d, %d) failed: %v", productID, imageID, err)
+		return nil, fmt.Errorf("failed to delete product image: %w", err)
+	}
+
+	if img.IsPrimary {
+		var nextID int
+		err := tx.QueryRow(ctx,
+			`SELECT "imageId" FROM product_images WHERE "productId" = $1 ORDER BY position, "imageId" LIMIT 1`,
+			productID,
+		).Scan(&nextID)
+		switch {
+		case errors.Is(err, pgx.ErrNoRows):
+		case err != nil:
+			return nil, fmt.Errorf("failed to find next primary image: %w", err)
+		default:
+			if err := r.setPrimary(ctx, tx, productID, nextID); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return &img, nil
+}
@@ -0,0 +1,319 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/your-username/golang-ecommerce-app/models"
+)
+
+// ErrPriceNotCancellable is returned when cancelling a price that has already run its course
+var ErrPriceNotCancellable = errors.New("only pending prices and running sales can be cancelled")
+
+type ProductPriceRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewProductPriceRepository(pool *pgxpool.Pool) *ProductPriceRepository {
+	return &ProductPriceRepository{pool: pool}
+}
+
+const productPriceColumns = `"priceId", "productId", kind, price, "effectiveFrom", "effectiveTo", "appliedAt", "createdAt"`
+
+func scanProductPrice(row pgx.Row, p *models.ProductPrice) error {
+	return row.Scan(
+		&p.PriceID,
+		&p.ProductID,
+		&p.Kind,
+		&p.Price,
+		&p.EffectiveFrom,
+		&p.EffectiveTo,
+		&p.AppliedAt,
+		&p.CreatedAt,
+	)
+}
+
+// GetPriceHistory fetches every regular and sale price of a product, newest first
+func (r *ProductPriceRepository) GetPriceHistory(ctx context.Context, productID int) ([]models.ProductPrice, error) {
+	query := `SELECT ` + productPriceColumns + `
+	          FROM product_prices WHERE "productId" = $1
+	          ORDER BY "effectiveFrom" DESC, "priceId" DESC`
+
+	rows, err := r.pool.Query(ctx, query, productID)
+	if err != nil {
+		log.Printf("Database error: GetPriceHistory(%d) failed: %v", productID, err)
+		return nil, fmt.Errorf("failed to fetch price history: %w", err)
+	}
+	defer rows.Close()
+
+	prices := []models.ProductPrice{}
+	for rows.Next() {
+		var p models.ProductPrice
+		if err := scanProductPrice(rows, &p); err != nil {
+			log.Printf("Row scan error in GetPriceHistory: %v", err)
+			return nil, fmt.Errorf("failed to scan product price row: %w", err)
+		}
+		prices = append(prices, p)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error in GetPriceHistory: %w", err)
+	}
+
+	return prices, nil
+}
+
+// GetPriceByID fetches a single price entry belonging to a product
+func (r *ProductPriceRepository) GetPriceByID(ctx context.Context, productID, priceID int) (*models.ProductPrice, error) {
+	query := `SELECT ` + productPriceColumns + `
+	          FROM product_prices WHERE "productId" = $1 AND "priceId" = $2`
+
+	var p models.ProductPrice
+	err := scanProductPrice(r.pool.QueryRow(ctx, query, productID, priceID), &p)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		log.Printf("Database error: GetPriceByID(%d, %d) failed: %v", productID, priceID, err)
+		return nil, fmt.Errorf("failed to fetch product price: %w", err)
+	}
+
+	return &p, nil
+}
+
+// recordRegularPrice closes the product's current regular price and opens a
+// new one at price, effective now. Nothing is recorded if the price is
+// unchanged. It runs inside the transaction that changed the product's price,
+// so the price and its history are saved together.
+func recordRegularPrice(ctx context.Context, db Tx, productID int, price float64, now time.Time) error {
+	var current float64
+	err := db.QueryRow(ctx, `
+		SELECT price FROM product_prices
+		WHERE "productId" = $1 AND kind = 'regular' AND "appliedAt" IS NOT NULL AND "effectiveTo" IS NULL
+		FOR UPDATE`, productID,
+	).Scan(&current)
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+	case err != nil:
+		log.Printf("Database error: recordRegularPrice(%d) failed: %v", productID, err)
+		return fmt.Errorf("failed to fetch current price: %w", err)
+	case current == price:
+		return nil
+	}
+
+	if err := closeRegularPrice(ctx, db, productID, now); err != nil {
+		return err
+	}
+
+	if _, err := db.Exec(ctx, `
+		INSERT INTO product_prices ("productId", kind, price, "effectiveFrom", "appliedAt")
+		VALUES ($1, 'regular', $2, $3, $3)`,
+		productID, price, now,
+	); err != nil {
+		log.Printf("Database error: recordRegularPrice(%d) failed: %v", productID, err)
+		return fmt.Errorf("failed to record price: %w", err)
+	}
+	return nil
+}
+
+// closeRegularPrice ends the product's current regular price at the given time
+func closeRegularPrice(ctx context.Context, db Tx, productID int, at time.Time) error {
+	if _, err := db.Exec(ctx, `
+		UPDATE product_prices SET "effectiveTo" = GREATEST($2, "effectiveFrom")
+		WHERE "productId" = $1 AND kind = 'regular' AND "appliedAt" IS NOT NULL AND "effectiveTo" IS NULL`,
+		productID, at,
+	); err != nil {
+		log.Printf("Database error: closeRegularPrice(%d) failed: %v", productID, err)
+		return fmt.Errorf("failed to close current price: %w", err)
+	}
+	return nil
+}
+
+// AddPrice schedules a regular or sale price. Regular prices take effect when
+// ApplyDueRegularPrices next runs after their effectiveFrom.
+func (r *ProductPriceRepository) AddPrice(ctx context.Context, price models.ProductPrice) (*models.ProductPrice, error) {
+	query := `
+		INSERT INTO product_prices ("productId", kind, price, "effectiveFrom", "effectiveTo")
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING ` + productPriceColumns
+
+	var p models.ProductPrice
+	err := scanProductPrice(r.pool.QueryRow(ctx, query,
+		price.ProductID,
+		price.Kind,
+		price.Price,
+		price.EffectiveFrom,
+		price.EffectiveTo,
+	), &p)
+	if err != nil {
+		log.Printf("Database error: AddPrice(%d) failed: %v", price.ProductID, err)
+		return nil, fmt.Errorf("failed to add product price: %w", err)
+	}
+
+	return &p, nil
+}
+
+// ApplyDueRegularPrices copies every pending regular price whose effectiveFrom
+// is at or before now onto its product, in effective order, and returns the IDs
+// of the products that changed
+func (r *ProductPriceRepository) ApplyDueRegularPrices(ctx context.Context, now time.Time) ([]int, error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	// SKIP LOCKED lets several instances run the scheduler without applying a price twice
+	rows, err := tx.Query(ctx, `
+		SELECT `+productPriceColumns+` FROM product_prices
+		WHERE kind = 'regular' AND "appliedAt" IS NULL AND "effectiveFrom" <= $1
+		ORDER BY "effectiveFrom", "priceId"
+		FOR UPDATE SKIP LOCKED`, now)
+	if err != nil {
+		log.Printf("Database error: ApplyDueRegularPrices failed: %v", err)
+		return nil, fmt.Errorf("failed to fetch due prices: %w", err)
+	}
+
+	var due []models.ProductPrice
+	for rows.Next() {
+		var p models.ProductPrice
+		if err := scanProductPrice(rows, &p); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan product price row: %w", err)
+		}
+		due = append(due, p)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error in ApplyDueRegularPrices: %w", err)
+	}
+
+	changed := make(map[int]bool)
+	var ids []int
+	for _, p := range due {
+		if err := closeRegularPrice(ctx, tx, p.ProductID, p.EffectiveFrom); err != nil {
+			return nil, err
+		}
+
+		if _, err := tx.Exec(ctx,
+			`UPDATE product_prices SET "appliedAt" = NOW() WHERE "priceId" = $1`, p.PriceID,
+		); err != nil {
+			log.Printf("Database error: ApplyDueRegularPrices(%d) failed: %v", p.PriceID, err)
+			return nil, fmt.Errorf("failed to mark price applied: %w", err)
+		}
+
+		if _, err := tx.Exec(ctx,
+			`UPDATE products SET price = $2, `+touchProduct+` WHERE "productId" = $1`,
+			p.ProductID, p.Price,
+		); err != nil {
+			log.Printf("Database error: ApplyDueRegularPrices(%d) failed: %v", p.PriceID, err)
+			return nil, fmt.Errorf("failed to update product price: %w", err)
+		}
+
+		if !changed[p.ProductID] {
+			changed[p.ProductID] = true
+			ids = append(ids, p.ProductID)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return ids, nil
+}
+
+// RefreshSalePrices sets each product's salePrice to the sale running at now,
+// clearing it when none is, and returns the IDs of the products that changed
+func (r *ProductPriceRepository) RefreshSalePrices(ctx context.Context, now time.Time) ([]int, error) {
+	query := `
+		UPDATE products p
+		SET "salePrice" = a.price, "saleEndsAt" = a."effectiveTo", ` + touchProduct + `
+		FROM (
+			SELECT c."productId", s.price, s."effectiveTo"
+			FROM products c
+			LEFT JOIN LATERAL (
+				SELECT price, "effectiveTo" FROM product_prices pp
+				WHERE pp."productId" = c."productId" AND pp.kind = 'sale'
+				  AND pp."effectiveFrom" <= $1 AND (pp."effectiveTo" IS NULL OR pp."effectiveTo" > $1)
+				ORDER BY pp."effectiveFrom" DESC, pp."priceId" DESC
+				LIMIT 1
+			) s ON TRUE
+			WHERE c."salePrice" IS NOT NULL
+			   OR EXISTS (SELECT 1 FROM product_prices e WHERE e."productId" = c."productId" AND e.kind = 'sale')
+		) a
+		WHERE p."productId" = a."productId"
+		  AND (p."salePrice" IS DISTINCT FROM a.price OR p."saleEndsAt" IS DISTINCT FROM a."effectiveTo")
+		RETURNING p."productId"`
+
+	rows, err := r.pool.Query(ctx, query, now)
+	if err != nil {
+		log.Printf("Database error: RefreshSalePrices failed: %v", err)
+		return nil, fmt.Errorf("failed to refresh sale prices: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan product ID: %w", err)
+		}
+		ids = append(ids, id)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error in RefreshSalePrices: %w", err)
+	}
+
+	return ids, nil
+}
+
+// CancelPrice withdraws a price that has not taken effect yet, or ends a running
+// sale at now. It returns nil if the price does not exist.
+func (r *ProductPriceRepository) CancelPrice(ctx context.Context, productID, priceID int, now time.Time) (*models.ProductPrice, error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	query := `SELECT ` + productPriceColumns + `
+	          FROM product_prices WHERE "productId" = $1 AND "priceId" = $2 FOR UPDATE`
+
+	var p models.ProductPrice
+	if err := scanProductPrice(tx.QueryRow(ctx, query, productID, priceID), &p); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		log.Printf("Database error: CancelPrice(%d, %d) failed: %v", productID, priceID, err)
+		return nil, fmt.Errorf("failed to fetch product price: %w", err)
+	}
+
+	pending := (p.Kind == models.PriceKindRegular && p.AppliedAt == nil) ||
+		(p.Kind == models.PriceKindSale && p.EffectiveFrom.After(now))
+	running := p.Kind == models.PriceKindSale && !pending && (p.EffectiveTo == nil || p.EffectiveTo.After(now))
+
+	switch {
+	case pending:
+		_, err = tx.Exec(ctx, `DELETE FROM product_prices WHERE "priceId" = $1`, priceID)
+	case running:
+		p.EffectiveTo = &now
+		_, err = tx.Exec(ctx, `UPDATE product_prices SET "effectiveTo" = $2 WHERE "priceId" = $1`, priceID, now)
+	default:
+		return nil, ErrPriceNotCancellable
+	}
+	if err != nil {
+		log.Printf("Database error: CancelPrice(%d, %d) failed: %v", productID, priceID, err)
+		return nil, fmt.Errorf("failed to cancel product price: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return &p, nil
+}
@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type RecentlyViewedRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewRecentlyViewedRepository(pool *pgxpool.Pool) *RecentlyViewedRepository {
+	return &RecentlyViewedRepository{pool: pool}
+}
+
+// RecordView marks a product as just viewed by the user and drops the user's
+// views beyond the keep most recent
+func (r *RecentlyViewedRepository) RecordView(ctx context.Context, userID string, productID, keep int) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `
+		INSERT INTO recently_viewed ("userId", "productId", "viewedAt")
+		VALUES ($1, $2, NOW())
+		ON CONFLICT ("userId", "productId") DO UPDATE SET "viewedAt" = EXCLUDED."viewedAt"`,
+		userID, productID,
+	); err != nil {
+		log.Printf("Database error: RecordView(%s, %d) failed: %v", userID, productID, err)
+		return fmt.Errorf("failed to record product view: %w", err)
+	}
+
+	if _, err := tx.Exec(ctx, `
+		DELETE FROM recently_viewed
+		WHERE "userId" = $1 AND "productId" NOT IN (
+			SELECT "productId" FROM recently_viewed WHERE "userId" = $1
+			ORDER BY "viewedAt" DESC LIMIT $2
+		)`,
+		userID, keep,
+	); err != nil {
+		log.Printf("Database error: RecordView(%s, %d) prune failed: %v", userID, productID, err)
+		return fmt.Errorf("failed to prune product views: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// GetRecentlyViewedIDs returns the IDs of the products the user viewed most recently, newest first
+func (r *RecentlyViewedRepository) GetRecentlyViewedIDs(ctx context.Context, userID string, limit int) ([]int, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT "productId" FROM recently_viewed WHERE "userId" = $1
+		ORDER BY "viewedAt" DESC LIMIT $2`,
+		userID, limit,
+	)
+	if err != nil {
+		log.Printf("Database error: GetRecentlyViewedIDs(%s) failed: %v", userID, err)
+		return nil, fmt.Errorf("failed to fetch recently viewed products: %w", err)
+	}
+	defer rows.Close()
+
+	ids := []int{}
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan product ID: %w", err)
+		}
+		ids = append(ids, id)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error in GetRecentlyViewedIDs: %w", err)
+	}
+
+	return ids, nil
+}
@@ -0,0 +1,148 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/your-username/golang-ecommerce-app/models"
+)
+
+var (
+	// ErrRefreshTokenInvalid is returned for unknown, expired or revoked refresh tokens
+	ErrRefreshTokenInvalid = errors.New("invalid or expired refresh token")
+	// ErrRefreshTokenReused is returned when an already rotated refresh token is
+	// presented again. Its whole family has been revoked by then.
+	ErrRefreshTokenReused = errors.New("refresh token reuse detected")
+)
+
+type RefreshTokenRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewRefreshTokenRepository(pool *pgxpool.Pool) *RefreshTokenRepository {
+	return &RefreshTokenRepository{pool: pool}
+}
+
+const refreshTokenColumns = `"tokenId", "tokenHash", "familyId", "userId", "expiresAt", "usedAt", "revokedAt", "createdAt"`
+
+func scanRefreshToken(row pgx.Row, t *models.RefreshToken) error {
+	return row.Scan(
+		&t.TokenID,
+		&t.TokenHash,
+		&t.FamilyID,
+		&t.UserID,
+		&t.ExpiresAt,
+		&t.UsedAt,
+		&t.RevokedAt,
+		&t.CreatedAt,
+	)
+}
+
+// CreateRefreshToken stores a newly issued refresh token
+func (r *RefreshTokenRepository) CreateRefreshToken(ctx context.Context, token models.RefreshToken) error {
+	return insertRefreshToken(ctx, r.pool, token)
+}
+
+func insertRefreshToken(ctx context.Context, db Tx, token models.RefreshToken) error {
+	_, err := db.Exec(ctx, `
+		INSERT INTO refresh_tokens ("tokenHash", "familyId", "userId", "expiresAt")
+		VALUES ($1, $2, $3, $4)`,
+		token.TokenHash, token.FamilyID, token.UserID, token.ExpiresAt,
+	)
+	if err != nil {
+		log.Printf("Database error: insertRefreshToken failed: %v", err)
+		return fmt.Errorf("failed to store refresh token: %w", err)
+	}
+	return nil
+}
+
+// RotateRefreshToken exchanges the token with hash oldHash for next, which joins
+// the same family. It returns the exchanged token. A token that was already
+// exchanged revokes its family and yields ErrRefreshTokenReused, since either the
+// client or an attacker holds a stolen copy and we cannot tell which.
+func (r *RefreshTokenRepository) RotateRefreshToken(ctx context.Context, oldHash string, next models.RefreshToken, now time.Time) (*models.RefreshToken, error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	var current models.RefreshToken
+	err = scanRefreshToken(tx.QueryRow(ctx,
+		`SELECT `+refreshTokenColumns+` FROM refresh_tokens WHERE "tokenHash" = $1 FOR UPDATE`,
+		oldHash,
+	), &current)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrRefreshTokenInvalid
+		}
+		log.Printf("Database error: RotateRefreshToken lookup failed: %v", err)
+		return nil, fmt.Errorf("failed to look up refresh token: %w", err)
+	}
+
+	if current.RevokedAt != nil || !current.ExpiresAt.After(now) {
+		return nil, ErrRefreshTokenInvalid
+	}
+
+	if current.UsedAt != nil {
+		if err := revokeRefreshFamily(ctx, tx, current.FamilyID, now); err != nil {
+			return nil, err
+		}
+		if err := tx.Commit(ctx); err != nil {
+			return nil, fmt.Errorf("failed to commit transaction: %w", err)
+		}
+		return &current, ErrRefreshTokenReused
+	}
+
+	if _, err := tx.Exec(ctx,
+		`UPDATE refresh_tokens SET "usedAt" = $2 WHERE "tokenId" = $1`,
+		current.TokenID, now,
+	); err != nil {
+		log.Printf("Database error: RotateRefreshToken(%d) failed: %v", current.TokenID, err)
+		return nil, fmt.Errorf("failed to rotate refresh token: %w", err)
+	}
+
+	next.FamilyID = current.FamilyID
+	next.UserID = current.UserID
+	if err := insertRefreshToken(ctx, tx, next); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return &current, nil
+}
+
+// FindByHash fetches a refresh token by the hash of its value
+func (r *RefreshTokenRepository) FindByHash(ctx context.Context, tokenHash string) (*models.RefreshToken, error) {
+	var token models.RefreshToken
+	err := scanRefreshToken(r.pool.QueryRow(ctx,
+		`SELECT `+refreshTokenColumns+` FROM refresh_tokens WHERE "tokenHash" = $1`, tokenHash,
+	), &token)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		log.Printf("Database error: FindByHash failed: %v", err)
+		return nil, fmt.Errorf("failed to look up refresh token: %w", err)
+	}
+	return &token, nil
+}
+
+func revokeRefreshFamily(ctx context.Context, db Tx, familyID string, now time.Time) error {
+	_, err := db.Exec(ctx,
+		`UPDATE refresh_tokens SET "revokedAt" = $2 WHERE "familyId" = $1 AND "revokedAt" IS NULL`,
+		familyID, now,
+	)
+	if err != nil {
+		log.Printf("Database error: revokeRefreshFamily(%s) failed: %v", familyID, err)
+		return fmt.Errorf("failed to revoke refresh tokens: %w", err)
+	}
+	return nil
+}
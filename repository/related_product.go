@@ -0,0 +1,139 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/your-username/golang-ecommerce-app/models"
+)
+
+type RelatedProductRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewRelatedProductRepository(pool *pgxpool.Pool) *RelatedProductRepository {
+	return &RelatedProductRepository{pool: pool}
+}
+
+// ErrRebuildInProgress is returned when another instance is already rebuilding related products
+var ErrRebuildInProgress = errors.New("related products are already being rebuilt")
+
+// RebuildRelatedProducts recomputes, from every order's line items, how often each
+// pair of products was bought together and keeps the topN partners of each
+// product. It returns the number of pairs stored. Only one instance rebuilds at
+// a time; the others get ErrRebuildInProgress. The new pairs are computed into
+// a temporary table and swapped in within the same transaction, so readers see
+// the old pairs until the new ones are committed.
+func (r *RelatedProductRepository) RebuildRelatedProducts(ctx context.Context, topN int) (int64, error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	var locked bool
+	if err := tx.QueryRow(ctx, `SELECT pg_try_advisory_xact_lock(hashtext('product_related_rebuild'))`).Scan(&locked); err != nil {
+		log.Printf("Database error: RebuildRelatedProducts lock failed: %v", err)
+		return 0, fmt.Errorf("failed to lock related products: %w", err)
+	}
+	if !locked {
+		return 0, ErrRebuildInProgress
+	}
+
+	// An order counts once per pair however many units of each it contained
+	if _, err := tx.Exec(ctx, `
+		CREATE TEMP TABLE product_related_next ON COMMIT DROP AS
+		WITH items AS (
+			SELECT DISTINCT o."orderId", (item->>'productId')::int AS "productId"
+			FROM orders o, jsonb_array_elements(o."productInfo"::jsonb) AS item
+		),
+		pairs AS (
+			SELECT a."productId", b."productId" AS "relatedProductId", COUNT(*) AS score
+			FROM items a
+			JOIN items b ON a."orderId" = b."orderId" AND a."productId" <> b."productId"
+			GROUP BY a."productId", b."productId"
+		),
+		ranked AS (
+			SELECT pairs.*, ROW_NUMBER() OVER (
+				PARTITION BY pairs."productId" ORDER BY score DESC, pairs."relatedProductId"
+			) AS rank
+			FROM pairs
+			JOIN products p ON p."productId" = pairs."productId" AND p."deletedAt" IS NULL
+			JOIN products rp ON rp."productId" = pairs."relatedProductId" AND rp."deletedAt" IS NULL
+		)
+		SELECT "productId", "relatedProductId", score FROM ranked WHERE rank <= $1`, topN); err != nil {
+		log.Printf("Database error: RebuildRelatedProducts failed: %v", err)
+		return 0, fmt.Errorf("failed to rebuild related products: %w", err)
+	}
+
+	if _, err := tx.Exec(ctx, `DELETE FROM product_related`); err != nil {
+		log.Printf("Database error: RebuildRelatedProducts failed: %v", err)
+		return 0, fmt.Errorf("failed to clear related products: %w", err)
+	}
+
+	tag, err := tx.Exec(ctx, `
+		INSERT INTO product_related ("productId", "relatedProductId", score)
+		SELECT "productId", "relatedProductId", score FROM product_related_next`)
+	if err != nil {
+		log.Printf("Database error: RebuildRelatedProducts failed: %v", err)
+		return 0, fmt.Errorf("failed to store related products: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return tag.RowsAffected(), nil
+}
+
+// GetRelatedProducts fetches the published products most often bought with a product
+func (r *RelatedProductRepository) GetRelatedProducts(ctx context.Context, productID, limit int) ([]models.Product, error) {
+	query := `SELECT ` + productColumns + `
+	          FROM products
+	          JOIN (
+	              SELECT "relatedProductId", score FROM product_related WHERE "productId" = $1
+	          ) rel ON rel."relatedProductId" = products."productId"
+	          WHERE ` + publicProductFilter + `
+	          ORDER BY rel.score DESC, products."productId"
+	          LIMIT $2`
+
+	return r.queryProducts(ctx, "GetRelatedProducts", query, productID, limit)
+}
+
+// GetProductsInCategory fetches published products sharing a category, best rated first
+func (r *RelatedProductRepository) GetProductsInCategory(ctx context.Context, category string, excludeID, limit int) ([]models.Product, error) {
+	query := `SELECT ` + productColumns + `
+	          FROM products
+	          WHERE category = $1 AND "productId" <> $2 AND ` + publicProductFilter + `
+	          ORDER BY "ratingAverage" DESC, "ratingCount" DESC, "productId"
+	          LIMIT $3`
+
+	return r.queryProducts(ctx, "GetProductsInCategory", query, category, excludeID, limit)
+}
+
+func (r *RelatedProductRepository) queryProducts(ctx context.Context, op, query string, args ...any) ([]models.Product, error) {
+	rows, err := r.pool.Query(ctx, query, args...)
+	if err != nil {
+		log.Printf("Database error: %s failed: %v", op, err)
+		return nil, fmt.Errorf("failed to fetch products: %w", err)
+	}
+	defer rows.Close()
+
+	products := []models.Product{}
+	for rows.Next() {
+		var p models.Product
+		if err := scanProduct(rows, &p); err != nil {
+			log.Printf("Row scan error in %s: %v", op, err)
+			return nil, fmt.Errorf("failed to scan product row: %w", err)
+		}
+		products = append(products, p)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error in %s: %w", op, err)
+	}
+
+	return products, nil
+}
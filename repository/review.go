@@ -0,0 +1,218 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/your-username/golang-ecommerce-app/models"
+)
+
+// ErrDuplicateReview is returned when a user reviews the same product twice
+var ErrDuplicateReview = errors.New("you have already reviewed this product")
+
+type ReviewRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewReviewRepository(pool *pgxpool.Pool) *ReviewRepository {
+	return &ReviewRepository{pool: pool}
+}
+
+const reviewColumns = `"reviewId", "productId", "userId", "orderId", rating, title, body, status, COALESCE("moderatedBy", ''), "moderatedAt", "createdAt",
+	COALESCE((SELECT "displayName" FROM users WHERE users."userId" = product_reviews."userId"), '')`
+
+func scanReview(row pgx.Row, r *models.Review) error {
+	return row.Scan(
+		&r.ReviewID,
+		&r.ProductID,
+		&r.UserID,
+		&r.OrderID,
+		&r.Rating,
+		&r.Title,
+		&r.Body,
+		&r.Status,
+		&r.ModeratedBy,
+		&r.ModeratedAt,
+		&r.CreatedAt,
+		&r.AuthorName,
+	)
+}
+
+// FindPurchaseOrderID returns the most recent order of the user that contains
+// the product, or 0 if they never bought it
+func (r *ReviewRepository) FindPurchaseOrderID(ctx context.Context, userID string, productID int) (int, error) {
+	query := `
+		SELECT "orderId" FROM orders o
+		WHERE o."userId" = $1 AND EXISTS (
+			SELECT 1 FROM jsonb_array_elements(o."productInfo"::jsonb) AS item
+			WHERE (item->>'productId')::int = $2
+		)
+		ORDER BY o."createdAt" DESC
+		LIMIT 1`
+
+	var orderID int
+	err := r.pool.QueryRow(ctx, query, userID, productID).Scan(&orderID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, nil
+		}
+		log.Printf("Database error: FindPurchaseOrderID(%s, %d) failed: %v", userID, productID, err)
+		return 0, fmt.Errorf("failed to check purchase: %w", err)
+	}
+
+	return orderID, nil
+}
+
+// CreateReview inserts a new review awaiting moderation
+func (r *ReviewRepository) CreateReview(ctx context.Context, review models.Review) (*models.Review, error) {
+	query := `
+		INSERT INTO product_reviews ("productId", "userId", "orderId", rating, title, body)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING ` + reviewColumns
+
+	var rv models.Review
+	err := scanReview(r.pool.QueryRow(ctx, query,
+		review.ProductID,
+		review.UserID,
+		review.OrderID,
+		review.Rating,
+		review.Title,
+		review.Body,
+	), &rv)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return nil, ErrDuplicateReview
+		}
+		log.Printf("Database error: CreateReview failed: %v", err)
+		return nil, fmt.Errorf("failed to create review: %w", err)
+	}
+
+	return &rv, nil
+}
+
+// GetReviewsByProduct fetches a page of a product's reviews in the given state, newest first
+func (r *ReviewRepository) GetReviewsByProduct(ctx context.Context, productID int, status string, limit, offset int) ([]models.Review, int, error) {
+	query := `SELECT ` + reviewColumns + `
+	          FROM product_reviews WHERE "productId" = $1 AND status = $2
+	          ORDER BY "createdAt" DESC, "reviewId" DESC LIMIT $3 OFFSET $4`
+
+	reviews, err := r.queryReviews(ctx, "GetReviewsByProduct", query, productID, status, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var total int
+	err = r.pool.QueryRow(ctx,
+		`SELECT COUNT(*) FROM product_reviews WHERE "productId" = $1 AND status = $2`,
+		productID, status,
+	).Scan(&total)
+	if err != nil {
+		log.Printf("Database error: GetReviewsByProduct count failed: %v", err)
+		return nil, 0, fmt.Errorf("failed to count reviews: %w", err)
+	}
+
+	return reviews, total, nil
+}
+
+// GetReviewsByStatus fetches a page of reviews across all products for
+// moderation, oldest first so the queue is worked in order
+func (r *ReviewRepository) GetReviewsByStatus(ctx context.Context, status string, limit, offset int) ([]models.Review, int, error) {
+	query := `SELECT ` + reviewColumns + `
+	          FROM product_reviews WHERE status = $1
+	          ORDER BY "createdAt", "reviewId" LIMIT $2 OFFSET $3`
+
+	reviews, err := r.queryReviews(ctx, "GetReviewsByStatus", query, status, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var total int
+	err = r.pool.QueryRow(ctx, `SELECT COUNT(*) FROM product_reviews WHERE status = $1`, status).Scan(&total)
+	if err != nil {
+		log.Printf("Database error: GetReviewsByStatus count failed: %v", err)
+		return nil, 0, fmt.Errorf("failed to count reviews: %w", err)
+	}
+
+	return reviews, total, nil
+}
+
+func (r *ReviewRepository) queryReviews(ctx context.Context, op, query string, args ...any) ([]models.Review, error) {
+	rows, err := r.pool.Query(ctx, query, args...)
+	if err != nil {
+		log.Printf("Database error: %s failed: %v", op, err)
+		return nil, fmt.Errorf("failed to fetch reviews: %w", err)
+	}
+	defer rows.Close()
+
+	reviews := []models.Review{}
+	for rows.Next() {
+		var rv models.Review
+		if err := scanReview(rows, &rv); err != nil {
+			log.Printf("Row scan error in %s: %v", op, err)
+			return nil, fmt.Errorf("failed to scan review row: %w", err)
+		}
+		reviews = append(reviews, rv)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error in %s: %w", op, err)
+	}
+
+	return reviews, nil
+}
+
+// SetReviewStatus moderates a review and refreshes the product's rating
+// aggregates in the same transaction. It returns nil if the review does not exist.
+func (r *ReviewRepository) SetReviewStatus(ctx context.Context, reviewID int, status, moderatorID string) (*models.Review, error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	query := `
+		UPDATE product_reviews
+		SET status = $2, "moderatedBy" = $3, "moderatedAt" = NOW()
+		WHERE "reviewId" = $1
+		RETURNING ` + reviewColumns
+
+	var rv models.Review
+	if err := scanReview(tx.QueryRow(ctx, query, reviewID, status, moderatorID), &rv); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		log.Printf("Database error: SetReviewStatus(%d) failed: %v", reviewID, err)
+		return nil, fmt.Errorf("failed to update review status: %w", err)
+	}
+
+	if err := refreshProductRating(ctx, tx, rv.ProductID); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return &rv, nil
+}
+
+// refreshProductRating recomputes a product's rating from its approved reviews.
+// Ratings are not admin-editable, so the product version is left alone.
+func refreshProductRating(ctx context.Context, db Tx, productID int) error {
+	_, err := db.Exec(ctx, `
+		UPDATE products p
+		SET "ratingAverage" = COALESCE(agg.average, 0), "ratingCount" = agg.count
+		FROM (
+			SELECT ROUND(AVG(rating)::numeric, 2) AS average, COUNT(*) AS count
+			FROM product_reviews WHERE "productId" = $1 AND status = 'approved'
+		) agg
+		WHERE p."productId" = $1`, productID)
+	if err != nil {
+		log.Printf("Database error: refreshProductRating(%d) failed: %v", productID, err)
+		return fmt.Errorf("failed to refresh product rating: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,132 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/your-username/golang-ecommerce-app/models"
+)
+
+type RoleRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewRoleRepository(pool *pgxpool.Pool) *RoleRepository {
+	return &RoleRepository{pool: pool}
+}
+
+// GetRoles lists every role with its permissions, ordered by name
+func (r *RoleRepository) GetRoles(ctx context.Context) ([]models.Role, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT r.name, r.description, r."builtIn", r."createdAt", r."updatedAt",
+			COALESCE(array_agg(p.permission ORDER BY p.permission) FILTER (WHERE p.permission IS NOT NULL), '{}')
+		FROM roles r
+		LEFT JOIN role_permissions p ON p.role = r.name
+		GROUP BY r.name
+		ORDER BY r.name`)
+	if err != nil {
+		log.Printf("Database error: GetRoles failed: %v", err)
+		return nil, fmt.Errorf("failed to fetch roles: %w", err)
+	}
+	defer rows.Close()
+
+	roles := []models.Role{}
+	for rows.Next() {
+		var role models.Role
+		if err := rows.Scan(&role.Name, &role.Description, &role.BuiltIn, &role.CreatedAt, &role.UpdatedAt, &role.Permissions); err != nil {
+			log.Printf("Row scan error in GetRoles: %v", err)
+			return nil, fmt.Errorf("failed to scan role row: %w", err)
+		}
+		roles = append(roles, role)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error in GetRoles: %w", err)
+	}
+
+	return roles, nil
+}
+
+// GetRole fetches a role with its permissions
+func (r *RoleRepository) GetRole(ctx context.Context, name string) (*models.Role, error) {
+	var role models.Role
+	err := r.pool.QueryRow(ctx, `
+		SELECT r.name, r.description, r."builtIn", r."createdAt", r."updatedAt",
+			COALESCE(array_agg(p.permission ORDER BY p.permission) FILTER (WHERE p.permission IS NOT NULL), '{}')
+		FROM roles r
+		LEFT JOIN role_permissions p ON p.role = r.name
+		WHERE r.name = $1
+		GROUP BY r.name`,
+		name,
+	).Scan(&role.Name, &role.Description, &role.BuiltIn, &role.CreatedAt, &role.UpdatedAt, &role.Permissions)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		log.Printf("Database error: GetRole(%s) failed: %v", name, err)
+		return nil, fmt.Errorf("failed to fetch role: %w", err)
+	}
+	return &role, nil
+}
+
+// SaveRole creates the role or replaces its description and permissions
+func (r *RoleRepository) SaveRole(ctx context.Context, role models.Role) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `
+		INSERT INTO roles (name, description) VALUES ($1, $2)
+		ON CONFLICT (name) DO UPDATE SET description = EXCLUDED.description, "updatedAt" = NOW()`,
+		role.Name, role.Description,
+	); err != nil {
+		log.Printf("Database error: SaveRole(%s) failed: %v", role.Name, err)
+		return fmt.Errorf("failed to save role: %w", err)
+	}
+
+	if _, err := tx.Exec(ctx, `DELETE FROM role_permissions WHERE role = $1`, role.Name); err != nil {
+		log.Printf("Database error: SaveRole(%s) permissions delete failed: %v", role.Name, err)
+		return fmt.Errorf("failed to save role permissions: %w", err)
+	}
+	for _, permission := range role.Permissions {
+		if _, err := tx.Exec(ctx,
+			`INSERT INTO role_permissions (role, permission) VALUES ($1, $2)`,
+			role.Name, permission,
+		); err != nil {
+			log.Printf("Database error: SaveRole(%s) permission insert failed: %v", role.Name, err)
+			return fmt.Errorf("failed to save role permissions: %w", err)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// DeleteRole removes a role that is not built in. It reports false when there
+// is no such role.
+func (r *RoleRepository) DeleteRole(ctx context.Context, name string) (bool, error) {
+	tag, err := r.pool.Exec(ctx, `DELETE FROM roles WHERE name = $1 AND NOT "builtIn"`, name)
+	if err != nil {
+		log.Printf("Database error: DeleteRole(%s) failed: %v", name, err)
+		return false, fmt.Errorf("failed to delete role: %w", err)
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+// CountUsersWithRole returns how many users hold the role
+func (r *RoleRepository) CountUsersWithRole(ctx context.Context, name string) (int, error) {
+	var count int
+	if err := r.pool.QueryRow(ctx, `SELECT COUNT(*) FROM users WHERE role = $1`, name).Scan(&count); err != nil {
+		log.Printf("Database error: CountUsersWithRole(%s) failed: %v", name, err)
+		return 0, fmt.Errorf("failed to count users with role: %w", err)
+	}
+	return count, nil
+}
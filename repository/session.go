@@ -0,0 +1,194 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/your-username/golang-ecommerce-app/models"
+)
+
+type SessionRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewSessionRepository(pool *pgxpool.Pool) *SessionRepository {
+	return &SessionRepository{pool: pool}
+}
+
+const sessionColumns = `"sessionId", "userId", device, ip, "userAgent", "mfaVerified", "createdAt", "lastSeenAt", "revokedAt"`
+
+func scanSession(row pgx.Row, s *models.Session) error {
+	return row.Scan(
+		&s.SessionID,
+		&s.UserID,
+		&s.Device,
+		&s.IP,
+		&s.UserAgent,
+		&s.MFAVerified,
+		&s.CreatedAt,
+		&s.LastSeenAt,
+		&s.RevokedAt,
+	)
+}
+
+// CreateSession records a new login
+func (r *SessionRepository) CreateSession(ctx context.Context, session models.Session) error {
+	_, err := r.pool.Exec(ctx, `
+		INSERT INTO sessions ("sessionId", "userId", device, ip, "userAgent", "mfaVerified")
+		VALUES ($1, $2, $3, $4, $5, $6)`,
+		session.SessionID, session.UserID, session.Device, session.IP, session.UserAgent, session.MFAVerified,
+	)
+	if err != nil {
+		log.Printf("Database error: CreateSession failed: %v", err)
+		return fmt.Errorf("failed to create session: %w", err)
+	}
+	return nil
+}
+
+// GetSession fetches a session by ID, whether or not it is still active
+func (r *SessionRepository) GetSession(ctx context.Context, sessionID string) (*models.Session, error) {
+	var s models.Session
+	err := scanSession(r.pool.QueryRow(ctx,
+		`SELECT `+sessionColumns+` FROM sessions WHERE "sessionId" = $1`, sessionID,
+	), &s)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		log.Printf("Database error: GetSession(%s) failed: %v", sessionID, err)
+		return nil, fmt.Errorf("failed to fetch session: %w", err)
+	}
+	return &s, nil
+}
+
+// TouchSession marks an active session of the user as seen now. It reports
+// false when the session does not exist, belongs to someone else or was revoked.
+func (r *SessionRepository) TouchSession(ctx context.Context, userID, sessionID string) (bool, error) {
+	tag, err := r.pool.Exec(ctx, `
+		UPDATE sessions SET "lastSeenAt" = NOW()
+		WHERE "sessionId" = $1 AND "userId" = $2 AND "revokedAt" IS NULL`,
+		sessionID, userID,
+	)
+	if err != nil {
+		log.Printf("Database error: TouchSession(%s) failed: %v", sessionID, err)
+		return false, fmt.Errorf("failed to check session: %w", err)
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+// GetActiveSessions lists a user's sessions that have not been revoked, most recently used first
+func (r *SessionRepository) GetActiveSessions(ctx context.Context, userID string) ([]models.Session, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT `+sessionColumns+` FROM sessions
+		WHERE "userId" = $1 AND "revokedAt" IS NULL
+		ORDER BY "lastSeenAt" DESC`,
+		userID,
+	)
+	if err != nil {
+		log.Printf("Database error: GetActiveSessions(%s) failed: %v", userID, err)
+		return nil, fmt.Errorf("failed to fetch sessions: %w", err)
+	}
+	defer rows.Close()
+
+	sessions := []models.Session{}
+	for rows.Next() {
+		var s models.Session
+		if err := scanSession(rows, &s); err != nil {
+			log.Printf("Row scan error in GetActiveSessions: %v", err)
+			return nil, fmt.Errorf("failed to scan session row: %w", err)
+		}
+		sessions = append(sessions, s)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error in GetActiveSessions: %w", err)
+	}
+
+	return sessions, nil
+}
+
+// RevokeSession signs one of the user's sessions out and revokes its refresh
+// tokens. It reports false when the user has no such active session.
+func (r *SessionRepository) RevokeSession(ctx context.Context, userID, sessionID string) (bool, error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	tag, err := tx.Exec(ctx, `
+		UPDATE sessions SET "revokedAt" = NOW()
+		WHERE "sessionId" = $1 AND "userId" = $2 AND "revokedAt" IS NULL`,
+		sessionID, userID,
+	)
+	if err != nil {
+		log.Printf("Database error: RevokeSession(%s) failed: %v", sessionID, err)
+		return false, fmt.Errorf("failed to revoke session: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return false, nil
+	}
+
+	if err := revokeRefreshFamily(ctx, tx, sessionID, time.Now()); err != nil {
+		return false, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return false, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return true, nil
+}
+
+// RevokeUserSessions signs the user out everywhere except the session keepID,
+// which may be empty, and returns the IDs of the sessions that were revoked
+func (r *SessionRepository) RevokeUserSessions(ctx context.Context, userID, keepID string) ([]string, error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	sessionIDs, err := revokeUserSessions(ctx, tx, userID, keepID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return sessionIDs, nil
+}
+
+// revokeUserSessions implements RevokeUserSessions inside tx, so credential
+// changes can sign the user out in the same transaction
+func revokeUserSessions(ctx context.Context, tx pgx.Tx, userID, keepID string) ([]string, error) {
+	rows, err := tx.Query(ctx, `
+		UPDATE sessions SET "revokedAt" = NOW()
+		WHERE "userId" = $1 AND "sessionId" <> $2 AND "revokedAt" IS NULL
+		RETURNING "sessionId"`,
+		userID, keepID,
+	)
+	if err != nil {
+		log.Printf("Database error: RevokeUserSessions(%s) failed: %v", userID, err)
+		return nil, fmt.Errorf("failed to revoke sessions: %w", err)
+	}
+	sessionIDs, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		log.Printf("Row scan error in RevokeUserSessions: %v", err)
+		return nil, fmt.Errorf("failed to revoke sessions: %w", err)
+	}
+
+	if _, err := tx.Exec(ctx,
+		`UPDATE refresh_tokens SET "revokedAt" = NOW() WHERE "userId" = $1 AND "familyId" <> $2 AND "revokedAt" IS NULL`,
+		userID, keepID,
+	); err != nil {
+		log.Printf("Database error: RevokeUserSessions(%s) refresh tokens failed: %v", userID, err)
+		return nil, fmt.Errorf("failed to revoke refresh tokens: %w", err)
+	}
+	return sessionIDs, nil
+}
@@ -0,0 +1,163 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/your-username/golang-ecommerce-app/models"
+)
+
+type StockSubscriptionRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewStockSubscriptionRepository(pool *pgxpool.Pool) *StockSubscriptionRepository {
+	return &StockSubscriptionRepository{pool: pool}
+}
+
+// Subscribe records the user's interest in a product. Subscribing again while a
+// subscription is pending returns the existing one.
+func (r *StockSubscriptionRepository) Subscribe(ctx context.Context, productID int, userID string) (*models.StockSubscription, error) {
+	query := `
+		WITH inserted AS (
+			INSERT INTO stock_subscriptions ("productId", "userId")
+			VALUES ($1, $2)
+			ON CONFLICT ("productId", "userId") WHERE "notifiedAt" IS NULL DO NOTHING
+			RETURNING "subscriptionId", "productId", "userId", "createdAt", "notifiedAt"
+		)
+		SELECT * FROM inserted
+		UNION ALL
+		SELECT "subscriptionId", "productId", "userId", "createdAt", "notifiedAt"
+		FROM stock_subscriptions
+		WHERE "productId" = $1 AND "userId" = $2 AND "notifiedAt" IS NULL
+		LIMIT 1`
+
+	var s models.StockSubscription
+	err := r.pool.QueryRow(ctx, query, productID, userID).Scan(
+		&s.SubscriptionID,
+		&s.ProductID,
+		&s.UserID,
+		&s.CreatedAt,
+		&s.NotifiedAt,
+	)
+	if err != nil {
+		log.Printf("Database error: Subscribe(%d, %s) failed: %v", productID, userID, err)
+		return nil, fmt.Errorf("failed to subscribe to product: %w", err)
+	}
+
+	return &s, nil
+}
+
+// queueBackInStock queues every pending subscription to a product for delivery.
+// It runs in the transaction that restocks the product, so a restock is never
+// saved without its notifications.
+func queueBackInStock(ctx context.Context, db Tx, productID int) (int64, error) {
+	tag, err := db.Exec(ctx, `
+		UPDATE stock_subscriptions
+		SET "queuedAt" = NOW(), "nextAttemptAt" = NOW(), attempts = 0, "lastError" = NULL
+		WHERE "productId" = $1 AND "notifiedAt" IS NULL`, productID,
+	)
+	if err != nil {
+		log.Printf("Database error: queueBackInStock(%d) failed: %v", productID, err)
+		return 0, fmt.Errorf("failed to queue back-in-stock notifications: %w", err)
+	}
+	return tag.RowsAffected(), nil
+}
+
+// ClaimQueuedNotifications leases up to limit queued notifications that are due
+// and have been tried fewer than maxAttempts times. A claimed notification is
+// not handed out again until lease has passed, so one whose sender stops before
+// marking it delivered is retried rather than lost.
+func (r *StockSubscriptionRepository) ClaimQueuedNotifications(ctx context.Context, limit int, lease time.Duration, maxAttempts int) ([]models.BackInStockNotification, error) {
+	query := `
+		WITH claimed AS (
+			UPDATE stock_subscriptions s
+			SET "nextAttemptAt" = NOW() + make_interval(secs => $2), attempts = s.attempts + 1
+			FROM (
+				SELECT "subscriptionId" FROM stock_subscriptions
+				WHERE "queuedAt" IS NOT NULL AND "notifiedAt" IS NULL
+				  AND "nextAttemptAt" <= NOW() AND attempts < $3
+				ORDER BY "nextAttemptAt"
+				LIMIT $1
+				FOR UPDATE SKIP LOCKED
+			) due
+			WHERE s."subscriptionId" = due."subscriptionId"
+			RETURNING s."subscriptionId", s."productId", s."userId", s."createdAt", s.attempts
+		)
+		SELECT c."subscriptionId", c."productId", c."userId", u.email, c."createdAt", c.attempts,
+		       p.name, COALESCE(p.stock, 0)
+		FROM claimed c
+		JOIN users u ON u."userId" = c."userId"
+		JOIN products p ON p."productId" = c."productId"`
+
+	rows, err := r.pool.Query(ctx, query, limit, lease.Seconds(), maxAttempts)
+	if err != nil {
+		log.Printf("Database error: ClaimQueuedNotifications failed: %v", err)
+		return nil, fmt.Errorf("failed to claim notifications: %w", err)
+	}
+	defer rows.Close()
+
+	var claimed []models.BackInStockNotification
+	for rows.Next() {
+		var n models.BackInStockNotification
+		if err := rows.Scan(&n.SubscriptionID, &n.ProductID, &n.UserID, &n.Email, &n.CreatedAt, &n.Attempts, &n.ProductName, &n.Stock); err != nil {
+			log.Printf("Row scan error in ClaimQueuedNotifications: %v", err)
+			return nil, fmt.Errorf("failed to scan notification row: %w", err)
+		}
+		claimed = append(claimed, n)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error in ClaimQueuedNotifications: %w", err)
+	}
+
+	return claimed, nil
+}
+
+// MarkNotified records that a claimed notification was delivered
+func (r *StockSubscriptionRepository) MarkNotified(ctx context.Context, subscriptionID int) error {
+	_, err := r.pool.Exec(ctx,
+		`UPDATE stock_subscriptions SET "notifiedAt" = NOW(), "lastError" = NULL WHERE "subscriptionId" = $1`, subscriptionID,
+	)
+	if err != nil {
+		log.Printf("Database error: MarkNotified(%d) failed: %v", subscriptionID, err)
+		return fmt.Errorf("failed to mark subscription notified: %w", err)
+	}
+	return nil
+}
+
+// RecordNotifyFailure schedules another delivery attempt of a claimed notification
+func (r *StockSubscriptionRepository) RecordNotifyFailure(ctx context.Context, subscriptionID int, reason string, retryAt time.Time) error {
+	_, err := r.pool.Exec(ctx,
+		`UPDATE stock_subscriptions SET "nextAttemptAt" = $2, "lastError" = $3 WHERE "subscriptionId" = $1 AND "notifiedAt" IS NULL`,
+		subscriptionID, retryAt, reason,
+	)
+	if err != nil {
+		log.Printf("Database error: RecordNotifyFailure(%d) failed: %v", subscriptionID, err)
+		return fmt.Errorf("failed to record notification failure: %w", err)
+	}
+	return nil
+}
+
+// Unsubscribe cancels the user's pending subscription. It reports whether one existed.
+func (r *StockSubscriptionRepository) Unsubscribe(ctx context.Context, productID int, userID string) (bool, error) {
+	var id int
+	err := r.pool.QueryRow(ctx, `
+		DELETE FROM stock_subscriptions
+		WHERE "productId" = $1 AND "userId" = $2 AND "notifiedAt" IS NULL
+		RETURNING "subscriptionId"`, productID, userID,
+	).Scan(&id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return false, nil
+		}
+		log.Printf("Database error: Unsubscribe(%d, %s) failed: %v", productID, userID, err)
+		return false, fmt.Errorf("failed to unsubscribe from product: %w", err)
+	}
+	return true, nil
+}
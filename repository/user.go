@@ -11,6 +11,9 @@ import (
 	"github.com/your-username/golang-ecommerce-app/models"
 )
 
+// ErrEmailTaken is returned when another account already has the email address
+var ErrEmailTaken = errors.New("email already in use")
+
 type UserRepository struct {
 	pool *pgxpool.Pool
 }
@@ -19,13 +22,28 @@ func NewUserRepository(pool *pgxpool.Pool) *UserRepository {
 	return &UserRepository{pool: pool}
 }
 
+const userColumns = `"userId", email, password, role, "displayName", phone, "emailVerified", "emailVerifiedAt", "createdAt"`
+
+func scanUser(row pgx.Row, u *models.User) error {
+	return row.Scan(
+		&u.UserId,
+		&u.Email,
+		&u.Password,
+		&u.Role,
+		&u.DisplayName,
+		&u.Phone,
+		&u.EmailVerified,
+		&u.EmailVerifiedAt,
+		&u.CreatedAt,
+	)
+}
+
 // FindByuserId fetches user by ID
 func (r *UserRepository) FindByuserId(ctx context.Context, userId string) (*models.User, error) {
-	query := `SELECT "userId", email, password, role, "createdAt" FROM users WHERE "userId" = $1`
+	query := `SELECT ` + userColumns + ` FROM users WHERE "userId" = $1`
 
 	var user models.User
-	err := r.pool.QueryRow(ctx, query, userId).
-		Scan(&user.UserId, &user.Email, &user.Password, &user.Role, &user.CreatedAt)
+	err := scanUser(r.pool.QueryRow(ctx, query, userId), &user)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, nil
@@ -42,23 +60,16 @@ func (r *UserRepository) CreateUser(ctx context.Context, user models.User) (*mod
 	query := `
 		INSERT INTO users ("userId", email, password, role, "createdAt")
 		VALUES ($1, $2, $3, $4, $5)
-		RETURNING "userId", email, password, role, "createdAt"
-	`
+		RETURNING ` + userColumns
 
 	var newUser models.User
-	err := r.pool.QueryRow(ctx, query,
+	err := scanUser(r.pool.QueryRow(ctx, query,
 		user.UserId,
 		user.Email,
 		user.Password,
 		user.Role,
 		user.CreatedAt,
-	).Scan(
-		&newUser.UserId,
-		&newUser.Email,
-		&newUser.Password,
-		&newUser.Role,
-		&newUser.CreatedAt,
-	)
+	), &newUser)
 
 	if err != nil {
 		log.Printf("Error creating user: %v", err)
@@ -70,11 +81,10 @@ func (r *UserRepository) CreateUser(ctx context.Context, user models.User) (*mod
 
 // FindByEmail fetches user by email
 func (r *UserRepository) FindByEmail(ctx context.Context, email string) (*models.User, error) {
-	query := `SELECT "userId", email, password, role, "createdAt" FROM users WHERE email = $1`
+	query := `SELECT ` + userColumns + ` FROM users WHERE email = $1`
 
 	var user models.User
-	err := r.pool.QueryRow(ctx, query, email).
-		Scan(&user.UserId, &user.Email, &user.Password, &user.Role)
+	err := scanUser(r.pool.QueryRow(ctx, query, email), &user)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, nil
@@ -86,19 +96,24 @@ func (r *UserRepository) FindByEmail(ctx context.Context, email string) (*models
 	return &user, nil
 }
 
-// UpdateUser updates user details
+// UpdateUser updates user details. Changing the email address clears its verification.
 func (r *UserRepository) UpdateUser(ctx context.Context, userId string, updates map[string]interface{}) (*models.User, error) {
 	query := `
 		UPDATE users
-		SET email = COALESCE($1, email),
+		SET email = COALESCE($1::text, email),
 			password = COALESCE($2, password),
+			"emailVerified" = CASE WHEN $1::text IS NOT NULL AND $1::text <> email THEN FALSE ELSE "emailVerified" END,
+			"emailVerifiedAt" = CASE WHEN $1::text IS NOT NULL AND $1::text <> email THEN NULL ELSE "emailVerifiedAt" END,
+			"displayName" = COALESCE($4, "displayName"),
+			phone = COALESCE($5, phone),
 			"updatedAt" = NOW()
 		WHERE "userId" = $3
-		RETURNING "userId", email, password, role, "createdAt"
-	`
+		RETURNING ` + userColumns
 
 	var email *string
 	var password *string
+	var displayName *string
+	var phone *string
 
 	if val, ok := updates["email"]; ok {
 		if s, ok := val.(string); ok {
@@ -112,19 +127,28 @@ func (r *UserRepository) UpdateUser(ctx context.Context, userId string, updates
 		}
 	}
 
+	if val, ok := updates["displayName"]; ok {
+		if s, ok := val.(string); ok {
+			displayName = &s
+		}
+	}
+
+	if val, ok := updates["phone"]; ok {
+		if s, ok := val.(string); ok {
+			phone = &s
+		}
+	}
+
 	var updatedUser models.User
-	err := r.pool.QueryRow(ctx, query, email, password, userId).
-		Scan(
-			&updatedUser.UserId,
-			&updatedUser.Email,
-			&updatedUser.Password,
-			&updatedUser.Role,
-		)
+	err := scanUser(r.pool.QueryRow(ctx, query, email, password, userId, displayName, phone), &updatedUser)
 
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, nil
 		}
+		if isUniqueViolation(err) {
+			return nil, ErrEmailTaken
+		}
 		log.Printf("Error updating user: %v", err)
 		return nil, fmt.Errorf("failed to update user: %w", err)
 	}
@@ -135,20 +159,12 @@ func (r *UserRepository) UpdateUser(ctx context.Context, userId string, updates
 // DeleteUser deletes user by ID
 func (r *UserRepository) DeleteUser(ctx context.Context, userId string) (*models.User, error) {
 	query := `
-		DELETE FROM users 
-		WHERE "userId" = $1 
-		RETURNING "userId", email, password, role, "createdAt"
-	`
+		DELETE FROM users
+		WHERE "userId" = $1
+		RETURNING ` + userColumns
 
 	var deletedUser models.User
-	err := r.pool.QueryRow(ctx, query, userId).
-		Scan(
-			&deletedUser.UserId,
-			&deletedUser.Email,
-			&deletedUser.Password,
-			&deletedUser.Role,
-			&deletedUser.CreatedAt,
-		)
+	err := scanUser(r.pool.QueryRow(ctx, query, userId), &deletedUser)
 
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
@@ -161,24 +177,75 @@ func (r *UserRepository) DeleteUser(ctx context.Context, userId string) (*models
 	return &deletedUser, nil
 }
 
+// ChangePassword sets the user's password and signs out every session except
+// keepSessionID in one transaction. It returns the IDs of the revoked sessions.
+func (r *UserRepository) ChangePassword(ctx context.Context, userId, passwordHash, keepSessionID string) ([]string, error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx,
+		`UPDATE users SET password = $2, "updatedAt" = NOW() WHERE "userId" = $1`,
+		userId, passwordHash,
+	); err != nil {
+		log.Printf("Database error: ChangePassword(%s) failed: %v", userId, err)
+		return nil, fmt.Errorf("failed to change password: %w", err)
+	}
+
+	sessionIDs, err := revokeUserSessions(ctx, tx, userId, keepSessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return sessionIDs, nil
+}
+
+// DeleteUserAndSessions deletes the user and returns the IDs of the sessions
+// that were still active, so they can be dropped from caches. The sessions
+// themselves go with the user.
+func (r *UserRepository) DeleteUserAndSessions(ctx context.Context, userId string) (*models.User, []string, error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	sessionIDs, err := revokeUserSessions(ctx, tx, userId, "")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var deletedUser models.User
+	err = scanUser(tx.QueryRow(ctx, `DELETE FROM users WHERE "userId" = $1 RETURNING `+userColumns, userId), &deletedUser)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil, nil
+		}
+		log.Printf("Error deleting user: %v", err)
+		return nil, nil, fmt.Errorf("failed to delete user: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return &deletedUser, sessionIDs, nil
+}
+
 // UpdateUserRole updates the role of a user
 func (r *UserRepository) UpdateUserRole(ctx context.Context, userId, role string) (*models.User, error) {
 	query := `
 		UPDATE users
 		SET role = $1, "createdAt" = NOW()
 		WHERE "userId" = $2
-		RETURNING "userId", email, password, role, "createdAt"
-	`
+		RETURNING ` + userColumns
 
 	var updatedUser models.User
-	err := r.pool.QueryRow(ctx, query, role, userId).
-		Scan(
-			&updatedUser.UserId,
-			&updatedUser.Email,
-			&updatedUser.Password,
-			&updatedUser.Role,
-			&updatedUser.CreatedAt,
-		)
+	err := scanUser(r.pool.QueryRow(ctx, query, role, userId), &updatedUser)
 
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
@@ -189,4 +256,20 @@ func (r *UserRepository) UpdateUserRole(ctx context.Context, userId, role string
 	}
 
 	return &updatedUser, nil
-}
\ No newline at end of file
+}
+
+// MarkEmailVerified records that the user confirmed email. It reports false
+// when the user no longer has that address.
+func (r *UserRepository) MarkEmailVerified(ctx context.Context, userId, email string) (bool, error) {
+	tag, err := r.pool.Exec(ctx, `
+		UPDATE users
+		SET "emailVerified" = TRUE, "emailVerifiedAt" = COALESCE("emailVerifiedAt", NOW()), "updatedAt" = NOW()
+		WHERE "userId" = $1 AND email = $2`,
+		userId, email,
+	)
+	if err != nil {
+		log.Printf("Database error: MarkEmailVerified(%s) failed: %v", userId, err)
+		return false, fmt.Errorf("failed to verify email: %w", err)
+	}
+	return tag.RowsAffected() > 0, nil
+}
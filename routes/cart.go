@@ -12,7 +12,8 @@ import (
 func RegisterCartRoutes(r *mux.Router, pool *pgxpool.Pool) {
 	// Initialize dependencies
 	cartRepo := repository.NewCartRepository(pool)
-	cartService := services.NewCartService(cartRepo)
+	productRepo := repository.NewProductRepository(pool)
+	cartService := services.NewCartService(cartRepo, productRepo)
 	cartController := controllers.NewCartController(cartService)
 
 	cartRouter := r.PathPrefix("/cart").Subrouter()
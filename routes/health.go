@@ -0,0 +1,43 @@
+package routes
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/your-username/golang-ecommerce-app/config"
+	"github.com/your-username/golang-ecommerce-app/utils"
+)
+
+// RegisterHealthRoutes exposes /health. The service is "ok" when the database
+// answers and the cache circuit is closed, "degraded" when it runs without the
+// cache, and "down" (503) when the database is unreachable.
+func RegisterHealthRoutes(r *mux.Router, pool *pgxpool.Pool) {
+	r.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(r.Context(), 2*time.Second)
+		defer cancel()
+
+		status, code := "ok", http.StatusOK
+		database := "ok"
+		if err := pool.Ping(ctx); err != nil {
+			database = "down"
+			status, code = "down", http.StatusServiceUnavailable
+		}
+
+		cache := config.CacheStatus()
+		if cache.State != "closed" && status == "ok" {
+			status = "degraded"
+		}
+
+		utils.RespondWithJSON(w, code, map[string]interface{}{
+			"status":   status,
+			"database": database,
+			"cache": map[string]interface{}{
+				"driver": config.CacheDriver,
+				"status": cache,
+			},
+		})
+	}).Methods("GET")
+}
@@ -0,0 +1,39 @@
+package routes
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/your-username/golang-ecommerce-app/config"
+	"github.com/your-username/golang-ecommerce-app/repository"
+	"github.com/your-username/golang-ecommerce-app/services"
+)
+
+// StartBackgroundJobs launches the periodic workers. They stop when ctx is cancelled.
+func StartBackgroundJobs(ctx context.Context, pool *pgxpool.Pool) {
+	productCache := sharedProductCache()
+	cache := productCache.Cache()
+
+	productRepo := repository.NewProductRepository(pool)
+	imageRepo := repository.NewProductImageRepository(pool)
+	priceRepo := repository.NewProductPriceRepository(pool)
+	productService := services.NewProductService(productRepo, imageRepo, config.BlobStore, cache, productCache)
+
+	priceService := services.NewProductPriceService(productRepo, priceRepo, cache)
+
+	scheduler := services.NewProductScheduler(productService, priceService, config.EnvDuration("PRODUCT_SCHEDULER_INTERVAL", time.Minute))
+	go scheduler.Run(ctx)
+
+	relatedService := services.NewRelatedProductService(repository.NewRelatedProductRepository(pool), productRepo, cache, productCache)
+	relatedJob := services.NewRelatedProductsJob(
+		relatedService,
+		config.EnvDuration("RELATED_PRODUCTS_INTERVAL", time.Hour),
+		config.EnvInt("RELATED_PRODUCTS_TOP_N", 10),
+	)
+	go relatedJob.Run(ctx)
+
+	stockService := services.NewStockService(productRepo, repository.NewStockSubscriptionRepository(pool), config.Notifier, cache)
+	backInStockJob := services.NewBackInStockJob(stockService, config.EnvDuration("BACK_IN_STOCK_INTERVAL", time.Minute))
+	go backInStockJob.Run(ctx)
+}
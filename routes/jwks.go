@@ -0,0 +1,18 @@
+package routes
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/your-username/golang-ecommerce-app/config"
+	"github.com/your-username/golang-ecommerce-app/utils"
+)
+
+// RegisterJWKSRoutes publishes the public token verification keys so other
+// services can check access tokens without sharing a secret
+func RegisterJWKSRoutes(r *mux.Router) {
+	r.HandleFunc("/.well-known/jwks.json", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "public, max-age=300")
+		utils.RespondWithJSON(w, http.StatusOK, config.KeyRing.JWKS())
+	}).Methods("GET")
+}
@@ -3,8 +3,10 @@ package routes
 import (
 	"github.com/gorilla/mux"
 	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/your-username/golang-ecommerce-app/config"
 	"github.com/your-username/golang-ecommerce-app/controllers"
 	"github.com/your-username/golang-ecommerce-app/middlewares"
+	"github.com/your-username/golang-ecommerce-app/models"
 	"github.com/your-username/golang-ecommerce-app/repository"
 	"github.com/your-username/golang-ecommerce-app/services"
 )
@@ -14,7 +16,15 @@ func RegisterOrderRoutes(r *mux.Router, pool *pgxpool.Pool) {
 	cartRepo := repository.NewCartRepository(pool)
 	paymentRepo := repository.NewPaymentRepository(pool)
 	productRepo := repository.NewProductRepository(pool)
-	orderService := services.NewOrderService(orderRepo, cartRepo, paymentRepo, productRepo)
+	userRepo := repository.NewUserRepository(pool)
+	orderService := services.NewOrderService(
+		orderRepo,
+		cartRepo,
+		paymentRepo,
+		productRepo,
+		userRepo,
+		config.EnvBool("REQUIRE_VERIFIED_EMAIL_FOR_CHECKOUT", false),
+	)
 	orderController := controllers.NewOrderController(orderService)
 
 	orderRouter := r.PathPrefix("/orders").Subrouter()
@@ -24,7 +34,6 @@ func RegisterOrderRoutes(r *mux.Router, pool *pgxpool.Pool) {
 	orderRouter.HandleFunc("/", orderController.GetUserOrders).Methods("GET")
 
 	adminOrderRouter := r.PathPrefix("/admin/orders").Subrouter()
-	adminOrderRouter.Use(middlewares.AuthenticateAdminToken)
-	adminOrderRouter.HandleFunc("/update/{id}", orderController.UpdateUserOrder).Methods("PUT")
+	adminOrderRouter.Handle("/update/{id}", can(models.PermOrdersUpdate, orderController.UpdateUserOrder)).Methods("PUT")
 
 }
\ No newline at end of file
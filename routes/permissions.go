@@ -0,0 +1,12 @@
+package routes
+
+import (
+	"net/http"
+
+	"github.com/your-username/golang-ecommerce-app/middlewares"
+)
+
+// can wraps h so only callers whose roles grant permission reach it
+func can(permission string, h http.HandlerFunc) http.Handler {
+	return middlewares.RequirePermission(permission)(h)
+}
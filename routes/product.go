@@ -1,33 +1,107 @@
 package routes
 
 import (
+	"net/http"
+	"sync"
+	"time"
+
 	"github.com/gorilla/mux"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/your-username/golang-ecommerce-app/config"
 	"github.com/your-username/golang-ecommerce-app/controllers"
 	"github.com/your-username/golang-ecommerce-app/middlewares"
+	"github.com/your-username/golang-ecommerce-app/models"
 	"github.com/your-username/golang-ecommerce-app/repository"
 	"github.com/your-username/golang-ecommerce-app/services"
 	"github.com/your-username/golang-ecommerce-app/utils"
 )
 
 func RegisterProductRoutes(r *mux.Router, pool *pgxpool.Pool) {
-	// Initialize Redis cache
-	cache := utils.NewRedisCache(config.RedisClient)
-	
+	productCache := sharedProductCache()
+	cache := productCache.Cache()
+
 	productRepo := repository.NewProductRepository(pool)
-	productService := services.NewProductService(productRepo, cache)
-	productController := controllers.NewProductController(productService)
+	imageRepo := repository.NewProductImageRepository(pool)
+	priceRepo := repository.NewProductPriceRepository(pool)
+	productService := services.NewProductService(productRepo, imageRepo, config.BlobStore, cache, productCache)
+	recentlyViewedService := newRecentlyViewedService(pool)
+	productController := controllers.NewProductController(productService, recentlyViewedService)
+	imageService := services.NewProductImageService(
+		productRepo,
+		imageRepo,
+		config.BlobStore,
+		cache,
+		config.ThumbnailWidths(),
+		int64(config.EnvInt("PRODUCT_IMAGE_MAX_PIXELS", 40_000_000)),
+	)
+	imageController := controllers.NewProductImageController(imageService)
+	priceService := services.NewProductPriceService(productRepo, priceRepo, cache)
+	priceController := controllers.NewProductPriceController(priceService)
+	relatedService := services.NewRelatedProductService(repository.NewRelatedProductRepository(pool), productRepo, cache, productCache)
+	relatedController := controllers.NewRelatedProductController(relatedService)
+	stockService := services.NewStockService(productRepo, repository.NewStockSubscriptionRepository(pool), config.Notifier, cache)
+	stockController := controllers.NewStockController(stockService)
 
 	productRouter := r.PathPrefix("/products").Subrouter()
 
 	productRouter.HandleFunc("/", productController.GetAllProducts).Methods("GET")
-	productRouter.HandleFunc("/{id}", productController.GetProductById).Methods("GET")
+	productRouter.Handle("/{id}", middlewares.OptionalAuthenticateToken(http.HandlerFunc(productController.GetProductById))).Methods("GET")
+	productRouter.HandleFunc("/{id}/images", imageController.GetProductImages).Methods("GET")
+	productRouter.HandleFunc("/{id}/related", relatedController.GetRelatedProducts).Methods("GET")
+	productRouter.Handle("/{id}/notify-me", middlewares.AuthenticateToken(http.HandlerFunc(stockController.Subscribe))).Methods("POST")
+	productRouter.Handle("/{id}/notify-me", middlewares.AuthenticateToken(http.HandlerFunc(stockController.Unsubscribe))).Methods("DELETE")
 
 	productAdminRouter := r.PathPrefix("/admin/products").Subrouter()
-	productAdminRouter.Use(middlewares.AuthenticateAdminToken) 
 
-	productAdminRouter.HandleFunc("/create", productController.CreateProduct).Methods("POST")
-	productAdminRouter.HandleFunc("/update/{id}", productController.UpdateProduct).Methods("PUT")
-	productAdminRouter.HandleFunc("/delete/{id}", productController.DeleteProduct).Methods("DELETE")
-}
\ No newline at end of file
+	productAdminRouter.Handle("/", can(models.PermProductsRead, productController.GetAdminProducts)).Methods("GET")
+	productAdminRouter.Handle("/create", can(models.PermProductsWrite, productController.CreateProduct)).Methods("POST")
+	productAdminRouter.Handle("/update/{id}", can(models.PermProductsWrite, productController.UpdateProduct)).Methods("PUT")
+	productAdminRouter.Handle("/delete/{id}", can(models.PermProductsWrite, productController.DeleteProduct)).Methods("DELETE")
+	productAdminRouter.Handle("/import", can(models.PermProductsWrite, productController.ImportProducts)).Methods("POST")
+	productAdminRouter.Handle("/export", can(models.PermProductsRead, productController.ExportProducts)).Methods("GET")
+	productAdminRouter.Handle("/deleted", can(models.PermProductsRead, productController.GetDeletedProducts)).Methods("GET")
+	productAdminRouter.Handle("/cache/stats", can(models.PermProductsRead, productController.GetCacheStats)).Methods("GET")
+	productAdminRouter.Handle("/{id}/restore", can(models.PermProductsWrite, productController.RestoreProduct)).Methods("POST")
+	productAdminRouter.Handle("/{id}/status", can(models.PermProductsWrite, productController.SetProductStatus)).Methods("PUT")
+	productAdminRouter.Handle("/{id}/stock", can(models.PermStockWrite, stockController.UpdateStock)).Methods("PUT")
+	productAdminRouter.Handle("/{id}", can(models.PermProductsRead, productController.GetAdminProductById)).Methods("GET")
+	productAdminRouter.Handle("/{id}", can(models.PermProductsWrite, productController.PatchProduct)).Methods("PATCH")
+
+	productAdminRouter.Handle("/{id}/images", can(models.PermProductsWrite, imageController.UploadProductImage)).Methods("POST")
+	productAdminRouter.Handle("/{id}/images/order", can(models.PermProductsWrite, imageController.ReorderImages)).Methods("PUT")
+	productAdminRouter.Handle("/{id}/images/{imageId}/primary", can(models.PermProductsWrite, imageController.SetPrimaryImage)).Methods("PUT")
+	productAdminRouter.Handle("/{id}/images/{imageId}", can(models.PermProductsWrite, imageController.DeleteImage)).Methods("DELETE")
+
+	productAdminRouter.Handle("/{id}/price-history", can(models.PermProductsRead, priceController.GetPriceHistory)).Methods("GET")
+	productAdminRouter.Handle("/{id}/prices", can(models.PermProductsWrite, priceController.SchedulePrice)).Methods("POST")
+	productAdminRouter.Handle("/{id}/prices/{priceId}", can(models.PermProductsWrite, priceController.CancelPrice)).Methods("DELETE")
+
+	productSuperAdminRouter := r.PathPrefix("/superadmin/products").Subrouter()
+
+	productSuperAdminRouter.Handle("/{id}/purge", can(models.PermProductsPurge, productController.PurgeProduct)).Methods("DELETE")
+}
+
+// sharedProductCache configures stampede protection for cached product reads.
+// Entries are fresh for PRODUCT_CACHE_TTL (plus up to 10% jitter) and may be
+// served for PRODUCT_CACHE_STALE_TTL longer while they are refreshed. The
+// routes and background jobs share one loader so that invalidations made by
+// either stop refreshes started by the other from writing stale data back.
+var sharedProductCache = sync.OnceValue(func() *utils.CacheLoader {
+	return utils.NewCacheLoader(
+		config.Cache,
+		config.EnvDuration("PRODUCT_CACHE_TTL", time.Hour),
+		config.EnvDuration("PRODUCT_CACHE_STALE_TTL", 5*time.Minute),
+		0.1,
+	)
+})
+
+// newRecentlyViewedService builds the service behind both recording views on
+// product pages and listing them under /users/me
+func newRecentlyViewedService(pool *pgxpool.Pool) *services.RecentlyViewedService {
+	return services.NewRecentlyViewedService(
+		repository.NewRecentlyViewedRepository(pool),
+		repository.NewProductRepository(pool),
+		config.Cache,
+		config.EnvInt("RECENTLY_VIEWED_LIMIT", 20),
+	)
+}
@@ -0,0 +1,31 @@
+package routes
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/your-username/golang-ecommerce-app/controllers"
+	"github.com/your-username/golang-ecommerce-app/middlewares"
+	"github.com/your-username/golang-ecommerce-app/models"
+	"github.com/your-username/golang-ecommerce-app/repository"
+	"github.com/your-username/golang-ecommerce-app/services"
+)
+
+func RegisterReviewRoutes(r *mux.Router, pool *pgxpool.Pool) {
+	cache := sharedProductCache().Cache()
+
+	reviewRepo := repository.NewReviewRepository(pool)
+	productRepo := repository.NewProductRepository(pool)
+	reviewService := services.NewReviewService(reviewRepo, productRepo, cache)
+	reviewController := controllers.NewReviewController(reviewService)
+
+	// Reading reviews is public; writing one needs a signed-in customer
+	r.HandleFunc("/products/{id}/reviews", reviewController.GetProductReviews).Methods("GET")
+	r.Handle("/products/{id}/reviews", middlewares.AuthenticateToken(http.HandlerFunc(reviewController.CreateReview))).Methods("POST")
+
+	reviewAdminRouter := r.PathPrefix("/admin/reviews").Subrouter()
+
+	reviewAdminRouter.Handle("/", can(models.PermReviewsModerate, reviewController.GetReviewsForModeration)).Methods("GET")
+	reviewAdminRouter.Handle("/{reviewId}/status", can(models.PermReviewsModerate, reviewController.ModerateReview)).Methods("PUT")
+}
@@ -1,35 +1,152 @@
 package routes
 
 import (
+	"net/http"
+	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/your-username/golang-ecommerce-app/config"
 	"github.com/your-username/golang-ecommerce-app/controllers"
 	"github.com/your-username/golang-ecommerce-app/repository"
 	"github.com/your-username/golang-ecommerce-app/services"
 	"github.com/your-username/golang-ecommerce-app/middlewares"
+	"github.com/your-username/golang-ecommerce-app/models"
+	"github.com/your-username/golang-ecommerce-app/utils"
 )
 func RegisterUserRoutes(r *mux.Router, pool *pgxpool.Pool) {
 	userRepo := repository.NewUserRepository(pool)
-	userService := services.NewUserService(userRepo)
-	controllers := controllers.NewUserController(userService)
+	refreshRepo := repository.NewRefreshTokenRepository(pool)
+	sessionService := services.NewSessionService(repository.NewSessionRepository(pool), refreshRepo, config.Cache)
+	middlewares.UseSessionValidator(sessionService.ValidateSession)
+	sessionController := controllers.NewSessionController(sessionService)
+
+	roleService := services.NewRoleService(
+		repository.NewRoleRepository(pool),
+		config.EnvDuration("ROLE_PERMISSIONS_REFRESH", 30*time.Second),
+	)
+	middlewares.UsePermissionResolver(roleService.PermissionsFor)
+	roleController := controllers.NewRoleController(roleService)
+
+	verificationService := services.NewEmailVerificationService(
+		userRepo,
+		repository.NewActionTokenRepository(pool),
+		config.Mailer,
+		config.Cache,
+		config.FrontendURL,
+		config.EnvDuration("EMAIL_VERIFICATION_TTL", 24*time.Hour),
+	)
+
+	loginThrottle := services.NewLoginThrottle(
+		repository.NewLoginFailureRepository(pool),
+		services.LoginThrottlePolicy{
+			FreeAttempts: 3,
+			BaseDelay:    time.Second,
+			MaxDelay:     5 * time.Minute,
+			LockAfter:    config.EnvInt("LOGIN_LOCKOUT_THRESHOLD", 10),
+			LockDuration: config.EnvDuration("LOGIN_LOCKOUT_DURATION", 30*time.Minute),
+			ResetAfter:   time.Hour,
+		},
+		// Addresses are shared behind NATs and proxies, so they get more room
+		services.LoginThrottlePolicy{
+			FreeAttempts: 20,
+			BaseDelay:    time.Second,
+			MaxDelay:     15 * time.Minute,
+			LockAfter:    config.EnvInt("LOGIN_IP_LOCKOUT_THRESHOLD", 100),
+			LockDuration: config.EnvDuration("LOGIN_IP_LOCKOUT_DURATION", time.Hour),
+			ResetAfter:   2 * time.Hour,
+		},
+	)
+
+	mfaService := services.NewMFAService(
+		repository.NewMFARepository(pool),
+		userRepo,
+		repository.NewActionTokenRepository(pool),
+		roleService,
+		loginThrottle,
+		config.Cache,
+		config.EnvString("MFA_ISSUER", "E-commerce App"),
+	)
+	middlewares.UseMFAPolicy(mfaService.RequiredForRole)
+	mfaController := controllers.NewMFAController(mfaService)
+
+	userService := services.NewUserService(
+		userRepo,
+		refreshRepo,
+		sessionService,
+		verificationService,
+		mfaService,
+		loginThrottle,
+		roleService,
+		config.EnvDuration("ACCESS_TOKEN_TTL", 15*time.Minute),
+		config.EnvDuration("REFRESH_TOKEN_TTL", 30*24*time.Hour),
+	)
+	passwordResetService := services.NewPasswordResetService(
+		userRepo,
+		repository.NewPasswordResetRepository(pool),
+		sessionService,
+		config.Mailer,
+		utils.NewRateLimiter(
+			config.Cache,
+			config.EnvInt("PASSWORD_RESET_RATE_LIMIT", 3),
+			config.EnvDuration("PASSWORD_RESET_RATE_WINDOW", time.Hour),
+		),
+		config.FrontendURL,
+		config.EnvDuration("PASSWORD_RESET_TTL", 30*time.Minute),
+	)
+	passwordController := controllers.NewPasswordController(passwordResetService)
+	profileController := controllers.NewProfileController(
+		services.NewProfileService(userRepo, sessionService, verificationService),
+	)
+	recentlyViewedController := controllers.NewRecentlyViewedController(newRecentlyViewedService(pool))
+	controllers := controllers.NewUserController(userService, verificationService)
 
 	// Public routes
 	userRouter := r.PathPrefix("/users").Subrouter()
 	userRouter.HandleFunc("/signup", controllers.SignupUser).Methods("POST")
 	userRouter.HandleFunc("/login", controllers.LoginUser).Methods("POST")
+	userRouter.HandleFunc("/login/mfa", controllers.LoginMFA).Methods("POST")
+	userRouter.HandleFunc("/token/refresh", controllers.RefreshToken).Methods("POST")
+	userRouter.HandleFunc("/logout", controllers.Logout).Methods("POST")
+	userRouter.HandleFunc("/verify-email", controllers.VerifyEmail).Methods("POST")
+	userRouter.HandleFunc("/password/forgot", passwordController.ForgotPassword).Methods("POST")
+	userRouter.HandleFunc("/password/reset", passwordController.ResetPassword).Methods("POST")
+	userRouter.Handle("/verify-email/resend", middlewares.AuthenticateToken(http.HandlerFunc(controllers.ResendVerificationEmail))).Methods("POST")
+
+	meRouter := r.PathPrefix("/users/me").Subrouter()
+	meRouter.Use(middlewares.AuthenticateToken)
 
-	// Admin subrouter with middleware
+	meRouter.HandleFunc("", profileController.GetProfile).Methods("GET")
+	meRouter.HandleFunc("", profileController.UpdateProfile).Methods("PATCH")
+	meRouter.HandleFunc("", profileController.CloseAccount).Methods("DELETE")
+	meRouter.HandleFunc("/password", profileController.ChangePassword).Methods("POST")
+	meRouter.HandleFunc("/mfa", mfaController.GetStatus).Methods("GET")
+	meRouter.HandleFunc("/mfa/totp", mfaController.StartEnrollment).Methods("POST")
+	meRouter.HandleFunc("/mfa/totp/confirm", mfaController.ConfirmEnrollment).Methods("POST")
+	meRouter.HandleFunc("/mfa/totp", mfaController.Disable).Methods("DELETE")
+	meRouter.HandleFunc("/mfa/recovery-codes", mfaController.RegenerateRecoveryCodes).Methods("POST")
+	meRouter.HandleFunc("/sessions", sessionController.GetMySessions).Methods("GET")
+	meRouter.HandleFunc("/sessions/{id}", sessionController.RevokeMySession).Methods("DELETE")
+	meRouter.HandleFunc("/recently-viewed", recentlyViewedController.GetRecentlyViewed).Methods("GET")
+
+	// Admin routes, each guarded by the permission it needs
 	adminRouter := r.PathPrefix("/admin").Subrouter()
-	adminRouter.Use(middlewares.AuthenticateAdminToken)
 
-	adminRouter.HandleFunc("/user/{userId}", controllers.UpdateUser).Methods("PUT")
-	adminRouter.HandleFunc("/user/{userId}", controllers.DeleteUser).Methods("DELETE")
+	adminRouter.Handle("/user/{userId}", can(models.PermUsersWrite, controllers.UpdateUser)).Methods("PUT")
+	adminRouter.Handle("/user/{userId}", can(models.PermUsersWrite, controllers.DeleteUser)).Methods("DELETE")
+	adminRouter.Handle("/user/{userId}/sessions", can(models.PermUsersWrite, controllers.RevokeUserSessions)).Methods("DELETE")
+	adminRouter.Handle("/user/{userId}/lockout", can(models.PermUsersWrite, controllers.UnlockUser)).Methods("DELETE")
 
-	// SuperAdmin subrouter with middleware
+	// SuperAdmin routes; the permissions they need are granted to superadmins by default
 	superAdminRouter := r.PathPrefix("/superadmin").Subrouter()
-	superAdminRouter.Use(middlewares.AuthenticateSuperAdminToken)
 
-	superAdminRouter.HandleFunc("/user/role/{userId}", controllers.UpdateUserRole).Methods("PUT")
-	superAdminRouter.HandleFunc("/user/{userId}", controllers.DeleteUserAllAccess).Methods("DELETE")
+	superAdminRouter.Handle("/user/role/{userId}", can(models.PermUsersAssignRole, controllers.UpdateUserRole)).Methods("PUT")
+	superAdminRouter.Handle("/mfa/policy", can(models.PermSecurityManage, mfaController.GetPolicy)).Methods("GET")
+	superAdminRouter.Handle("/mfa/policy", can(models.PermSecurityManage, mfaController.SetPolicy)).Methods("PUT")
+	superAdminRouter.Handle("/user/{userId}", can(models.PermUsersDelete, controllers.DeleteUserAllAccess)).Methods("DELETE")
+
+	superAdminRouter.Handle("/roles", can(models.PermRolesManage, roleController.GetRoles)).Methods("GET")
+	superAdminRouter.Handle("/roles/permissions", can(models.PermRolesManage, roleController.GetPermissions)).Methods("GET")
+	superAdminRouter.Handle("/roles/{name}", can(models.PermRolesManage, roleController.SaveRole)).Methods("PUT")
+	superAdminRouter.Handle("/roles/{name}", can(models.PermRolesManage, roleController.DeleteRole)).Methods("DELETE")
 }
@@ -4,18 +4,63 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"log"
+	"net/http"
 
 	"github.com/your-username/golang-ecommerce-app/models"
 	"github.com/your-username/golang-ecommerce-app/repository"
 )
 
 type CartService struct {
-	cartRepo *repository.CartRepository
+	cartRepo    *repository.CartRepository
+	productRepo *repository.ProductRepository
 }
 
-func NewCartService(cartRepo *repository.CartRepository) *CartService {
-	return &CartService{cartRepo: cartRepo}
+func NewCartService(cartRepo *repository.CartRepository, productRepo *repository.ProductRepository) *CartService {
+	return &CartService{
+		cartRepo:    cartRepo,
+		productRepo: productRepo,
+	}
+}
+
+// UnavailableItemsError is returned when cart items refer to products that have been removed
+type UnavailableItemsError struct {
+	Items []models.CartProduct
+}
+
+func (e *UnavailableItemsError) Error() string {
+	return fmt.Sprintf("%d item(s) in the cart are no longer available", len(e.Items))
+}
+
+// findUnavailableItems returns the cart items whose product no longer exists or was deleted
+func findUnavailableItems(ctx context.Context, productRepo *repository.ProductRepository, items []models.CartProduct) ([]models.CartProduct, error) {
+	if len(items) == 0 {
+		return nil, nil
+	}
+
+	ids := make([]int, len(items))
+	for i, item := range items {
+		ids[i] = item.ProductID
+	}
+
+	missingIDs, err := productRepo.FindUnavailableProductIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	missing := make(map[int]bool, len(missingIDs))
+	for _, id := range missingIDs {
+		missing[id] = true
+	}
+
+	var unavailable []models.CartProduct
+	for _, item := range items {
+		if missing[item.ProductID] {
+			unavailable = append(unavailable, item)
+		}
+	}
+	return unavailable, nil
 }
 
 func (s *CartService) AddToCartService(ctx context.Context, userID string, newProductInfo json.RawMessage) (*repository.Cart, error) {
@@ -36,6 +81,14 @@ func (s *CartService) AddToCartService(ctx context.Context, userID string, newPr
 		return nil, errors.New("invalid product ID, quantity, or price")
 	}
 
+	product, err := s.productRepo.GetProductByID(ctx, newProduct.ProductID)
+	if err != nil {
+		return nil, errors.New("failed to check product")
+	}
+	if product == nil {
+		return nil, &ServiceError{Status: http.StatusNotFound, Message: "Product is not available"}
+	}
+
 	existingCart, err := s.cartRepo.GetCartByUserID(ctx, userID)
 	if err != nil {
 		log.Printf("Error fetching cart for user %s: %v", userID, err)
@@ -157,8 +210,26 @@ func (s *CartService) RemoveFromCartService(ctx context.Context, userID string,
     return s.cartRepo.UpdateCartProducts(ctx, userID, updatedJson)
 }
 
+// GetCartService returns the user's cart, flagging items whose product was removed
 func (s *CartService) GetCartService(ctx context.Context, userID string) (*repository.Cart, error) {
-	return s.cartRepo.GetCartByUserID(ctx, userID)
+	cart, err := s.cartRepo.GetCartByUserID(ctx, userID)
+	if err != nil || cart == nil {
+		return cart, err
+	}
+
+	var products []models.CartProduct
+	if err := json.Unmarshal(cart.ProductInfo, &products); err != nil {
+		log.Printf("Failed to parse cart product info for user %s: %v", userID, err)
+		return cart, nil
+	}
+
+	cart.UnavailableItems, err = findUnavailableItems(ctx, s.productRepo, products)
+	if err != nil {
+		log.Printf("Error checking cart availability for user %s: %v", userID, err)
+		return nil, errors.New("failed to check cart availability")
+	}
+
+	return cart, nil
 }
 
 func (s *CartService) ClearUserCart(ctx context.Context, userID string) error {
@@ -0,0 +1,139 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/your-username/golang-ecommerce-app/models"
+	"github.com/your-username/golang-ecommerce-app/repository"
+	"github.com/your-username/golang-ecommerce-app/utils"
+)
+
+// verificationResendInterval is how long a user waits between verification emails
+const verificationResendInterval = time.Minute
+
+type EmailVerificationService struct {
+	userRepo    *repository.UserRepository
+	actionRepo  *repository.ActionTokenRepository
+	mailer      utils.Mailer
+	cache       utils.CacheProvider
+	frontendURL string
+	ttl         time.Duration
+}
+
+func NewEmailVerificationService(
+	userRepo *repository.UserRepository,
+	actionRepo *repository.ActionTokenRepository,
+	mailer utils.Mailer,
+	cache utils.CacheProvider,
+	frontendURL string,
+	ttl time.Duration,
+) *EmailVerificationService {
+	return &EmailVerificationService{
+		userRepo:    userRepo,
+		actionRepo:  actionRepo,
+		mailer:      mailer,
+		cache:       cache,
+		frontendURL: frontendURL,
+		ttl:         ttl,
+	}
+}
+
+// SendVerification emails the user a link that confirms their current address
+func (s *EmailVerificationService) SendVerification(ctx context.Context, user *models.User) error {
+	token, err := utils.GenerateActionToken(utils.ActionVerifyEmail, user.UserId, user.Email, s.ttl)
+	if err != nil {
+		return fmt.Errorf("failed to create verification token: %w", err)
+	}
+
+	link := s.frontendURL + "/verify-email?token=" + url.QueryEscape(token)
+	err = s.mailer.Send(ctx, utils.Email{
+		To:      user.Email,
+		Subject: "Confirm your email address",
+		Body: fmt.Sprintf("Hi %s,\n\nPlease confirm your email address by opening the link below. "+
+			"It expires on %s.\n\n%s\n\nIf you did not create an account, you can ignore this email.\n",
+			user.UserId, time.Now().Add(s.ttl).UTC().Format(time.RFC1123), link),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to send verification email: %w", err)
+	}
+	return nil
+}
+
+// SendEmailChangedNotice tells the previous address that the account's email
+// was changed, so the owner learns of it even if someone else made the change
+func (s *EmailVerificationService) SendEmailChangedNotice(ctx context.Context, user *models.User, previousEmail string) error {
+	err := s.mailer.Send(ctx, utils.Email{
+		To:      previousEmail,
+		Subject: "Your email address was changed",
+		Body: fmt.Sprintf("Hi %s,\n\nThe email address on your account was changed to %s on %s. "+
+			"Emails about your account will go there from now on.\n\nIf you did not make this change, "+
+			"reset your password right away and contact support.\n",
+			user.UserId, user.Email, time.Now().UTC().Format(time.RFC1123)),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to send email change notice: %w", err)
+	}
+	return nil
+}
+
+// ResendVerification sends a new verification link, at most once per
+// verificationResendInterval
+func (s *EmailVerificationService) ResendVerification(ctx context.Context, userID string) error {
+	user, err := s.userRepo.FindByuserId(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		return &ServiceError{Status: http.StatusNotFound, Message: "User not found"}
+	}
+	if user.EmailVerified {
+		return &ServiceError{Status: http.StatusConflict, Message: "Email is already verified"}
+	}
+
+	key := "email-verification:sent:" + userID
+	if _, err := s.cache.Get(ctx, key); err == nil {
+		return &ServiceError{Status: http.StatusTooManyRequests, Message: "A verification email was sent recently; please wait a minute"}
+	}
+
+	if err := s.SendVerification(ctx, user); err != nil {
+		return err
+	}
+
+	if err := s.cache.Set(ctx, key, "1", verificationResendInterval); err != nil && !errors.Is(err, utils.ErrCacheUnavailable) {
+		log.Printf("Failed to record verification email for %s: %v", userID, err)
+	}
+	return nil
+}
+
+// VerifyEmail confirms the address named in a verification token. Each token
+// works once, and only while the account still has that address.
+func (s *EmailVerificationService) VerifyEmail(ctx context.Context, token string) error {
+	claims, err := utils.VerifyActionToken(token, utils.ActionVerifyEmail)
+	if err != nil {
+		return &ServiceError{Status: http.StatusBadRequest, Message: "Invalid or expired verification link"}
+	}
+
+	fresh, err := s.actionRepo.ConsumeActionToken(ctx, claims.ID, claims.Purpose, claims.ExpiresAt.Time)
+	if err != nil {
+		return err
+	}
+	if !fresh {
+		return &ServiceError{Status: http.StatusBadRequest, Message: "This verification link has already been used"}
+	}
+
+	verified, err := s.userRepo.MarkEmailVerified(ctx, claims.Subject, claims.Email)
+	if err != nil {
+		return err
+	}
+	if !verified {
+		return &ServiceError{Status: http.StatusBadRequest, Message: "This verification link is for an address no longer on the account"}
+	}
+
+	return nil
+}
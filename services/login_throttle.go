@@ -0,0 +1,203 @@
+package services
+
+import (
+	"context"
+	"log"
+	"math"
+	"net/http"
+	"time"
+
+	"github.com/your-username/golang-ecommerce-app/models"
+	"github.com/your-username/golang-ecommerce-app/repository"
+	"github.com/your-username/golang-ecommerce-app/utils"
+)
+
+// LoginThrottlePolicy decides how long a subject must wait after failed logins.
+// The first FreeAttempts failures cost nothing; each one after that doubles the
+// wait from BaseDelay up to MaxDelay, and LockAfter failures lock the subject
+// out for LockDuration. Counts start over ResetAfter the last failure.
+type LoginThrottlePolicy struct {
+	FreeAttempts int
+	BaseDelay    time.Duration
+	MaxDelay     time.Duration
+	LockAfter    int
+	LockDuration time.Duration
+	ResetAfter   time.Duration
+}
+
+// blockedUntil returns when the subject may try again
+func (p LoginThrottlePolicy) blockedUntil(f *models.LoginFailures) time.Time {
+	if f == nil || f.FailedCount <= p.FreeAttempts {
+		return time.Time{}
+	}
+	if p.LockAfter > 0 && f.FailedCount >= p.LockAfter {
+		return f.LastFailedAt.Add(p.LockDuration)
+	}
+
+	delay := p.MaxDelay
+	if shift := f.FailedCount - p.FreeAttempts - 1; shift < 30 {
+		delay = time.Duration(math.Min(float64(p.BaseDelay<<shift), float64(p.MaxDelay)))
+	}
+	return f.LastFailedAt.Add(delay)
+}
+
+// normalized keeps counts around at least as long as a lockout lasts, so a
+// lockout cannot end early by its count being forgotten
+func (p LoginThrottlePolicy) normalized() LoginThrottlePolicy {
+	if p.ResetAfter < p.LockDuration {
+		p.ResetAfter = p.LockDuration
+	}
+	return p
+}
+
+// blockedAt reports whether a subject with the given failures must still wait at now
+func (p LoginThrottlePolicy) blockedAt(now time.Time) func(*models.LoginFailures) bool {
+	return func(f *models.LoginFailures) bool {
+		return now.Before(p.blockedUntil(f))
+	}
+}
+
+func (p LoginThrottlePolicy) locked(f *models.LoginFailures) bool {
+	return f != nil && p.LockAfter > 0 && f.FailedCount >= p.LockAfter
+}
+
+// LoginThrottle slows down and locks out repeated failed logins, both per user
+// ID and per IP address. Counts live in Postgres. Each attempt is counted as a
+// failure before the credentials are checked, so concurrent attempts cannot
+// all pass on the same count, and is taken back once it turns out otherwise.
+type LoginThrottle struct {
+	repo       *repository.LoginFailureRepository
+	userPolicy LoginThrottlePolicy
+	ipPolicy   LoginThrottlePolicy
+}
+
+func NewLoginThrottle(
+	repo *repository.LoginFailureRepository,
+	userPolicy LoginThrottlePolicy,
+	ipPolicy LoginThrottlePolicy,
+) *LoginThrottle {
+	return &LoginThrottle{
+		repo:       repo,
+		userPolicy: userPolicy.normalized(),
+		ipPolicy:   ipPolicy.normalized(),
+	}
+}
+
+// LoginAttempt is an attempt let through by LoginThrottle.Begin. Once its
+// outcome is known exactly one of Succeeded, Failed or Release is called.
+type LoginAttempt struct {
+	throttle *LoginThrottle
+	userID   string
+	ip       string
+	user     *models.LoginFailures
+	ipCount  *models.LoginFailures
+}
+
+// Begin counts a login attempt against the user ID and the IP address, or
+// refuses it while either is backing off or locked. Unknown user IDs are
+// throttled the same way as real ones, so the response does not reveal which
+// accounts exist.
+func (t *LoginThrottle) Begin(ctx context.Context, userID, ip string) (*LoginAttempt, error) {
+	now := time.Now()
+	attempt := &LoginAttempt{throttle: t, userID: userID, ip: ip}
+
+	previous, counted, err := t.repo.CountLoginAttempt(ctx, userSubject(userID), now, t.userPolicy.ResetAfter, t.userPolicy.blockedAt(now))
+	if err != nil {
+		return nil, err
+	}
+	if counted == nil {
+		message := "Too many failed login attempts; please wait before trying again"
+		if t.userPolicy.locked(previous) {
+			message = "This account is temporarily locked after too many failed login attempts"
+		}
+		return nil, &ServiceError{Status: http.StatusTooManyRequests, Message: message, RetryAfter: t.userPolicy.blockedUntil(previous).Sub(now)}
+	}
+	attempt.user = counted
+
+	if ip == "" {
+		return attempt, nil
+	}
+	previous, counted, err = t.repo.CountLoginAttempt(ctx, ipSubject(ip), now, t.ipPolicy.ResetAfter, t.ipPolicy.blockedAt(now))
+	if err != nil || counted == nil {
+		t.release(ctx, userSubject(userID))
+	}
+	if err != nil {
+		return nil, err
+	}
+	if counted == nil {
+		return nil, &ServiceError{
+			Status:     http.StatusTooManyRequests,
+			Message:    "Too many failed login attempts from your network; please wait before trying again",
+			RetryAfter: t.ipPolicy.blockedUntil(previous).Sub(now),
+		}
+	}
+	attempt.ipCount = counted
+	return attempt, nil
+}
+
+// Failed keeps the attempt counted and reports a lockout it caused
+func (a *LoginAttempt) Failed() {
+	t := a.throttle
+	if t.userPolicy.locked(a.user) && a.user.FailedCount == t.userPolicy.LockAfter {
+		go utils.LogEventToProducer("Account Locked", a.userID, map[string]interface{}{
+			"Timestamp":      time.Now().UTC().Format(time.RFC3339),
+			"Action":         "account_lock",
+			"userId":         a.userID,
+			"ip":             a.ip,
+			"failedAttempts": a.user.FailedCount,
+			"lockedUntil":    a.user.LastFailedAt.Add(t.userPolicy.LockDuration).UTC().Format(time.RFC3339),
+		})
+	}
+	if t.ipPolicy.locked(a.ipCount) && a.ipCount.FailedCount == t.ipPolicy.LockAfter {
+		go utils.LogEventToProducer("IP Address Locked", a.ip, map[string]interface{}{
+			"Timestamp":      time.Now().UTC().Format(time.RFC3339),
+			"Action":         "ip_lock",
+			"ip":             a.ip,
+			"failedAttempts": a.ipCount.FailedCount,
+			"lockedUntil":    a.ipCount.LastFailedAt.Add(t.ipPolicy.LockDuration).UTC().Format(time.RFC3339),
+		})
+	}
+}
+
+// Succeeded forgets the user's failed logins. The IP address only gets this
+// attempt back, so one valid account cannot reset an attacker's budget.
+func (a *LoginAttempt) Succeeded(ctx context.Context) {
+	if _, err := a.throttle.repo.ClearLoginFailures(ctx, userSubject(a.userID)); err != nil {
+		log.Printf("Failed to clear login failures for user %s: %v", a.userID, err)
+	}
+	a.releaseIP(ctx)
+}
+
+// Release takes the attempt back without forgetting earlier failures, for a
+// step that passed but does not finish the login, or an attempt that ended on
+// an error of our own
+func (a *LoginAttempt) Release(ctx context.Context) {
+	a.throttle.release(ctx, userSubject(a.userID))
+	a.releaseIP(ctx)
+}
+
+func (a *LoginAttempt) releaseIP(ctx context.Context) {
+	if a.ip != "" {
+		a.throttle.release(ctx, ipSubject(a.ip))
+	}
+}
+
+// Unlock lifts a lockout or backoff on the user. It reports false when the
+// user had no failed logins recorded.
+func (t *LoginThrottle) Unlock(ctx context.Context, userID string) (bool, error) {
+	return t.repo.ClearLoginFailures(ctx, userSubject(userID))
+}
+
+func (t *LoginThrottle) release(ctx context.Context, subject string) {
+	if err := t.repo.ReleaseLoginAttempt(ctx, subject); err != nil {
+		log.Printf("Failed to release login attempt for %s: %v", subject, err)
+	}
+}
+
+func userSubject(userID string) string {
+	return "user:" + userID
+}
+
+func ipSubject(ip string) string {
+	return "ip:" + ip
+}
@@ -0,0 +1,99 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/your-username/golang-ecommerce-app/models"
+)
+
+var testPolicy = LoginThrottlePolicy{
+	FreeAttempts: 3,
+	BaseDelay:    time.Second,
+	MaxDelay:     10 * time.Second,
+	LockAfter:    10,
+	LockDuration: 30 * time.Minute,
+	ResetAfter:   time.Hour,
+}
+
+func failures(count int, last time.Time) *models.LoginFailures {
+	return &models.LoginFailures{Subject: "user:jane", FailedCount: count, LastFailedAt: last}
+}
+
+func TestLoginThrottlePolicyBlockedUntil(t *testing.T) {
+	last := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name  string
+		f     *models.LoginFailures
+		wait  time.Duration
+		block bool
+	}{
+		{"no failures", nil, 0, false},
+		{"within the free attempts", failures(3, last), 0, false},
+		{"first paid failure waits the base delay", failures(4, last), time.Second, true},
+		{"each failure doubles the wait", failures(6, last), 4 * time.Second, true},
+		{"the wait is capped", failures(9, last), 10 * time.Second, true},
+		{"lock after the threshold", failures(10, last), 30 * time.Minute, true},
+		{"counts past the threshold stay locked", failures(500, last), 30 * time.Minute, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			until := testPolicy.blockedUntil(tt.f)
+			if !tt.block {
+				if !until.IsZero() {
+					t.Fatalf("blockedUntil = %s, want no wait", until)
+				}
+				return
+			}
+			if got := until.Sub(last); got != tt.wait {
+				t.Errorf("wait = %s, want %s", got, tt.wait)
+			}
+		})
+	}
+}
+
+func TestLoginThrottlePolicyBlockedAt(t *testing.T) {
+	last := time.Now()
+	f := failures(4, last)
+	if !testPolicy.blockedAt(last.Add(500 * time.Millisecond))(f) {
+		t.Error("not blocked during the backoff")
+	}
+	if testPolicy.blockedAt(last.Add(time.Second))(f) {
+		t.Error("still blocked once the backoff ended")
+	}
+	if testPolicy.blockedAt(last)(nil) {
+		t.Error("blocked without failures")
+	}
+}
+
+func TestLoginThrottlePolicyLocked(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy LoginThrottlePolicy
+		f      *models.LoginFailures
+		want   bool
+	}{
+		{"no failures", testPolicy, nil, false},
+		{"below the threshold", testPolicy, failures(9, time.Now()), false},
+		{"at the threshold", testPolicy, failures(10, time.Now()), true},
+		{"locking disabled", LoginThrottlePolicy{FreeAttempts: 3}, failures(1000, time.Now()), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.policy.locked(tt.f); got != tt.want {
+				t.Errorf("locked = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoginThrottlePolicyNormalized(t *testing.T) {
+	short := testPolicy
+	short.ResetAfter = time.Minute
+	if got := short.normalized().ResetAfter; got != short.LockDuration {
+		t.Errorf("ResetAfter = %s, want it raised to the lock duration %s", got, short.LockDuration)
+	}
+	if got := testPolicy.normalized(); got != testPolicy {
+		t.Errorf("normalized changed a valid policy: %+v", got)
+	}
+}
@@ -0,0 +1,424 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/your-username/golang-ecommerce-app/models"
+	"github.com/your-username/golang-ecommerce-app/repository"
+	"github.com/your-username/golang-ecommerce-app/utils"
+)
+
+const (
+	mfaChallengeTTL   = 5 * time.Minute
+	recoveryCodeCount = 10
+	// mfaChallengeAttempts caps the codes tried against a single login challenge
+	mfaChallengeAttempts = 5
+
+	mfaPolicyCacheKey = "mfa:required-roles"
+	mfaPolicyCacheTTL = time.Minute
+)
+
+// MFAService manages TOTP two-factor authentication and the policy of which
+// roles must use it
+type MFAService struct {
+	mfaRepo    *repository.MFARepository
+	userRepo   *repository.UserRepository
+	actionRepo *repository.ActionTokenRepository
+	roles      *RoleService
+	throttle   *LoginThrottle
+	cache      utils.CacheProvider
+	issuer     string
+}
+
+func NewMFAService(
+	mfaRepo *repository.MFARepository,
+	userRepo *repository.UserRepository,
+	actionRepo *repository.ActionTokenRepository,
+	roles *RoleService,
+	throttle *LoginThrottle,
+	cache utils.CacheProvider,
+	issuer string,
+) *MFAService {
+	return &MFAService{
+		mfaRepo:    mfaRepo,
+		userRepo:   userRepo,
+		actionRepo: actionRepo,
+		roles:      roles,
+		throttle:   throttle,
+		cache:      cache,
+		issuer:     issuer,
+	}
+}
+
+// Status describes the user's two-factor setup and whether their role requires it
+func (s *MFAService) Status(ctx context.Context, userID string) (*models.MFAStatus, error) {
+	user, err := s.findUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	status := &models.MFAStatus{Required: s.RequiredForRole(ctx, user.Role)}
+
+	secret, err := s.mfaRepo.GetTOTPSecret(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if secret != nil && secret.EnabledAt != nil {
+		status.Enabled = true
+		status.EnabledAt = secret.EnabledAt
+		if status.RecoveryCodesRemaining, err = s.mfaRepo.CountRecoveryCodes(ctx, userID); err != nil {
+			return nil, err
+		}
+	}
+	return status, nil
+}
+
+// StartEnrollment creates a new authenticator secret for the user. It only
+// takes effect once confirmed with a code from the app.
+func (s *MFAService) StartEnrollment(ctx context.Context, userID string) (*models.TOTPEnrollment, error) {
+	user, err := s.findUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	secret, err := utils.NewTOTPSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	saved, err := s.mfaRepo.SavePendingTOTPSecret(ctx, userID, secret)
+	if err != nil {
+		return nil, err
+	}
+	if !saved {
+		return nil, &ServiceError{Status: http.StatusConflict, Message: "Two-factor authentication is already enabled"}
+	}
+
+	return &models.TOTPEnrollment{
+		Secret: secret,
+		URI:    utils.TOTPURI(s.issuer, user.UserId, secret),
+	}, nil
+}
+
+// ConfirmEnrollment enables two-factor once the user enters a valid code and
+// returns their recovery codes. They are shown this once; only hashes are kept.
+func (s *MFAService) ConfirmEnrollment(ctx context.Context, userID, code string) ([]string, error) {
+	secret, err := s.mfaRepo.GetTOTPSecret(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if secret == nil {
+		return nil, &ServiceError{Status: http.StatusBadRequest, Message: "Start two-factor enrollment first"}
+	}
+	if secret.EnabledAt != nil {
+		return nil, &ServiceError{Status: http.StatusConflict, Message: "Two-factor authentication is already enabled"}
+	}
+
+	step, ok := utils.ValidateTOTP(secret.Secret, code, time.Now())
+	if !ok {
+		return nil, &ServiceError{Status: http.StatusBadRequest, Message: "Invalid authentication code"}
+	}
+
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+
+	enabled, err := s.mfaRepo.EnableTOTP(ctx, userID, step, hashes)
+	if err != nil {
+		return nil, err
+	}
+	if !enabled {
+		return nil, &ServiceError{Status: http.StatusConflict, Message: "Two-factor authentication is already enabled"}
+	}
+
+	logMFAEvent("MFA Enabled", "mfa_enable", userID)
+	return codes, nil
+}
+
+// Disable turns two-factor off after checking the password and a current code.
+// Users whose role requires two-factor cannot turn it off.
+func (s *MFAService) Disable(ctx context.Context, userID, password, code, ip string) error {
+	user, err := s.findUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if s.RequiredForRole(ctx, user.Role) {
+		return &ServiceError{Status: http.StatusForbidden, Message: "Two-factor authentication is required for your role"}
+	}
+
+	if err := s.checkPasswordAndCode(ctx, user, password, code, ip); err != nil {
+		return err
+	}
+
+	if err := s.mfaRepo.DisableTOTP(ctx, userID); err != nil {
+		return err
+	}
+
+	logMFAEvent("MFA Disabled", "mfa_disable", userID)
+	return nil
+}
+
+// RegenerateRecoveryCodes replaces the user's recovery codes after checking
+// the password and a current code
+func (s *MFAService) RegenerateRecoveryCodes(ctx context.Context, userID, password, code, ip string) ([]string, error) {
+	user, err := s.findUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkPasswordAndCode(ctx, user, password, code, ip); err != nil {
+		return nil, err
+	}
+
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.mfaRepo.ReplaceRecoveryCodes(ctx, userID, hashes); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// checkPasswordAndCode guards changes to the user's two-factor setup. Wrong
+// passwords and codes count against the user and the IP address in the login
+// throttle, so a stolen access token cannot be used to guess codes.
+func (s *MFAService) checkPasswordAndCode(ctx context.Context, user *models.User, password, code, ip string) error {
+	attempt, err := s.throttle.Begin(ctx, user.UserId, ip)
+	if err != nil {
+		return err
+	}
+
+	if !utils.ComparePasswords(password, user.Password) {
+		attempt.Failed()
+		return &ServiceError{Status: http.StatusUnauthorized, Message: "Password is incorrect"}
+	}
+	if err := s.checkCode(ctx, user.UserId, code); err != nil {
+		var serviceErr *ServiceError
+		if errors.As(err, &serviceErr) && serviceErr.Status == http.StatusUnauthorized {
+			attempt.Failed()
+		} else {
+			attempt.Release(ctx)
+		}
+		return err
+	}
+
+	attempt.Succeeded(ctx)
+	return nil
+}
+
+// IsEnabled reports whether the user signs in with a second factor
+func (s *MFAService) IsEnabled(ctx context.Context, userID string) (bool, error) {
+	secret, err := s.mfaRepo.GetTOTPSecret(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+	return secret != nil && secret.EnabledAt != nil, nil
+}
+
+// NewLoginChallenge returns the token a client exchanges, together with a code,
+// to finish a password login for the user
+func (s *MFAService) NewLoginChallenge(userID string) (string, int, error) {
+	token, err := utils.GenerateActionToken(utils.ActionMFALogin, userID, "", mfaChallengeTTL)
+	if err != nil {
+		return "", 0, err
+	}
+	return token, int(mfaChallengeTTL.Seconds()), nil
+}
+
+// LoginChallengeSubject returns the user a login challenge token was issued to
+func (s *MFAService) LoginChallengeSubject(token string) (string, error) {
+	claims, err := utils.VerifyActionToken(token, utils.ActionMFALogin)
+	if err != nil {
+		return "", &ServiceError{Status: http.StatusUnauthorized, Message: "Invalid or expired MFA token; please log in again"}
+	}
+	return claims.Subject, nil
+}
+
+// CompleteLoginChallenge checks the code for a login challenge and returns the
+// user it was issued to. A challenge allows a few attempts and works once.
+func (s *MFAService) CompleteLoginChallenge(ctx context.Context, token, code string) (string, error) {
+	claims, err := utils.VerifyActionToken(token, utils.ActionMFALogin)
+	if err != nil {
+		return "", &ServiceError{Status: http.StatusUnauthorized, Message: "Invalid or expired MFA token; please log in again"}
+	}
+
+	// Counted in the database so the limit cannot be lifted by a cache outage
+	allowed, err := s.actionRepo.RecordActionTokenAttempt(ctx, claims.ID, claims.ExpiresAt.Time, mfaChallengeAttempts)
+	if err != nil {
+		return "", err
+	}
+	if !allowed {
+		return "", &ServiceError{Status: http.StatusTooManyRequests, Message: "Too many attempts; please log in again"}
+	}
+
+	if err := s.checkCode(ctx, claims.Subject, code); err != nil {
+		return "", err
+	}
+
+	fresh, err := s.actionRepo.ConsumeActionToken(ctx, claims.ID, claims.Purpose, claims.ExpiresAt.Time)
+	if err != nil {
+		return "", err
+	}
+	if !fresh {
+		return "", &ServiceError{Status: http.StatusUnauthorized, Message: "MFA token has already been used; please log in again"}
+	}
+	return claims.Subject, nil
+}
+
+// checkCode accepts a current TOTP code or an unused recovery code. Each TOTP
+// code works once, and so does each recovery code.
+func (s *MFAService) checkCode(ctx context.Context, userID, code string) error {
+	invalid := &ServiceError{Status: http.StatusUnauthorized, Message: "Invalid authentication code"}
+	code = strings.TrimSpace(code)
+	if code == "" {
+		return invalid
+	}
+
+	secret, err := s.mfaRepo.GetTOTPSecret(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if secret == nil || secret.EnabledAt == nil {
+		return &ServiceError{Status: http.StatusBadRequest, Message: "Two-factor authentication is not enabled"}
+	}
+
+	if step, ok := utils.ValidateTOTP(secret.Secret, code, time.Now()); ok {
+		used, err := s.mfaRepo.UseTOTPStep(ctx, userID, step)
+		if err != nil {
+			return err
+		}
+		if !used {
+			return invalid
+		}
+		return nil
+	}
+
+	used, err := s.mfaRepo.UseRecoveryCode(ctx, userID, utils.HashRecoveryCode(code))
+	if err != nil {
+		return err
+	}
+	if !used {
+		return invalid
+	}
+	logMFAEvent("MFA Recovery Code Used", "mfa_recovery_code", userID)
+	return nil
+}
+
+// RequiredForRole reports whether members of role must use two-factor. If the
+// policy cannot be read it errs on the side of requiring it for every role
+// that grants permissions.
+func (s *MFAService) RequiredForRole(ctx context.Context, role string) bool {
+	roles, err := s.requiredRoles(ctx)
+	if err != nil {
+		log.Printf("Failed to read two-factor policy: %v", err)
+		return len(s.roles.PermissionsFor(ctx, []string{role})) > 0
+	}
+	for _, r := range roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+// GetPolicy lists the roles that must use two-factor
+func (s *MFAService) GetPolicy(ctx context.Context) ([]string, error) {
+	return s.requiredRoles(ctx)
+}
+
+// SetPolicy replaces the roles that must use two-factor. Requiring it for
+// superadmins is refused until the caller has it enabled, so the change cannot
+// lock them out.
+func (s *MFAService) SetPolicy(ctx context.Context, callerID string, roles []string) ([]string, error) {
+	seen := map[string]bool{}
+	unique := []string{}
+	for _, role := range roles {
+		if seen[role] {
+			continue
+		}
+		exists, err := s.roles.RoleExists(ctx, role)
+		if err != nil {
+			return nil, err
+		}
+		if !exists {
+			return nil, &ServiceError{Status: http.StatusBadRequest, Message: "Unknown role: " + role}
+		}
+		seen[role] = true
+		unique = append(unique, role)
+	}
+
+	if seen["superadmin"] {
+		enabled, err := s.IsEnabled(ctx, callerID)
+		if err != nil {
+			return nil, err
+		}
+		if !enabled {
+			return nil, &ServiceError{Status: http.StatusConflict, Message: "Enable two-factor on your own account before requiring it for superadmins"}
+		}
+	}
+
+	if err := s.mfaRepo.SetRequiredRoles(ctx, unique); err != nil {
+		return nil, err
+	}
+	if err := s.cache.Delete(ctx, mfaPolicyCacheKey); err != nil {
+		log.Printf("Failed to drop cached two-factor policy: %v", err)
+	}
+	return unique, nil
+}
+
+func (s *MFAService) requiredRoles(ctx context.Context) ([]string, error) {
+	if cached, err := s.cache.Get(ctx, mfaPolicyCacheKey); err == nil {
+		if cached == "" {
+			return []string{}, nil
+		}
+		return strings.Split(cached, ","), nil
+	}
+
+	roles, err := s.mfaRepo.GetRequiredRoles(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.cache.Set(ctx, mfaPolicyCacheKey, strings.Join(roles, ","), mfaPolicyCacheTTL); err != nil && !errors.Is(err, utils.ErrCacheUnavailable) {
+		log.Printf("Failed to cache two-factor policy: %v", err)
+	}
+	return roles, nil
+}
+
+func (s *MFAService) findUser(ctx context.Context, userID string) (*models.User, error) {
+	user, err := s.userRepo.FindByuserId(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, &ServiceError{Status: http.StatusNotFound, Message: "User not found"}
+	}
+	return user, nil
+}
+
+// newRecoveryCodes returns fresh recovery codes and the hashes to store
+func newRecoveryCodes() ([]string, []string, error) {
+	codes, err := utils.NewRecoveryCodes(recoveryCodeCount)
+	if err != nil {
+		return nil, nil, err
+	}
+	hashes := make([]string, len(codes))
+	for i, code := range codes {
+		hashes[i] = utils.HashRecoveryCode(code)
+	}
+	return codes, hashes, nil
+}
+
+func logMFAEvent(name, action, userID string) {
+	go utils.LogEventToProducer(name, userID, map[string]interface{}{
+		"Timestamp": time.Now().UTC().Format(time.RFC3339),
+		"Action":    action,
+		"userId":    userID,
+	})
+}
@@ -5,6 +5,8 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
+	"net/http"
+	"time"
 
 	"github.com/your-username/golang-ecommerce-app/models"
 	"github.com/your-username/golang-ecommerce-app/repository"
@@ -15,6 +17,9 @@ type OrderService struct {
 	cartRepo    *repository.CartRepository
 	paymentRepo *repository.PaymentRepository
 	productRepo *repository.ProductRepository
+	userRepo    *repository.UserRepository
+	// requireVerifiedEmail blocks checkout until the user has confirmed their email
+	requireVerifiedEmail bool
 }
 
 func NewOrderService(
@@ -22,12 +27,16 @@ func NewOrderService(
 	cartRepo *repository.CartRepository,
 	paymentRepo *repository.PaymentRepository,
 	productRepo *repository.ProductRepository,
+	userRepo *repository.UserRepository,
+	requireVerifiedEmail bool,
 ) *OrderService {
 	return &OrderService{
-		orderRepo:   orderRepo,
-		cartRepo:    cartRepo,
-		paymentRepo: paymentRepo,
-		productRepo: productRepo,
+		orderRepo:            orderRepo,
+		cartRepo:             cartRepo,
+		paymentRepo:          paymentRepo,
+		productRepo:          productRepo,
+		userRepo:             userRepo,
+		requireVerifiedEmail: requireVerifiedEmail,
 	}
 }
 
@@ -36,6 +45,16 @@ func (s *OrderService) CreateOrder(ctx context.Context, userId string) (*models.
 		return nil, fmt.Errorf("invalid user ID")
 	}
 
+	if s.requireVerifiedEmail {
+		user, err := s.userRepo.FindByuserId(ctx, userId)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get user: %w", err)
+		}
+		if user == nil || !user.EmailVerified {
+			return nil, &ServiceError{Status: http.StatusForbidden, Message: "Please verify your email address before checking out"}
+		}
+	}
+
 	cart, err := s.cartRepo.GetCartByUserID(ctx, userId)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get cart: %w", err)
@@ -49,8 +68,17 @@ func (s *OrderService) CreateOrder(ctx context.Context, userId string) (*models.
 		return nil, fmt.Errorf("failed to parse cart products: %w", err)
 	}
 
+	unavailable, err := findUnavailableItems(ctx, s.productRepo, cartProducts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check cart availability: %w", err)
+	}
+	if len(unavailable) > 0 {
+		return nil, &UnavailableItemsError{Items: unavailable}
+	}
+
 	var items []models.OrderItem
 	var totalAmount float64
+	now := time.Now()
 
 	for _, item := range cartProducts {
 		product, err := s.productRepo.GetProductByID(ctx, item.ProductID)
@@ -58,18 +86,19 @@ func (s *OrderService) CreateOrder(ctx context.Context, userId string) (*models.
 			return nil, fmt.Errorf("failed to get product %d: %w", item.ProductID, err)
 		}
 		if product == nil {
-			return nil, fmt.Errorf("product with ID %d not found", item.ProductID)
+			return nil, &UnavailableItemsError{Items: []models.CartProduct{item}}
 		}
 
+		price := product.EffectivePrice(now)
 		items = append(items, models.OrderItem{
 			ProductID: product.ProductID,
 			Name:      product.Name,
 			Image:     product.Image,
-			Price:     product.Price,
+			Price:     price,
 			Quantity:  item.Quantity,
 		})
 
-		totalAmount += product.Price * float64(item.Quantity)
+		totalAmount += price * float64(item.Quantity)
 	}
 
 	tx, err := s.orderRepo.BeginTx(ctx)
@@ -136,7 +165,11 @@ func (s *OrderService) GetUserOrders(ctx context.Context, userId string) ([]mode
 	return orders, nil
 }
 
-func (s *OrderService) UpdateUserOrder(ctx context.Context, userId string, orderId string, status string) (*models.Order, error) {
+// UpdateUserOrder sets the status of one of the user's orders on behalf of actor
+func (s *OrderService) UpdateUserOrder(ctx context.Context, actor *models.Principal, userId string, orderId string, status string) (*models.Order, error) {
+	if actor == nil || !actor.Can(models.PermOrdersUpdate) {
+		return nil, &ServiceError{Status: http.StatusForbidden, Message: "You do not have permission to update orders"}
+	}
 	if userId == "" || orderId == "" {
 		return nil, fmt.Errorf("invalid user ID or order ID")
 	}
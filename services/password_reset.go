@@ -0,0 +1,134 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/your-username/golang-ecommerce-app/models"
+	"github.com/your-username/golang-ecommerce-app/repository"
+	"github.com/your-username/golang-ecommerce-app/utils"
+)
+
+type PasswordResetService struct {
+	userRepo       *repository.UserRepository
+	resetRepo      *repository.PasswordResetRepository
+	sessionService *SessionService
+	mailer         utils.Mailer
+	limiter        *utils.RateLimiter
+	frontendURL    string
+	ttl            time.Duration
+}
+
+func NewPasswordResetService(
+	userRepo *repository.UserRepository,
+	resetRepo *repository.PasswordResetRepository,
+	sessionService *SessionService,
+	mailer utils.Mailer,
+	limiter *utils.RateLimiter,
+	frontendURL string,
+	ttl time.Duration,
+) *PasswordResetService {
+	return &PasswordResetService{
+		userRepo:       userRepo,
+		resetRepo:      resetRepo,
+		sessionService: sessionService,
+		mailer:         mailer,
+		limiter:        limiter,
+		frontendURL:    frontendURL,
+		ttl:            ttl,
+	}
+}
+
+// RequestReset emails a reset link to the account with the given address. The
+// lookup and the email happen in the background so neither the response nor
+// its timing tells the caller whether such an account exists.
+func (s *PasswordResetService) RequestReset(ctx context.Context, email string) error {
+	email = strings.TrimSpace(email)
+	if email == "" {
+		return &ServiceError{Status: http.StatusBadRequest, Message: "email is required"}
+	}
+
+	allowed, err := s.limiter.Allow(ctx, "password-reset:"+strings.ToLower(email))
+	if err != nil && !errors.Is(err, utils.ErrCacheUnavailable) {
+		log.Printf("Password reset rate limit check failed: %v", err)
+	}
+	if !allowed {
+		return &ServiceError{Status: http.StatusTooManyRequests, Message: "Too many password reset requests; please try again later"}
+	}
+
+	go s.sendReset(context.WithoutCancel(ctx), email)
+	return nil
+}
+
+func (s *PasswordResetService) sendReset(ctx context.Context, email string) {
+	user, err := s.userRepo.FindByEmail(ctx, email)
+	if err != nil {
+		log.Printf("Password reset lookup failed: %v", err)
+		return
+	}
+	if user == nil {
+		return
+	}
+
+	if err := s.sendResetEmail(ctx, user); err != nil {
+		log.Printf("Failed to send password reset email to user %s: %v", user.UserId, err)
+	}
+}
+
+func (s *PasswordResetService) sendResetEmail(ctx context.Context, user *models.User) error {
+	token, tokenHash, err := utils.NewOpaqueToken()
+	if err != nil {
+		return fmt.Errorf("failed to create reset token: %w", err)
+	}
+
+	expiresAt := time.Now().Add(s.ttl)
+	if err := s.resetRepo.CreateResetToken(ctx, user.UserId, tokenHash, expiresAt); err != nil {
+		return err
+	}
+
+	link := s.frontendURL + "/reset-password?token=" + url.QueryEscape(token)
+	return s.mailer.Send(ctx, utils.Email{
+		To:      user.Email,
+		Subject: "Reset your password",
+		Body: fmt.Sprintf("Hi %s,\n\nSomeone asked to reset the password for your account. "+
+			"Open the link below to choose a new one. It works once and expires on %s.\n\n%s\n\n"+
+			"If you did not ask for this, you can ignore this email; your password has not changed.\n",
+			user.UserId, expiresAt.UTC().Format(time.RFC1123), link),
+	})
+}
+
+// ResetPassword sets a new password using a token from a reset email and
+// signs the user out of every session
+func (s *PasswordResetService) ResetPassword(ctx context.Context, token, newPassword string) error {
+	if token == "" || newPassword == "" {
+		return &ServiceError{Status: http.StatusBadRequest, Message: "token and password are required"}
+	}
+
+	hashedPassword, err := utils.HashPassword(newPassword)
+	if err != nil {
+		log.Println("Hashing error:", err)
+		return err
+	}
+
+	userID, sessionIDs, err := s.resetRepo.ResetPassword(ctx, utils.HashOpaqueToken(token), hashedPassword, time.Now())
+	if err != nil {
+		return err
+	}
+	if userID == "" {
+		return &ServiceError{Status: http.StatusBadRequest, Message: "Invalid or expired reset link"}
+	}
+	s.sessionService.forget(ctx, sessionIDs...)
+
+	go utils.LogEventToProducer("Password Reset", userID, map[string]interface{}{
+		"Timestamp": time.Now().UTC().Format(time.RFC3339),
+		"Action":    "password_reset",
+		"userId":    userID,
+	})
+	return nil
+}
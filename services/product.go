@@ -6,6 +6,8 @@ import (
 	"errors"
 	"fmt"
 	"log"
+	"net/http"
+	"strings"
 	"time"
 
 	"github.com/your-username/golang-ecommerce-app/models"
@@ -14,21 +16,34 @@ import (
 )
 
 const (
-	productCacheTTL       = time.Hour
-	defaultPage           = 1
-	defaultLimit          = 5
-	firstPageCachePattern = "product:1:*" // Pattern to match first page cache keys
+	defaultPage  = 1
+	defaultLimit = 5
 )
 
+// errProductNotFound tells a cache load that there is nothing to cache
+var errProductNotFound = errors.New("product not found")
+
 type ProductService struct {
 	productRepo *repository.ProductRepository
+	imageRepo   *repository.ProductImageRepository
+	store       utils.BlobStore
 	cache       utils.CacheProvider
+	loader      *utils.CacheLoader
 }
 
-func NewProductService(productRepo *repository.ProductRepository, cache utils.CacheProvider) *ProductService {
+func NewProductService(
+	productRepo *repository.ProductRepository,
+	imageRepo *repository.ProductImageRepository,
+	store utils.BlobStore,
+	cache utils.CacheProvider,
+	loader *utils.CacheLoader,
+) *ProductService {
 	return &ProductService{
 		productRepo: productRepo,
+		imageRepo:   imageRepo,
+		store:       store,
 		cache:       cache,
+		loader:      loader,
 	}
 }
 
@@ -43,59 +58,129 @@ func (s *ProductService) GetPaginatedProducts(ctx context.Context, page, limit i
 	offset := (page - 1) * limit
 
 	cacheKey := fmt.Sprintf("products:%d:%d", page, limit)
+	data, err := s.loader.Load(ctx, cacheKey, func(ctx context.Context) (string, []string, error) {
+		products, err := s.productRepo.GetPaginatedProducts(ctx, limit, offset)
+		if err != nil {
+			return "", nil, fmt.Errorf("failed to get paginated products: %w", err)
+		}
 
-	var cachedResponse models.PaginatedProductResponse
-	cachedData, err := s.cache.Get(ctx, cacheKey)
-	if err == nil {
-		log.Printf("Cache hit for key: %s", cacheKey)
-		if err := json.Unmarshal([]byte(cachedData), &cachedResponse); err == nil {
-			return &cachedResponse, nil
+		if err := s.attachSrcSets(ctx, products); err != nil {
+			return "", nil, err
 		}
-	}
 
-	products, err := s.productRepo.GetPaginatedProducts(ctx, limit, offset)
+		total, err := s.productRepo.GetTotalProductCount(ctx)
+		if err != nil {
+			return "", nil, fmt.Errorf("failed to get product count: %w", err)
+		}
+
+		jsonData, err := json.Marshal(models.PaginatedProductResponse{
+			Products: products,
+			Total:    total,
+			Page:     page,
+			Limit:    limit,
+		})
+		if err != nil {
+			return "", nil, fmt.Errorf("failed to marshal products: %w", err)
+		}
+
+		tags := []string{productListTag}
+		for _, p := range products {
+			tags = append(tags, productTag(p.ProductID))
+		}
+		return string(jsonData), tags, nil
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to get paginated products: %w", err)
+		return nil, err
+	}
+
+	var response models.PaginatedProductResponse
+	if err := json.Unmarshal([]byte(data), &response); err != nil {
+		return nil, fmt.Errorf("failed to decode products: %w", err)
+	}
+	return &response, nil
+}
+
+func (s *ProductService) GetProductByID(ctx context.Context, id int) (*models.Product, error) {
+	if id <= 0 {
+		return nil, errors.New("invalid product ID")
 	}
 
-	total, err := s.productRepo.GetTotalProductCount(ctx)
+	data, err := s.loader.Load(ctx, productDetailCacheKey(id), func(ctx context.Context) (string, []string, error) {
+		product, err := s.productRepo.GetProductByID(ctx, id)
+		if err != nil {
+			return "", nil, fmt.Errorf("failed to get product by ID: %w", err)
+		}
+		if product == nil {
+			return "", nil, errProductNotFound
+		}
+
+		if err := s.attachImages(ctx, product); err != nil {
+			return "", nil, err
+		}
+
+		jsonData, err := json.Marshal(product)
+		if err != nil {
+			return "", nil, fmt.Errorf("failed to marshal product: %w", err)
+		}
+		return string(jsonData), []string{productTag(id)}, nil
+	})
+	if errors.Is(err, errProductNotFound) {
+		return nil, nil
+	}
 	if err != nil {
-		return nil, fmt.Errorf("failed to get product count: %w", err)
+		return nil, err
 	}
 
-	response := &models.PaginatedProductResponse{
-		Products: products,
-		Total:    total,
-		Page:     page,
-		Limit:    limit,
+	var product models.Product
+	if err := json.Unmarshal([]byte(data), &product); err != nil {
+		return nil, fmt.Errorf("failed to decode product: %w", err)
 	}
+	return &product, nil
+}
+
+// CacheStats reports how the product read cache is performing
+func (s *ProductService) CacheStats() utils.CacheStats {
+	return s.loader.Stats()
+}
 
-	jsonData, err := json.Marshal(response)
+// attachImages loads a product's images and fills in their srcsets
+func (s *ProductService) attachImages(ctx context.Context, product *models.Product) error {
+	images, err := s.imageRepo.GetImagesByProductID(ctx, product.ProductID)
 	if err != nil {
-		log.Printf("Failed to marshal products for caching: %v", err)
-	} else {
-		if err := s.cache.Set(ctx, cacheKey, string(jsonData), productCacheTTL); err != nil {
-			log.Printf("Failed to cache products: %v", err)
-		}
+		return fmt.Errorf("failed to get product images: %w", err)
 	}
 
-	return response, nil
+	product.Images = images
+	for i := range product.Images {
+		product.Images[i].SrcSet = buildSrcSet(product.Images[i])
+		if product.Images[i].IsPrimary {
+			product.SrcSet = product.Images[i].SrcSet
+		}
+	}
+	return nil
 }
 
-func (s *ProductService) GetProductByID(ctx context.Context, id int) (*models.Product, error) {
-	if id <= 0 {
-		return nil, errors.New("invalid product ID")
+// attachSrcSets fills in each product's srcset from its primary image's thumbnails
+func (s *ProductService) attachSrcSets(ctx context.Context, products []models.Product) error {
+	if len(products) == 0 {
+		return nil
+	}
+
+	ids := make([]int, len(products))
+	for i, p := range products {
+		ids[i] = p.ProductID
 	}
 
-	product, err := s.productRepo.GetProductByID(ctx, id)
+	primaries, err := s.imageRepo.GetPrimaryImages(ctx, ids)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get product by ID: %w", err)
+		return fmt.Errorf("failed to get primary images: %w", err)
 	}
-	if product == nil {
-		return nil, nil
+	for i := range products {
+		if img, ok := primaries[products[i].ProductID]; ok {
+			products[i].SrcSet = buildSrcSet(img)
+		}
 	}
-
-	return product, nil
+	return nil
 }
 
 func (s *ProductService) CreateProduct(ctx context.Context, product *models.Product) (*models.Product, error) {
@@ -106,19 +191,39 @@ func (s *ProductService) CreateProduct(ctx context.Context, product *models.Prod
 		return nil, errors.New("product price must be positive")
 	}
 
+	// New products stay hidden until published, either explicitly or by the scheduler
+	if product.Status == "" {
+		product.Status = models.ProductStatusDraft
+		if product.PublishAt != nil {
+			product.Status = models.ProductStatusScheduled
+		}
+	}
+	if err := validateProductStatus(product.Status, product.PublishAt); err != nil {
+		return nil, err
+	}
+	if product.Status != models.ProductStatusScheduled {
+		product.PublishAt = nil
+	}
+
 	createdProduct, err := s.productRepo.CreateProduct(ctx, *product)
+	if errors.Is(err, repository.ErrDuplicateSKU) {
+		return nil, &ServiceError{Status: http.StatusConflict, Message: err.Error()}
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to create product: %w", err)
 	}
 
-	if err := s.cache.DeletePattern(ctx, firstPageCachePattern); err != nil {
-		log.Printf("Failed to invalidate product cache: %v", err)
+	// Drafts and scheduled products are not visible yet, so cached pages stay valid
+	if createdProduct.Status == models.ProductStatusPublished {
+		invalidateProductCache(ctx, s.cache, true, createdProduct.ProductID)
 	}
 
 	return createdProduct, nil
 }
 
-func (s *ProductService) UpdateProduct(ctx context.Context, id int, updates models.Product) (*models.Product, error) {
+// UpdateProduct replaces a product's editable fields. A non-zero expectedVersion
+// rejects the update if the product has changed since that version was read.
+func (s *ProductService) UpdateProduct(ctx context.Context, id int, updates models.Product, expectedVersion int) (*models.Product, error) {
 	if id <= 0 {
 		return nil, errors.New("invalid product ID")
 	}
@@ -127,21 +232,177 @@ func (s *ProductService) UpdateProduct(ctx context.Context, id int, updates mode
 		return nil, errors.New("no valid fields provided for update")
 	}
 
-	updatedProduct, err := s.productRepo.UpdateProduct(ctx, id, updates)
-	if err != nil {
-		return nil, fmt.Errorf("failed to update product: %w", err)
+	updatedProduct, err := s.productRepo.UpdateProduct(ctx, id, updates, expectedVersion)
+	if err := productWriteError(err); err != nil {
+		return nil, err
 	}
 	if updatedProduct == nil {
 		return nil, nil
 	}
 
-	if err := s.cache.DeletePattern(ctx, "products:*"); err != nil {
-		log.Printf("Failed to invalidate product cache: %v", err)
-	}
+	invalidateProductCache(ctx, s.cache, false, id)
 
 	return updatedProduct, nil
 }
 
+// PatchProduct applies a partial update to a product that must still be at expectedVersion
+func (s *ProductService) PatchProduct(ctx context.Context, id int, patch models.ProductPatch, expectedVersion int) (*models.Product, error) {
+	if id <= 0 {
+		return nil, errors.New("invalid product ID")
+	}
+
+	switch {
+	case patch.SKU == nil && patch.Name == nil && patch.Description == nil && patch.Category == nil && patch.Image == nil && patch.Price == nil:
+		return nil, &ServiceError{Status: http.StatusBadRequest, Message: "No fields provided for update"}
+	case patch.Name != nil && strings.TrimSpace(*patch.Name) == "":
+		return nil, &ServiceError{Status: http.StatusBadRequest, Message: "name cannot be empty"}
+	case patch.Price != nil && *patch.Price <= 0:
+		return nil, &ServiceError{Status: http.StatusBadRequest, Message: "price must be positive"}
+	case patch.SKU != nil && (*patch.SKU == "" || len(*patch.SKU) > 64):
+		return nil, &ServiceError{Status: http.StatusBadRequest, Message: "sku must be between 1 and 64 characters"}
+	case patch.Category != nil && len(*patch.Category) > 100:
+		return nil, &ServiceError{Status: http.StatusBadRequest, Message: "category must be at most 100 characters"}
+	}
+
+	patchedProduct, err := s.productRepo.PatchProduct(ctx, id, patch, expectedVersion)
+	if err := productWriteError(err); err != nil {
+		return nil, err
+	}
+	if patchedProduct == nil {
+		return nil, nil
+	}
+	invalidateProductCache(ctx, s.cache, false, id)
+
+	return patchedProduct, nil
+}
+
+// productWriteError maps repository write errors to service errors
+func productWriteError(err error) error {
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, repository.ErrDuplicateSKU):
+		return &ServiceError{Status: http.StatusConflict, Message: err.Error()}
+	case errors.Is(err, repository.ErrVersionConflict):
+		return &ServiceError{Status: http.StatusPreconditionFailed, Message: err.Error()}
+	}
+	return fmt.Errorf("failed to update product: %w", err)
+}
+
+// GetAdminProducts lists live products in every publishing state, optionally
+// filtered by status. Results are never cached since admins expect fresh data.
+func (s *ProductService) GetAdminProducts(ctx context.Context, status string, page, limit int) (*models.PaginatedProductResponse, error) {
+	if status != "" && !isProductStatus(status) {
+		return nil, &ServiceError{Status: http.StatusBadRequest, Message: "Invalid product status: " + status}
+	}
+	if page <= 0 {
+		page = defaultPage
+	}
+	if limit <= 0 {
+		limit = defaultLimit
+	}
+
+	products, total, err := s.productRepo.GetProductsByStatus(ctx, status, limit, (page-1)*limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get products: %w", err)
+	}
+
+	if err := s.attachSrcSets(ctx, products); err != nil {
+		return nil, err
+	}
+
+	return &models.PaginatedProductResponse{
+		Products: products,
+		Total:    total,
+		Page:     page,
+		Limit:    limit,
+	}, nil
+}
+
+// GetAdminProductByID fetches a product with its images whatever its publishing state
+func (s *ProductService) GetAdminProductByID(ctx context.Context, id int) (*models.Product, error) {
+	if id <= 0 {
+		return nil, errors.New("invalid product ID")
+	}
+
+	product, err := s.productRepo.GetAnyProductByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get product by ID: %w", err)
+	}
+	if product == nil {
+		return nil, nil
+	}
+
+	if err := s.attachImages(ctx, product); err != nil {
+		return nil, err
+	}
+	return product, nil
+}
+
+// SetProductStatus moves a product to a new publishing state. Scheduling
+// requires a publishAt in the future; other states clear it.
+func (s *ProductService) SetProductStatus(ctx context.Context, id int, status string, publishAt *time.Time) (*models.Product, error) {
+	if id <= 0 {
+		return nil, errors.New("invalid product ID")
+	}
+	if err := validateProductStatus(status, publishAt); err != nil {
+		return nil, err
+	}
+	if status != models.ProductStatusScheduled {
+		publishAt = nil
+	}
+
+	product, err := s.productRepo.UpdateProductStatus(ctx, id, status, publishAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update product status: %w", err)
+	}
+	if product == nil {
+		return nil, nil
+	}
+
+	// Any transition may add or remove the product from public listings
+	invalidateProductCache(ctx, s.cache, true, id)
+
+	return product, nil
+}
+
+// PublishDueProducts publishes every scheduled product whose publishAt has passed
+func (s *ProductService) PublishDueProducts(ctx context.Context, now time.Time) ([]int, error) {
+	ids, err := s.productRepo.PublishDueProducts(ctx, now)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(ids) > 0 {
+		invalidateProductCache(ctx, s.cache, true, ids...)
+	}
+
+	return ids, nil
+}
+
+func isProductStatus(status string) bool {
+	switch status {
+	case models.ProductStatusDraft, models.ProductStatusScheduled, models.ProductStatusPublished, models.ProductStatusArchived:
+		return true
+	}
+	return false
+}
+
+func validateProductStatus(status string, publishAt *time.Time) error {
+	if !isProductStatus(status) {
+		return &ServiceError{Status: http.StatusBadRequest, Message: "Invalid product status: " + status}
+	}
+	if status == models.ProductStatusScheduled {
+		if publishAt == nil {
+			return &ServiceError{Status: http.StatusBadRequest, Message: "publishAt is required to schedule a product"}
+		}
+		if !publishAt.After(time.Now()) {
+			return &ServiceError{Status: http.StatusBadRequest, Message: "publishAt must be in the future"}
+		}
+	}
+	return nil
+}
+
 func (s *ProductService) DeleteProduct(ctx context.Context, id int) (*models.Product, error) {
 	if id <= 0 {
 		return nil ,errors.New("invalid product ID")
@@ -155,9 +416,71 @@ func (s *ProductService) DeleteProduct(ctx context.Context, id int) (*models.Pro
 		return nil, nil
 	}
 
-	if err := s.cache.DeletePattern(ctx, "products:*"); err != nil {
-		log.Printf("Failed to invalidate product cache: %v", err)
-	}
+	invalidateProductCache(ctx, s.cache, true, id)
 
 	return deletedProduct, nil
-}
\ No newline at end of file
+}
+
+func (s *ProductService) GetDeletedProducts(ctx context.Context, page, limit int) ([]models.Product, error) {
+	if page <= 0 {
+		page = defaultPage
+	}
+	if limit <= 0 {
+		limit = defaultLimit
+	}
+
+	products, err := s.productRepo.GetDeletedProducts(ctx, limit, (page-1)*limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get deleted products: %w", err)
+	}
+	return products, nil
+}
+
+func (s *ProductService) RestoreProduct(ctx context.Context, id int) (*models.Product, error) {
+	if id <= 0 {
+		return nil, errors.New("invalid product ID")
+	}
+
+	restoredProduct, err := s.productRepo.RestoreProduct(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to restore product: %w", err)
+	}
+	if restoredProduct == nil {
+		return nil, nil
+	}
+
+	invalidateProductCache(ctx, s.cache, true, id)
+
+	return restoredProduct, nil
+}
+
+// PurgeProduct permanently removes a soft-deleted product along with its stored images
+func (s *ProductService) PurgeProduct(ctx context.Context, id int) (*models.Product, error) {
+	if id <= 0 {
+		return nil, errors.New("invalid product ID")
+	}
+
+	images, err := s.imageRepo.GetImagesByProductID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get product images: %w", err)
+	}
+
+	purgedProduct, err := s.productRepo.PurgeProduct(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to purge product: %w", err)
+	}
+	if purgedProduct == nil {
+		return nil, nil
+	}
+
+	// Image rows are removed by the cascade; the stored files have to go separately
+	for _, image := range images {
+		for _, key := range imageObjectKeys(image) {
+			if err := s.store.Delete(ctx, key); err != nil {
+				log.Printf("Failed to delete stored image %s: %v", key, err)
+			}
+		}
+	}
+
+	return purgedProduct, nil
+}
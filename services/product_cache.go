@@ -0,0 +1,46 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/your-username/golang-ecommerce-app/utils"
+)
+
+// Cache tags for product data. Every cached response that embeds a product is
+// tagged with productTag(id), so editing one product only drops the responses
+// that show it; productListTag marks listing pages, whose membership changes
+// whenever a product is published, unpublished or deleted.
+const (
+	productListTag    = "products:list"
+	relatedProductTag = "products:related"
+)
+
+func productTag(id int) string {
+	return fmt.Sprintf("product:%d", id)
+}
+
+func productDetailCacheKey(id int) string {
+	return fmt.Sprintf("products:detail:%d", id)
+}
+
+// invalidateProductCache drops cached responses that show any of the given
+// products. listChanged must be set when products were added to or removed from
+// the public catalog, since every listing page may then shift.
+func invalidateProductCache(ctx context.Context, cache utils.CacheProvider, listChanged bool, ids ...int) {
+	tags := make([]string, 0, len(ids)+1)
+	if listChanged {
+		tags = append(tags, productListTag)
+	}
+	for _, id := range ids {
+		tags = append(tags, productTag(id))
+	}
+	if len(tags) == 0 {
+		return
+	}
+
+	if err := cache.InvalidateTags(ctx, tags...); err != nil {
+		log.Printf("Failed to invalidate product cache: %v", err)
+	}
+}
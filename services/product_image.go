@@ -0,0 +1,301 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/your-username/golang-ecommerce-app/models"
+	"github.com/your-username/golang-ecommerce-app/repository"
+	"github.com/your-username/golang-ecommerce-app/utils"
+)
+
+// MaxProductImageSize is the largest product image upload accepted, in bytes
+const MaxProductImageSize = 5 << 20
+
+// allowedImageTypes maps accepted image content types to the file extension used when storing them
+var allowedImageTypes = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/gif":  ".gif",
+	"image/webp": ".webp",
+}
+
+type ProductImageService struct {
+	productRepo     *repository.ProductRepository
+	imageRepo       *repository.ProductImageRepository
+	store           utils.BlobStore
+	cache           utils.CacheProvider
+	thumbnailWidths []int
+	// maxPixels bounds width*height so a small file declaring huge dimensions
+	// cannot make decoding allocate gigabytes
+	maxPixels int64
+}
+
+func NewProductImageService(
+	productRepo *repository.ProductRepository,
+	imageRepo *repository.ProductImageRepository,
+	store utils.BlobStore,
+	cache utils.CacheProvider,
+	thumbnailWidths []int,
+	maxPixels int64,
+) *ProductImageService {
+	return &ProductImageService{
+		productRepo:     productRepo,
+		imageRepo:       imageRepo,
+		store:           store,
+		cache:           cache,
+		thumbnailWidths: thumbnailWidths,
+		maxPixels:       maxPixels,
+	}
+}
+
+// GetProductImages lists the images of a published product
+func (s *ProductImageService) GetProductImages(ctx context.Context, productID int) ([]models.ProductImage, error) {
+	product, err := s.productRepo.GetProductByID(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+	if product == nil {
+		return nil, &ServiceError{Status: http.StatusNotFound, Message: "Product not found"}
+	}
+
+	images, err := s.imageRepo.GetImagesByProductID(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range images {
+		images[i].SrcSet = buildSrcSet(images[i])
+	}
+	return images, nil
+}
+
+// UploadImage validates and stores an uploaded image for a product. A negative
+// position appends the image after the existing ones.
+func (s *ProductImageService) UploadImage(ctx context.Context, productID int, file io.Reader, position int, primary bool) (*models.ProductImage, error) {
+	if err := s.ensureProduct(ctx, productID); err != nil {
+		return nil, err
+	}
+
+	data, err := io.ReadAll(io.LimitReader(file, MaxProductImageSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read image: %w", err)
+	}
+	if len(data) == 0 {
+		return nil, &ServiceError{Status: http.StatusBadRequest, Message: "Image file is empty"}
+	}
+	if len(data) > MaxProductImageSize {
+		return nil, &ServiceError{
+			Status:  http.StatusRequestEntityTooLarge,
+			Message: fmt.Sprintf("Image exceeds maximum size of %d bytes", MaxProductImageSize),
+		}
+	}
+
+	// Trust the file contents rather than the client-supplied Content-Type
+	contentType := http.DetectContentType(data)
+	ext, ok := allowedImageTypes[contentType]
+	if !ok {
+		return nil, &ServiceError{
+			Status:  http.StatusUnsupportedMediaType,
+			Message: "Unsupported image type: " + contentType,
+		}
+	}
+
+	dims, err := utils.DecodeImageConfig(data)
+	if err != nil {
+		return nil, &ServiceError{Status: http.StatusBadRequest, Message: "Image could not be decoded"}
+	}
+	if dims.Width <= 0 || dims.Height <= 0 || int64(dims.Width)*int64(dims.Height) > s.maxPixels {
+		return nil, &ServiceError{
+			Status:  http.StatusRequestEntityTooLarge,
+			Message: fmt.Sprintf("Image dimensions exceed the maximum of %d pixels", s.maxPixels),
+		}
+	}
+
+	thumbnails, err := utils.GenerateThumbnails(data, s.thumbnailWidths)
+	if err != nil {
+		log.Printf("Thumbnail generation failed for product %d: %v", productID, err)
+		return nil, &ServiceError{Status: http.StatusBadRequest, Message: "Image could not be decoded"}
+	}
+
+	objectKey, err := newObjectKey(fmt.Sprintf("products/%d", productID), ext)
+	if err != nil {
+		return nil, err
+	}
+
+	stored := []string{}
+	cleanup := func() {
+		for _, key := range stored {
+			if delErr := s.store.Delete(ctx, key); delErr != nil {
+				log.Printf("Failed to clean up orphaned image %s: %v", key, delErr)
+			}
+		}
+	}
+
+	if err := s.store.Put(ctx, objectKey, bytes.NewReader(data), int64(len(data)), contentType); err != nil {
+		log.Printf("Failed to store product image %s: %v", objectKey, err)
+		return nil, fmt.Errorf("failed to store image: %w", err)
+	}
+	stored = append(stored, objectKey)
+
+	variants := make([]models.ImageVariant, 0, len(thumbnails))
+	for _, thumb := range thumbnails {
+		key := variantObjectKey(objectKey, thumb.Width, thumb.ContentType)
+		if err := s.store.Put(ctx, key, bytes.NewReader(thumb.Data), int64(len(thumb.Data)), thumb.ContentType); err != nil {
+			log.Printf("Failed to store image variant %s: %v", key, err)
+			cleanup()
+			return nil, fmt.Errorf("failed to store image variant: %w", err)
+		}
+		stored = append(stored, key)
+
+		variants = append(variants, models.ImageVariant{
+			URL:         s.store.URL(key),
+			Width:       thumb.Width,
+			Height:      thumb.Height,
+			ContentType: thumb.ContentType,
+			Size:        int64(len(thumb.Data)),
+		})
+	}
+
+	image, err := s.imageRepo.AddImage(ctx, models.ProductImage{
+		ProductID:   productID,
+		ObjectKey:   objectKey,
+		URL:         s.store.URL(objectKey),
+		ContentType: contentType,
+		Size:        int64(len(data)),
+		Width:       dims.Width,
+		Height:      dims.Height,
+		Variants:    variants,
+		Position:    position,
+		IsPrimary:   primary,
+	})
+	if err != nil {
+		cleanup()
+		return nil, err
+	}
+	image.SrcSet = buildSrcSet(*image)
+
+	invalidateProductCache(ctx, s.cache, false, productID)
+
+	return image, nil
+}
+
+func (s *ProductImageService) SetPrimaryImage(ctx context.Context, productID, imageID int) error {
+	err := s.imageRepo.SetPrimaryImage(ctx, productID, imageID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return &ServiceError{Status: http.StatusNotFound, Message: "Image not found"}
+	}
+	if err != nil {
+		return err
+	}
+
+	invalidateProductCache(ctx, s.cache, false, productID)
+	return nil
+}
+
+func (s *ProductImageService) ReorderImages(ctx context.Context, productID int, imageIDs []int) ([]models.ProductImage, error) {
+	if err := s.ensureProduct(ctx, productID); err != nil {
+		return nil, err
+	}
+
+	err := s.imageRepo.ReorderImages(ctx, productID, imageIDs)
+	if errors.Is(err, repository.ErrImageSetMismatch) {
+		return nil, &ServiceError{Status: http.StatusBadRequest, Message: err.Error()}
+	}
+	if err != nil {
+		return nil, err
+	}
+	invalidateProductCache(ctx, s.cache, false, productID)
+
+	images, err := s.imageRepo.GetImagesByProductID(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range images {
+		images[i].SrcSet = buildSrcSet(images[i])
+	}
+	return images, nil
+}
+
+func (s *ProductImageService) DeleteImage(ctx context.Context, productID, imageID int) (*models.ProductImage, error) {
+	image, err := s.imageRepo.DeleteImage(ctx, productID, imageID)
+	if err != nil {
+		return nil, err
+	}
+	if image == nil {
+		return nil, &ServiceError{Status: http.StatusNotFound, Message: "Image not found"}
+	}
+
+	for _, key := range imageObjectKeys(*image) {
+		if err := s.store.Delete(ctx, key); err != nil {
+			log.Printf("Failed to delete stored image %s: %v", key, err)
+		}
+	}
+
+	invalidateProductCache(ctx, s.cache, false, productID)
+
+	return image, nil
+}
+
+// ensureProduct checks that an admin can manage the product's images, whatever its status
+func (s *ProductImageService) ensureProduct(ctx context.Context, productID int) error {
+	product, err := s.productRepo.GetAnyProductByID(ctx, productID)
+	if err != nil {
+		return err
+	}
+	if product == nil {
+		return &ServiceError{Status: http.StatusNotFound, Message: "Product not found"}
+	}
+	return nil
+}
+
+// variantObjectKey derives the blob key of a thumbnail from its original, e.g.
+// products/1/ab12.png -> products/1/ab12_320w.webp
+func variantObjectKey(objectKey string, width int, contentType string) string {
+	base := strings.TrimSuffix(objectKey, path.Ext(objectKey))
+	return fmt.Sprintf("%s_%dw%s", base, width, allowedImageTypes[contentType])
+}
+
+// imageObjectKeys lists the blob keys of an image and all of its thumbnails
+func imageObjectKeys(image models.ProductImage) []string {
+	keys := []string{image.ObjectKey}
+	for _, v := range image.Variants {
+		keys = append(keys, variantObjectKey(image.ObjectKey, v.Width, v.ContentType))
+	}
+	return keys
+}
+
+// buildSrcSet groups an image and its thumbnails by content type into srcset strings
+func buildSrcSet(image models.ProductImage) map[string]string {
+	candidates := make(map[string][]string)
+	for _, v := range image.Variants {
+		candidates[v.ContentType] = append(candidates[v.ContentType], fmt.Sprintf("%s %dw", v.URL, v.Width))
+	}
+	if image.Width > 0 {
+		candidates[image.ContentType] = append(candidates[image.ContentType], fmt.Sprintf("%s %dw", image.URL, image.Width))
+	}
+
+	srcSet := make(map[string]string, len(candidates))
+	for contentType, entries := range candidates {
+		srcSet[contentType] = strings.Join(entries, ", ")
+	}
+	return srcSet
+}
+
+// newObjectKey builds a random, collision-resistant blob key under prefix
+func newObjectKey(prefix, ext string) (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate object key: %w", err)
+	}
+	return prefix + "/" + hex.EncodeToString(buf) + ext, nil
+}
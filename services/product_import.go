@@ -0,0 +1,259 @@
+package services
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/your-username/golang-ecommerce-app/models"
+)
+
+const (
+	ProductFormatCSV   = "csv"
+	ProductFormatJSONL = "jsonl"
+
+	// maxImportErrors caps the per-row errors returned in an import report
+	maxImportErrors = 1000
+	// exportFlushEvery is how many rows are written between flushes to the client
+	exportFlushEvery = 100
+)
+
+var productCSVHeader = []string{"id", "sku", "name", "description", "category", "image", "price", "status", "createdAt"}
+
+// ImportProducts reads products row by row from r in the given format and upserts
+// each valid row by SKU. Invalid rows are skipped and reported; they do not abort the import.
+func (s *ProductService) ImportProducts(ctx context.Context, format string, r io.Reader) (*models.ProductImportReport, error) {
+	report := &models.ProductImportReport{Errors: []models.ProductImportError{}}
+	var savedIDs []int
+
+	handle := func(row int, product models.Product, parseErr error) {
+		report.Processed++
+		if parseErr == nil {
+			parseErr = validateImportedProduct(product)
+		}
+		if parseErr == nil {
+			saved, created, err := s.productRepo.UpsertProductBySKU(ctx, product)
+			if err == nil {
+				savedIDs = append(savedIDs, saved.ProductID)
+				if created {
+					report.Created++
+				} else {
+					report.Updated++
+				}
+				return
+			}
+			parseErr = errors.New("failed to save product")
+		}
+
+		report.Failed++
+		if len(report.Errors) < maxImportErrors {
+			report.Errors = append(report.Errors, models.ProductImportError{
+				Row:   row,
+				SKU:   product.SKU,
+				Error: parseErr.Error(),
+			})
+		}
+	}
+
+	var err error
+	switch format {
+	case ProductFormatCSV:
+		err = readProductCSV(r, handle)
+	case ProductFormatJSONL:
+		err = readProductJSONL(r, handle)
+	default:
+		return nil, &ServiceError{Status: http.StatusBadRequest, Message: "Unsupported import format: " + format}
+	}
+
+	if len(savedIDs) > 0 {
+		invalidateProductCache(ctx, s.cache, true, savedIDs...)
+	}
+
+	return report, err
+}
+
+// ExportProducts streams the full catalog to w in the given format
+func (s *ProductService) ExportProducts(ctx context.Context, format string, w io.Writer) error {
+	flusher, _ := w.(http.Flusher)
+
+	switch format {
+	case ProductFormatCSV:
+		cw := csv.NewWriter(w)
+		if err := cw.Write(productCSVHeader); err != nil {
+			return err
+		}
+		count := 0
+		err := s.productRepo.StreamProducts(ctx, func(p models.Product) error {
+			if err := cw.Write([]string{
+				strconv.Itoa(p.ProductID),
+				p.SKU,
+				p.Name,
+				p.Description,
+				p.Category,
+				p.Image,
+				strconv.FormatFloat(p.Price, 'f', -1, 64),
+				p.Status,
+				p.CreatedAt.UTC().Format(time.RFC3339),
+			}); err != nil {
+				return err
+			}
+			if count++; count%exportFlushEvery == 0 {
+				cw.Flush()
+				if flusher != nil {
+					flusher.Flush()
+				}
+			}
+			return cw.Error()
+		})
+		cw.Flush()
+		if err != nil {
+			return err
+		}
+		return cw.Error()
+
+	case ProductFormatJSONL:
+		enc := json.NewEncoder(w)
+		count := 0
+		return s.productRepo.StreamProducts(ctx, func(p models.Product) error {
+			if err := enc.Encode(p); err != nil {
+				return err
+			}
+			if count++; count%exportFlushEvery == 0 && flusher != nil {
+				flusher.Flush()
+			}
+			return nil
+		})
+
+	default:
+		return &ServiceError{Status: http.StatusBadRequest, Message: "Unsupported export format: " + format}
+	}
+}
+
+func validateImportedProduct(p models.Product) error {
+	switch {
+	case p.SKU == "":
+		return errors.New("sku is required")
+	case len(p.SKU) > 64:
+		return errors.New("sku must be at most 64 characters")
+	case len(p.Category) > 100:
+		return errors.New("category must be at most 100 characters")
+	case p.Name == "":
+		return errors.New("name is required")
+	case p.Price <= 0:
+		return errors.New("price must be positive")
+	case p.Status == models.ProductStatusScheduled:
+		return errors.New("products cannot be scheduled by import")
+	case p.Status != "" && !isProductStatus(p.Status):
+		return errors.New("status must be draft, published or archived")
+	}
+	return nil
+}
+
+// readProductCSV parses a CSV file whose header row names the columns. Columns
+// other than sku, name, description, category, image, price and status (e.g. id
+// from an export) are ignored.
+func readProductCSV(r io.Reader, handle func(row int, p models.Product, err error)) error {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+
+	header, err := cr.Read()
+	if err == io.EOF {
+		return &ServiceError{Status: http.StatusBadRequest, Message: "CSV file is empty"}
+	}
+	if err != nil {
+		return &ServiceError{Status: http.StatusBadRequest, Message: "Invalid CSV header: " + err.Error()}
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, required := range []string{"sku", "name", "price"} {
+		if _, ok := columns[required]; !ok {
+			return &ServiceError{Status: http.StatusBadRequest, Message: "CSV header is missing column: " + required}
+		}
+	}
+
+	field := func(record []string, name string) string {
+		if i, ok := columns[name]; ok && i < len(record) {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+
+	// Row numbers are 1-based and count the header, so they match a spreadsheet view
+	for row := 2; ; row++ {
+		record, err := cr.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			var parseErr *csv.ParseError
+			if errors.As(err, &parseErr) {
+				handle(row, models.Product{}, fmt.Errorf("invalid CSV row: %v", parseErr.Err))
+				continue
+			}
+			return err
+		}
+
+		product := models.Product{
+			SKU:         field(record, "sku"),
+			Name:        field(record, "name"),
+			Description: field(record, "description"),
+			Category:    field(record, "category"),
+			Image:       field(record, "image"),
+			Status:      strings.ToLower(field(record, "status")),
+		}
+		price, err := strconv.ParseFloat(field(record, "price"), 64)
+		if err != nil {
+			handle(row, product, errors.New("price must be a number"))
+			continue
+		}
+		product.Price = price
+
+		handle(row, product, nil)
+	}
+}
+
+// readProductJSONL parses one JSON product object per line. Blank lines are skipped.
+func readProductJSONL(r io.Reader, handle func(row int, p models.Product, err error)) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1<<20)
+
+	for row := 1; scanner.Scan(); row++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		var product models.Product
+		if err := json.Unmarshal([]byte(line), &product); err != nil {
+			handle(row, product, fmt.Errorf("invalid JSON: %v", err))
+			continue
+		}
+		product.SKU = strings.TrimSpace(product.SKU)
+		product.Name = strings.TrimSpace(product.Name)
+		product.Category = strings.TrimSpace(product.Category)
+		product.Status = strings.ToLower(strings.TrimSpace(product.Status))
+		product.PublishAt = nil
+
+		handle(row, product, nil)
+	}
+
+	if err := scanner.Err(); err != nil {
+		if errors.Is(err, bufio.ErrTooLong) {
+			return &ServiceError{Status: http.StatusBadRequest, Message: "JSON Lines row exceeds 1MB"}
+		}
+		return err
+	}
+	return nil
+}
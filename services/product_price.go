@@ -0,0 +1,142 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/your-username/golang-ecommerce-app/models"
+	"github.com/your-username/golang-ecommerce-app/repository"
+	"github.com/your-username/golang-ecommerce-app/utils"
+)
+
+type ProductPriceService struct {
+	productRepo *repository.ProductRepository
+	priceRepo   *repository.ProductPriceRepository
+	cache       utils.CacheProvider
+}
+
+func NewProductPriceService(
+	productRepo *repository.ProductRepository,
+	priceRepo *repository.ProductPriceRepository,
+	cache utils.CacheProvider,
+) *ProductPriceService {
+	return &ProductPriceService{
+		productRepo: productRepo,
+		priceRepo:   priceRepo,
+		cache:       cache,
+	}
+}
+
+func (s *ProductPriceService) GetPriceHistory(ctx context.Context, productID int) ([]models.ProductPrice, error) {
+	if _, err := s.ensureProduct(ctx, productID); err != nil {
+		return nil, err
+	}
+	return s.priceRepo.GetPriceHistory(ctx, productID)
+}
+
+// SchedulePrice adds a regular or sale price to a product. A missing or past
+// effectiveFrom means now, in which case the price is applied immediately.
+// Sale prices need an end date and must undercut the current regular price.
+func (s *ProductPriceService) SchedulePrice(ctx context.Context, productID int, price models.ProductPrice) (*models.ProductPrice, error) {
+	product, err := s.ensureProduct(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if price.EffectiveFrom.IsZero() || price.EffectiveFrom.Before(now) {
+		price.EffectiveFrom = now
+	}
+	price.ProductID = productID
+
+	switch {
+	case price.Price <= 0:
+		return nil, &ServiceError{Status: http.StatusBadRequest, Message: "price must be positive"}
+	case price.Kind == models.PriceKindRegular:
+		if price.EffectiveTo != nil {
+			return nil, &ServiceError{Status: http.StatusBadRequest, Message: "regular prices run until replaced and cannot have effectiveTo"}
+		}
+	case price.Kind == models.PriceKindSale:
+		if price.EffectiveTo == nil || !price.EffectiveTo.After(price.EffectiveFrom) {
+			return nil, &ServiceError{Status: http.StatusBadRequest, Message: "sale prices need an effectiveTo after effectiveFrom"}
+		}
+		if price.Price >= product.Price {
+			return nil, &ServiceError{Status: http.StatusBadRequest, Message: "sale price must be lower than the regular price"}
+		}
+	default:
+		return nil, &ServiceError{Status: http.StatusBadRequest, Message: "kind must be regular or sale"}
+	}
+
+	created, err := s.priceRepo.AddPrice(ctx, price)
+	if err != nil {
+		return nil, err
+	}
+
+	if !created.EffectiveFrom.After(now) {
+		if _, err := s.ApplyDuePrices(ctx, now); err != nil {
+			return nil, err
+		}
+		return s.priceRepo.GetPriceByID(ctx, productID, created.PriceID)
+	}
+
+	return created, nil
+}
+
+// CancelPrice withdraws a pending price or ends a running sale now
+func (s *ProductPriceService) CancelPrice(ctx context.Context, productID, priceID int) (*models.ProductPrice, error) {
+	now := time.Now()
+	price, err := s.priceRepo.CancelPrice(ctx, productID, priceID, now)
+	if errors.Is(err, repository.ErrPriceNotCancellable) {
+		return nil, &ServiceError{Status: http.StatusConflict, Message: err.Error()}
+	}
+	if err != nil {
+		return nil, err
+	}
+	if price == nil {
+		return nil, &ServiceError{Status: http.StatusNotFound, Message: "Price not found"}
+	}
+
+	if price.Kind == models.PriceKindSale {
+		if _, err := s.ApplyDuePrices(ctx, now); err != nil {
+			return nil, err
+		}
+	}
+
+	return price, nil
+}
+
+// ApplyDuePrices brings products in line with their price schedule: due regular
+// prices are applied and sale prices started or ended. It returns how many
+// products changed.
+func (s *ProductPriceService) ApplyDuePrices(ctx context.Context, now time.Time) (int, error) {
+	regular, err := s.priceRepo.ApplyDueRegularPrices(ctx, now)
+	if err != nil {
+		return 0, fmt.Errorf("failed to apply scheduled prices: %w", err)
+	}
+
+	sales, err := s.priceRepo.RefreshSalePrices(ctx, now)
+	if err != nil {
+		return 0, fmt.Errorf("failed to refresh sale prices: %w", err)
+	}
+
+	changed := len(regular) + len(sales)
+	if changed > 0 {
+		invalidateProductCache(ctx, s.cache, false, append(regular, sales...)...)
+	}
+
+	return changed, nil
+}
+
+func (s *ProductPriceService) ensureProduct(ctx context.Context, productID int) (*models.Product, error) {
+	product, err := s.productRepo.GetAnyProductByID(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+	if product == nil {
+		return nil, &ServiceError{Status: http.StatusNotFound, Message: "Product not found"}
+	}
+	return product, nil
+}
@@ -0,0 +1,62 @@
+package services
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// ProductScheduler periodically publishes scheduled products whose publishAt has
+// passed and applies scheduled price changes
+type ProductScheduler struct {
+	productService *ProductService
+	priceService   *ProductPriceService
+	interval       time.Duration
+}
+
+func NewProductScheduler(productService *ProductService, priceService *ProductPriceService, interval time.Duration) *ProductScheduler {
+	return &ProductScheduler{productService: productService, priceService: priceService, interval: interval}
+}
+
+// Run processes due work once immediately and then on every tick until ctx is cancelled
+func (s *ProductScheduler) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		s.publishDue(ctx)
+		s.applyDuePrices(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *ProductScheduler) publishDue(ctx context.Context) {
+	ids, err := s.productService.PublishDueProducts(ctx, time.Now())
+	if err != nil {
+		if ctx.Err() == nil {
+			log.Printf("Product scheduler: failed to publish due products: %v", err)
+		}
+		return
+	}
+	if len(ids) > 0 {
+		log.Printf("Product scheduler: published products %v", ids)
+	}
+}
+
+func (s *ProductScheduler) applyDuePrices(ctx context.Context) {
+	changed, err := s.priceService.ApplyDuePrices(ctx, time.Now())
+	if err != nil {
+		if ctx.Err() == nil {
+			log.Printf("Product scheduler: failed to apply due prices: %v", err)
+		}
+		return
+	}
+	if changed > 0 {
+		log.Printf("Product scheduler: updated prices of %d products", changed)
+	}
+}
@@ -0,0 +1,221 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"net/mail"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/your-username/golang-ecommerce-app/models"
+	"github.com/your-username/golang-ecommerce-app/repository"
+	"github.com/your-username/golang-ecommerce-app/utils"
+)
+
+const maxDisplayNameLength = 100
+
+var phonePattern = regexp.MustCompile(`^\+?[0-9][0-9 ()-]{5,30}$`)
+
+// ProfileUpdate holds the fields a user may change on their own account; nil
+// fields are left as they are. CurrentPassword is only needed to change Email.
+type ProfileUpdate struct {
+	Email           *string `json:"email"`
+	DisplayName     *string `json:"displayName"`
+	Phone           *string `json:"phone"`
+	CurrentPassword string  `json:"currentPassword"`
+}
+
+// ProfileService lets logged-in users manage their own account
+type ProfileService struct {
+	userRepo            *repository.UserRepository
+	sessionService      *SessionService
+	verificationService *EmailVerificationService
+}
+
+func NewProfileService(
+	userRepo *repository.UserRepository,
+	sessionService *SessionService,
+	verificationService *EmailVerificationService,
+) *ProfileService {
+	return &ProfileService{
+		userRepo:            userRepo,
+		sessionService:      sessionService,
+		verificationService: verificationService,
+	}
+}
+
+func (s *ProfileService) GetProfile(ctx context.Context, userID string) (*models.Profile, error) {
+	user, err := s.findUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	profile := user.Profile()
+	return &profile, nil
+}
+
+// UpdateProfile applies the given changes. Changing the email address takes
+// the current password; the new address has to be verified again, and the old
+// one is told about the change.
+func (s *ProfileService) UpdateProfile(ctx context.Context, userID string, update ProfileUpdate) (*models.Profile, error) {
+	user, err := s.findUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	updates := map[string]interface{}{}
+
+	if update.Email != nil {
+		email := strings.TrimSpace(*update.Email)
+		if addr, err := mail.ParseAddress(email); err != nil || addr.Address != email {
+			return nil, &ServiceError{Status: http.StatusBadRequest, Message: "Invalid email format"}
+		}
+		if email != user.Email {
+			if update.CurrentPassword == "" {
+				return nil, &ServiceError{Status: http.StatusBadRequest, Message: "currentPassword is required to change email"}
+			}
+			if !utils.ComparePasswords(update.CurrentPassword, user.Password) {
+				return nil, &ServiceError{Status: http.StatusUnauthorized, Message: "Current password is incorrect"}
+			}
+			existingUser, err := s.userRepo.FindByEmail(ctx, email)
+			if err != nil {
+				return nil, err
+			}
+			if existingUser != nil {
+				return nil, &ServiceError{Status: http.StatusConflict, Message: "Email already in use"}
+			}
+			updates["email"] = email
+		}
+	}
+
+	if update.DisplayName != nil {
+		displayName := strings.TrimSpace(*update.DisplayName)
+		if utf8.RuneCountInString(displayName) > maxDisplayNameLength {
+			return nil, &ServiceError{Status: http.StatusBadRequest, Message: "displayName must be at most 100 characters"}
+		}
+		updates["displayName"] = displayName
+	}
+
+	if update.Phone != nil {
+		phone := strings.TrimSpace(*update.Phone)
+		if phone != "" && !phonePattern.MatchString(phone) {
+			return nil, &ServiceError{Status: http.StatusBadRequest, Message: "Invalid phone number"}
+		}
+		updates["phone"] = phone
+	}
+
+	if len(updates) == 0 {
+		profile := user.Profile()
+		return &profile, nil
+	}
+
+	updatedUser, err := s.userRepo.UpdateUser(ctx, userID, updates)
+	if err != nil {
+		// Another account took the address after the check above
+		if errors.Is(err, repository.ErrEmailTaken) {
+			return nil, &ServiceError{Status: http.StatusConflict, Message: "Email already in use"}
+		}
+		return nil, err
+	}
+	if updatedUser == nil {
+		return nil, &ServiceError{Status: http.StatusNotFound, Message: "User not found"}
+	}
+
+	if _, ok := updates["email"]; ok {
+		go func(ctx context.Context, previousEmail string) {
+			if err := s.verificationService.SendVerification(ctx, updatedUser); err != nil {
+				log.Printf("Failed to send verification email to user %s: %v", userID, err)
+			}
+			if err := s.verificationService.SendEmailChangedNotice(ctx, updatedUser, previousEmail); err != nil {
+				log.Printf("Failed to notify previous email of user %s: %v", userID, err)
+			}
+		}(context.WithoutCancel(ctx), user.Email)
+	}
+
+	profile := updatedUser.Profile()
+	return &profile, nil
+}
+
+// ChangePassword replaces the password after checking the current one. Every
+// other session is signed out; the one making the change stays logged in.
+func (s *ProfileService) ChangePassword(ctx context.Context, userID, sessionID, currentPassword, newPassword string) error {
+	if currentPassword == "" || newPassword == "" {
+		return &ServiceError{Status: http.StatusBadRequest, Message: "currentPassword and newPassword are required"}
+	}
+
+	user, err := s.findUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if !utils.ComparePasswords(currentPassword, user.Password) {
+		return &ServiceError{Status: http.StatusUnauthorized, Message: "Current password is incorrect"}
+	}
+	if currentPassword == newPassword {
+		return &ServiceError{Status: http.StatusBadRequest, Message: "New password must differ from the current one"}
+	}
+
+	hashedPassword, err := utils.HashPassword(newPassword)
+	if err != nil {
+		log.Println("Hashing error:", err)
+		return err
+	}
+	sessionIDs, err := s.userRepo.ChangePassword(ctx, userID, hashedPassword, sessionID)
+	if err != nil {
+		return err
+	}
+	s.sessionService.forget(ctx, sessionIDs...)
+
+	go utils.LogEventToProducer("Password Changed", userID, map[string]interface{}{
+		"Timestamp": time.Now().UTC().Format(time.RFC3339),
+		"Action":    "password_change",
+		"userId":    userID,
+	})
+	return nil
+}
+
+// CloseAccount deletes the user's own account once they confirm their password.
+// Superadmin accounts cannot be closed this way.
+func (s *ProfileService) CloseAccount(ctx context.Context, userID, password string) error {
+	if password == "" {
+		return &ServiceError{Status: http.StatusBadRequest, Message: "password is required"}
+	}
+
+	user, err := s.findUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if !utils.ComparePasswords(password, user.Password) {
+		return &ServiceError{Status: http.StatusUnauthorized, Message: "Password is incorrect"}
+	}
+	if user.Role == "superadmin" {
+		return &ServiceError{Status: http.StatusForbidden, Message: "Superadmin accounts cannot be closed"}
+	}
+
+	_, sessionIDs, err := s.userRepo.DeleteUserAndSessions(ctx, userID)
+	if err != nil {
+		return err
+	}
+	s.sessionService.forget(ctx, sessionIDs...)
+
+	go utils.LogEventToProducer("User Account Closed", userID, map[string]interface{}{
+		"Timestamp": time.Now().UTC().Format(time.RFC3339),
+		"Email":     user.Email,
+		"Action":    "account_close",
+		"userId":    userID,
+	})
+	return nil
+}
+
+func (s *ProfileService) findUser(ctx context.Context, userID string) (*models.User, error) {
+	user, err := s.userRepo.FindByuserId(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, &ServiceError{Status: http.StatusNotFound, Message: "User not found"}
+	}
+	return user, nil
+}
@@ -0,0 +1,135 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/your-username/golang-ecommerce-app/models"
+	"github.com/your-username/golang-ecommerce-app/repository"
+	"github.com/your-username/golang-ecommerce-app/utils"
+)
+
+// recentlyViewedTTL is how long an idle user's list stays in the cache. Postgres
+// keeps the list after that, and the next read warms the cache again.
+const recentlyViewedTTL = 30 * 24 * time.Hour
+
+// recentlyViewedWarmGuard is how long a view recorded while the list was not
+// cached stops the cache being warmed, so a warm that read Postgres before
+// the view cannot store a list without it
+const recentlyViewedWarmGuard = 10 * time.Second
+
+type RecentlyViewedService struct {
+	viewRepo    *repository.RecentlyViewedRepository
+	productRepo *repository.ProductRepository
+	cache       utils.CacheProvider
+	maxItems    int
+}
+
+func NewRecentlyViewedService(
+	viewRepo *repository.RecentlyViewedRepository,
+	productRepo *repository.ProductRepository,
+	cache utils.CacheProvider,
+	maxItems int,
+) *RecentlyViewedService {
+	return &RecentlyViewedService{
+		viewRepo:    viewRepo,
+		productRepo: productRepo,
+		cache:       cache,
+		maxItems:    maxItems,
+	}
+}
+
+// RecordView adds a product to the front of the user's recently viewed list.
+// Postgres is written first; the cached list is only updated when it is already
+// warm, so a cold cache is never seeded with a partial history, and a view that
+// finds it cold briefly holds off warming it.
+func (s *RecentlyViewedService) RecordView(ctx context.Context, userID string, productID int) {
+	if err := s.viewRepo.RecordView(ctx, userID, productID, s.maxItems); err != nil {
+		log.Printf("Failed to record view of product %d by user %s: %v", productID, userID, err)
+		return
+	}
+
+	key := recentlyViewedKey(userID)
+	pushed, err := s.cache.ListPushCappedIfExists(ctx, key, strconv.Itoa(productID), int64(s.maxItems), recentlyViewedTTL)
+	if err != nil {
+		log.Printf("Failed to cache view of product %d by user %s: %v", productID, userID, err)
+		// A list that missed an update would be served stale until it expired
+		if delErr := s.cache.Delete(ctx, key); delErr != nil {
+			log.Printf("Failed to drop recently viewed cache for user %s: %v", userID, delErr)
+		}
+		return
+	}
+	if !pushed {
+		if err := s.cache.Set(ctx, recentlyViewedPendingKey(userID), "1", recentlyViewedWarmGuard); err != nil {
+			log.Printf("Failed to hold off recently viewed cache warm for user %s: %v", userID, err)
+		}
+	}
+}
+
+// GetRecentlyViewed returns the user's recently viewed products, newest first.
+// Products that have since been unpublished or deleted are left out.
+func (s *RecentlyViewedService) GetRecentlyViewed(ctx context.Context, userID string) ([]models.Product, error) {
+	ids, err := s.recentlyViewedIDs(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if len(ids) == 0 {
+		return []models.Product{}, nil
+	}
+
+	products, err := s.productRepo.GetProductsByIDs(ctx, ids)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get recently viewed products: %w", err)
+	}
+	return products, nil
+}
+
+// recentlyViewedIDs reads the list from the cache, falling back to Postgres when
+// the cache is cold or unavailable
+func (s *RecentlyViewedService) recentlyViewedIDs(ctx context.Context, userID string) ([]int, error) {
+	key := recentlyViewedKey(userID)
+
+	cached, err := s.cache.ListRange(ctx, key, 0, int64(s.maxItems)-1)
+	if err == nil && len(cached) > 0 {
+		ids := make([]int, 0, len(cached))
+		for _, v := range cached {
+			if id, err := strconv.Atoi(v); err == nil {
+				ids = append(ids, id)
+			}
+		}
+		return ids, nil
+	}
+	if err != nil {
+		log.Printf("Recently viewed cache unavailable for user %s, using database: %v", userID, err)
+	}
+
+	ids, err := s.viewRepo.GetRecentlyViewedIDs(ctx, userID, s.maxItems)
+	if err != nil {
+		return nil, err
+	}
+
+	// Warm the cache in one step, unless a view was recorded meanwhile
+	if len(ids) > 0 {
+		values := make([]string, len(ids))
+		for i, id := range ids {
+			values[i] = strconv.Itoa(id)
+		}
+		if _, err := s.cache.ListFill(ctx, key, values, recentlyViewedTTL, recentlyViewedPendingKey(userID)); err != nil {
+			log.Printf("Failed to warm recently viewed cache for user %s: %v", userID, err)
+		}
+	}
+
+	return ids, nil
+}
+
+func recentlyViewedKey(userID string) string {
+	return "recently-viewed:" + userID
+}
+
+// recentlyViewedPendingKey marks a view recorded while the user's list was not cached
+func recentlyViewedPendingKey(userID string) string {
+	return "recently-viewed-pending:" + userID
+}
@@ -0,0 +1,150 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/your-username/golang-ecommerce-app/models"
+	"github.com/your-username/golang-ecommerce-app/repository"
+	"github.com/your-username/golang-ecommerce-app/utils"
+)
+
+const (
+	RelatedSourcePurchases = "purchases"
+	RelatedSourceCategory  = "category"
+
+	maxRelatedProducts = 20
+)
+
+type RelatedProductService struct {
+	relatedRepo *repository.RelatedProductRepository
+	productRepo *repository.ProductRepository
+	cache       utils.CacheProvider
+	loader      *utils.CacheLoader
+}
+
+func NewRelatedProductService(
+	relatedRepo *repository.RelatedProductRepository,
+	productRepo *repository.ProductRepository,
+	cache utils.CacheProvider,
+	loader *utils.CacheLoader,
+) *RelatedProductService {
+	return &RelatedProductService{
+		relatedRepo: relatedRepo,
+		productRepo: productRepo,
+		cache:       cache,
+		loader:      loader,
+	}
+}
+
+// GetRelatedProducts returns the products most often bought together with a
+// published product, falling back to the same category when it has no purchase history
+func (s *RelatedProductService) GetRelatedProducts(ctx context.Context, productID, limit int) (*models.RelatedProductsResponse, error) {
+	if limit <= 0 {
+		limit = defaultLimit
+	}
+	if limit > maxRelatedProducts {
+		limit = maxRelatedProducts
+	}
+
+	cacheKey := fmt.Sprintf("products:related:%d:%d", productID, limit)
+	data, err := s.loader.Load(ctx, cacheKey, func(ctx context.Context) (string, []string, error) {
+		product, err := s.productRepo.GetProductByID(ctx, productID)
+		if err != nil {
+			return "", nil, err
+		}
+		if product == nil {
+			return "", nil, &ServiceError{Status: http.StatusNotFound, Message: "Product not found"}
+		}
+
+		response := models.RelatedProductsResponse{Source: RelatedSourcePurchases}
+		response.Products, err = s.relatedRepo.GetRelatedProducts(ctx, productID, limit)
+		if err != nil {
+			return "", nil, fmt.Errorf("failed to get related products: %w", err)
+		}
+
+		if len(response.Products) == 0 && product.Category != "" {
+			response.Source = RelatedSourceCategory
+			response.Products, err = s.relatedRepo.GetProductsInCategory(ctx, product.Category, productID, limit)
+			if err != nil {
+				return "", nil, fmt.Errorf("failed to get products in category: %w", err)
+			}
+		}
+
+		jsonData, err := json.Marshal(response)
+		if err != nil {
+			return "", nil, fmt.Errorf("failed to marshal related products: %w", err)
+		}
+
+		tags := []string{relatedProductTag, productTag(productID)}
+		for _, p := range response.Products {
+			tags = append(tags, productTag(p.ProductID))
+		}
+		return string(jsonData), tags, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var response models.RelatedProductsResponse
+	if err := json.Unmarshal([]byte(data), &response); err != nil {
+		return nil, fmt.Errorf("failed to decode related products: %w", err)
+	}
+	return &response, nil
+}
+
+// RebuildRelatedProducts recomputes co-purchase recommendations, keeping topN per product
+func (s *RelatedProductService) RebuildRelatedProducts(ctx context.Context, topN int) (int64, error) {
+	pairs, err := s.relatedRepo.RebuildRelatedProducts(ctx, topN)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := s.cache.InvalidateTags(ctx, relatedProductTag); err != nil {
+		log.Printf("Failed to invalidate related products cache: %v", err)
+	}
+
+	return pairs, nil
+}
+
+// RelatedProductsJob periodically rebuilds co-purchase recommendations
+type RelatedProductsJob struct {
+	relatedService *RelatedProductService
+	interval       time.Duration
+	topN           int
+}
+
+func NewRelatedProductsJob(relatedService *RelatedProductService, interval time.Duration, topN int) *RelatedProductsJob {
+	return &RelatedProductsJob{relatedService: relatedService, interval: interval, topN: topN}
+}
+
+// Run rebuilds once immediately and then on every tick until ctx is cancelled
+func (j *RelatedProductsJob) Run(ctx context.Context) {
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+
+	for {
+		start := time.Now()
+		pairs, err := j.relatedService.RebuildRelatedProducts(ctx, j.topN)
+		if errors.Is(err, repository.ErrRebuildInProgress) {
+			log.Printf("Related products job: skipped, another instance is rebuilding")
+		} else if err != nil {
+			if ctx.Err() == nil {
+				log.Printf("Related products job: rebuild failed: %v", err)
+			}
+		} else {
+			log.Printf("Related products job: stored %d pairs in %s", pairs, time.Since(start).Round(time.Millisecond))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
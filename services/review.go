@@ -0,0 +1,201 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/your-username/golang-ecommerce-app/models"
+	"github.com/your-username/golang-ecommerce-app/repository"
+	"github.com/your-username/golang-ecommerce-app/utils"
+)
+
+const (
+	maxReviewTitleLength = 200
+	maxReviewBodyLength  = 5000
+
+	// anonymousReviewer names reviewers who have not set a display name
+	anonymousReviewer = "Verified buyer"
+)
+
+type ReviewService struct {
+	reviewRepo  *repository.ReviewRepository
+	productRepo *repository.ProductRepository
+	cache       utils.CacheProvider
+}
+
+func NewReviewService(
+	reviewRepo *repository.ReviewRepository,
+	productRepo *repository.ProductRepository,
+	cache utils.CacheProvider,
+) *ReviewService {
+	return &ReviewService{
+		reviewRepo:  reviewRepo,
+		productRepo: productRepo,
+		cache:       cache,
+	}
+}
+
+// GetProductReviews returns a page of a published product's approved reviews
+// together with its rating aggregates
+func (s *ReviewService) GetProductReviews(ctx context.Context, productID, page, limit int) (*models.PaginatedReviewResponse, error) {
+	product, err := s.productRepo.GetProductByID(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+	if product == nil {
+		return nil, &ServiceError{Status: http.StatusNotFound, Message: "Product not found"}
+	}
+
+	if page <= 0 {
+		page = defaultPage
+	}
+	if limit <= 0 {
+		limit = defaultLimit
+	}
+
+	reviews, total, err := s.reviewRepo.GetReviewsByProduct(ctx, productID, models.ReviewStatusApproved, limit, (page-1)*limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get reviews: %w", err)
+	}
+
+	// Moderation details, the verifying order and the reviewer's user ID, which
+	// is also their login name, are for admins only
+	for i := range reviews {
+		if reviews[i].AuthorName == "" {
+			reviews[i].AuthorName = anonymousReviewer
+		}
+		reviews[i].UserID = ""
+		reviews[i].OrderID = 0
+		reviews[i].Status = ""
+		reviews[i].ModeratedBy = ""
+		reviews[i].ModeratedAt = nil
+	}
+
+	return &models.PaginatedReviewResponse{
+		Reviews:       reviews,
+		Total:         total,
+		Page:          page,
+		Limit:         limit,
+		RatingAverage: product.RatingAverage,
+		RatingCount:   product.RatingCount,
+	}, nil
+}
+
+// CreateReview records a review by a user who has ordered the product. The
+// review stays pending until a moderator approves it.
+func (s *ReviewService) CreateReview(ctx context.Context, userID string, productID int, review models.Review) (*models.Review, error) {
+	review.Title = strings.TrimSpace(review.Title)
+	review.Body = strings.TrimSpace(review.Body)
+
+	switch {
+	case review.Rating < 1 || review.Rating > 5:
+		return nil, &ServiceError{Status: http.StatusBadRequest, Message: "rating must be between 1 and 5"}
+	case len(review.Title) > maxReviewTitleLength:
+		return nil, &ServiceError{Status: http.StatusBadRequest, Message: fmt.Sprintf("title must be at most %d characters", maxReviewTitleLength)}
+	case len(review.Body) > maxReviewBodyLength:
+		return nil, &ServiceError{Status: http.StatusBadRequest, Message: fmt.Sprintf("body must be at most %d characters", maxReviewBodyLength)}
+	}
+
+	product, err := s.productRepo.GetProductByID(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+	if product == nil {
+		return nil, &ServiceError{Status: http.StatusNotFound, Message: "Product not found"}
+	}
+
+	orderID, err := s.reviewRepo.FindPurchaseOrderID(ctx, userID, productID)
+	if err != nil {
+		return nil, err
+	}
+	if orderID == 0 {
+		return nil, &ServiceError{Status: http.StatusForbidden, Message: "Only customers who have ordered this product can review it"}
+	}
+
+	review.ProductID = productID
+	review.UserID = userID
+	review.OrderID = orderID
+
+	created, err := s.reviewRepo.CreateReview(ctx, review)
+	if errors.Is(err, repository.ErrDuplicateReview) {
+		return nil, &ServiceError{Status: http.StatusConflict, Message: err.Error()}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return created, nil
+}
+
+// GetReviewsForModeration lists reviews in a moderation state, pending by default
+func (s *ReviewService) GetReviewsForModeration(ctx context.Context, actor *models.Principal, status string, page, limit int) (*models.PaginatedReviewResponse, error) {
+	if err := requireModerator(actor); err != nil {
+		return nil, err
+	}
+	if status == "" {
+		status = models.ReviewStatusPending
+	}
+	if !isReviewStatus(status) {
+		return nil, &ServiceError{Status: http.StatusBadRequest, Message: "Invalid review status: " + status}
+	}
+	if page <= 0 {
+		page = defaultPage
+	}
+	if limit <= 0 {
+		limit = defaultLimit
+	}
+
+	reviews, total, err := s.reviewRepo.GetReviewsByStatus(ctx, status, limit, (page-1)*limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get reviews: %w", err)
+	}
+
+	return &models.PaginatedReviewResponse{
+		Reviews: reviews,
+		Total:   total,
+		Page:    page,
+		Limit:   limit,
+	}, nil
+}
+
+// ModerateReview approves, rejects or hides a review on behalf of actor and
+// updates the product's rating
+func (s *ReviewService) ModerateReview(ctx context.Context, actor *models.Principal, reviewID int, status string) (*models.Review, error) {
+	if err := requireModerator(actor); err != nil {
+		return nil, err
+	}
+	if status == models.ReviewStatusPending || !isReviewStatus(status) {
+		return nil, &ServiceError{Status: http.StatusBadRequest, Message: "status must be approved, rejected or hidden"}
+	}
+
+	review, err := s.reviewRepo.SetReviewStatus(ctx, reviewID, status, actor.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if review == nil {
+		return nil, &ServiceError{Status: http.StatusNotFound, Message: "Review not found"}
+	}
+
+	// Listings embed the rating aggregates
+	invalidateProductCache(ctx, s.cache, false, review.ProductID)
+
+	return review, nil
+}
+
+func requireModerator(actor *models.Principal) error {
+	if actor == nil || !actor.Can(models.PermReviewsModerate) {
+		return &ServiceError{Status: http.StatusForbidden, Message: "You do not have permission to moderate reviews"}
+	}
+	return nil
+}
+
+func isReviewStatus(status string) bool {
+	switch status {
+	case models.ReviewStatusPending, models.ReviewStatusApproved, models.ReviewStatusRejected, models.ReviewStatusHidden:
+		return true
+	}
+	return false
+}
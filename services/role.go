@@ -0,0 +1,222 @@
+package services
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/your-username/golang-ecommerce-app/models"
+	"github.com/your-username/golang-ecommerce-app/repository"
+)
+
+var roleNamePattern = regexp.MustCompile(`^[a-z][a-z0-9_-]{1,49}$`)
+
+// roleReloadRetry is how long a failed reload waits before trying again
+const roleReloadRetry = 5 * time.Second
+
+// RoleService manages roles and answers permission checks. Checks read an
+// in-memory copy of every role's permissions that is reloaded once it is older
+// than the refresh interval, so requests never wait on the database and an
+// edit reaches every instance within that interval.
+type RoleService struct {
+	roleRepo *repository.RoleRepository
+	refresh  time.Duration
+
+	mu       sync.RWMutex
+	grants   map[string]map[string]bool
+	loadedAt time.Time
+	// loading keeps concurrent checks from reloading at the same time
+	loading sync.Mutex
+}
+
+func NewRoleService(roleRepo *repository.RoleRepository, refresh time.Duration) *RoleService {
+	return &RoleService{roleRepo: roleRepo, refresh: refresh}
+}
+
+// PermissionsFor lists the permissions granted by any of roles
+func (s *RoleService) PermissionsFor(ctx context.Context, roles []string) []string {
+	grants := s.snapshot(ctx)
+	seen := map[string]bool{}
+	permissions := []string{}
+	for _, role := range roles {
+		for permission := range grants[role] {
+			if !seen[permission] {
+				seen[permission] = true
+				permissions = append(permissions, permission)
+			}
+		}
+	}
+	sort.Strings(permissions)
+	return permissions
+}
+
+// RoleExists reports whether name is a defined role
+func (s *RoleService) RoleExists(ctx context.Context, name string) (bool, error) {
+	role, err := s.roleRepo.GetRole(ctx, name)
+	if err != nil {
+		return false, err
+	}
+	return role != nil, nil
+}
+
+// CheckAssignable refuses to let actor hand out the role name. It must exist,
+// must not be superadmin, which is never handed out through the API, and must
+// grant nothing the actor does not hold.
+func (s *RoleService) CheckAssignable(ctx context.Context, actor *models.Principal, name string) error {
+	role, err := s.roleRepo.GetRole(ctx, name)
+	if err != nil {
+		return err
+	}
+	if role == nil || role.Name == "superadmin" {
+		return &ServiceError{Status: http.StatusBadRequest, Message: "Invalid role"}
+	}
+	if !holdsAll(actor, role.Permissions) {
+		return &ServiceError{Status: http.StatusForbidden, Message: "You cannot assign a role with permissions you do not hold"}
+	}
+	return nil
+}
+
+func (s *RoleService) GetRoles(ctx context.Context) ([]models.Role, error) {
+	return s.roleRepo.GetRoles(ctx)
+}
+
+// SaveRole creates a role or replaces its permissions. The superadmin role
+// always has every permission and cannot be edited, so there is always a way
+// back in. Actors can only grant permissions they hold themselves, and only
+// edit roles whose permissions they hold, so "*" stays with holders of "*".
+func (s *RoleService) SaveRole(ctx context.Context, actor *models.Principal, role models.Role) (*models.Role, error) {
+	if !roleNamePattern.MatchString(role.Name) {
+		return nil, &ServiceError{Status: http.StatusBadRequest, Message: "Role names are 2-50 lowercase letters, digits, '-' or '_'"}
+	}
+	if role.Name == "superadmin" {
+		return nil, &ServiceError{Status: http.StatusForbidden, Message: "The superadmin role cannot be edited"}
+	}
+
+	seen := map[string]bool{}
+	permissions := []string{}
+	for _, permission := range role.Permissions {
+		if !models.IsPermission(permission) {
+			return nil, &ServiceError{Status: http.StatusBadRequest, Message: "Unknown permission: " + permission}
+		}
+		if !actor.Can(permission) {
+			return nil, &ServiceError{Status: http.StatusForbidden, Message: "You cannot grant a permission you do not hold: " + permission}
+		}
+		if !seen[permission] {
+			seen[permission] = true
+			permissions = append(permissions, permission)
+		}
+	}
+	sort.Strings(permissions)
+	role.Permissions = permissions
+
+	existing, err := s.roleRepo.GetRole(ctx, role.Name)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil && !holdsAll(actor, existing.Permissions) {
+		return nil, &ServiceError{Status: http.StatusForbidden, Message: "You cannot edit a role with permissions you do not hold"}
+	}
+	role.Description = strings.TrimSpace(role.Description)
+
+	if err := s.roleRepo.SaveRole(ctx, role); err != nil {
+		return nil, err
+	}
+	s.invalidate()
+
+	return s.roleRepo.GetRole(ctx, role.Name)
+}
+
+// DeleteRole removes a custom role that no user holds
+func (s *RoleService) DeleteRole(ctx context.Context, name string) error {
+	role, err := s.roleRepo.GetRole(ctx, name)
+	if err != nil {
+		return err
+	}
+	if role == nil {
+		return &ServiceError{Status: http.StatusNotFound, Message: "Role not found"}
+	}
+	if role.BuiltIn {
+		return &ServiceError{Status: http.StatusForbidden, Message: "Built-in roles cannot be deleted"}
+	}
+
+	count, err := s.roleRepo.CountUsersWithRole(ctx, name)
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return &ServiceError{Status: http.StatusConflict, Message: "Role is still assigned to users"}
+	}
+
+	if _, err := s.roleRepo.DeleteRole(ctx, name); err != nil {
+		return err
+	}
+	s.invalidate()
+	return nil
+}
+
+// snapshot returns the role grants, reloading them when they are stale. If a
+// reload fails the previous grants keep being used; with none loaded yet
+// principals get no permissions.
+func (s *RoleService) snapshot(ctx context.Context) map[string]map[string]bool {
+	s.mu.RLock()
+	grants, fresh := s.grants, time.Since(s.loadedAt) < s.refresh
+	s.mu.RUnlock()
+	if fresh {
+		return grants
+	}
+
+	s.loading.Lock()
+	defer s.loading.Unlock()
+
+	s.mu.RLock()
+	grants, fresh = s.grants, time.Since(s.loadedAt) < s.refresh
+	s.mu.RUnlock()
+	if fresh {
+		return grants
+	}
+
+	roles, err := s.roleRepo.GetRoles(ctx)
+	if err != nil {
+		log.Printf("Failed to reload role permissions: %v", err)
+		s.mu.Lock()
+		s.loadedAt = time.Now().Add(roleReloadRetry - s.refresh)
+		s.mu.Unlock()
+		return grants
+	}
+
+	grants = make(map[string]map[string]bool, len(roles))
+	for _, role := range roles {
+		granted := make(map[string]bool, len(role.Permissions))
+		for _, permission := range role.Permissions {
+			granted[permission] = true
+		}
+		grants[role.Name] = granted
+	}
+
+	s.mu.Lock()
+	s.grants, s.loadedAt = grants, time.Now()
+	s.mu.Unlock()
+	return grants
+}
+
+// holdsAll reports whether actor holds every one of permissions
+func holdsAll(actor *models.Principal, permissions []string) bool {
+	for _, permission := range permissions {
+		if !actor.Can(permission) {
+			return false
+		}
+	}
+	return true
+}
+
+// invalidate makes the next check reload the grants
+func (s *RoleService) invalidate() {
+	s.mu.Lock()
+	s.loadedAt = time.Time{}
+	s.mu.Unlock()
+}
@@ -0,0 +1,220 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/your-username/golang-ecommerce-app/models"
+	"github.com/your-username/golang-ecommerce-app/repository"
+	"github.com/your-username/golang-ecommerce-app/utils"
+)
+
+// sessionCacheTTL bounds how often an active session is checked against
+// Postgres, which is also how often its lastSeenAt moves
+const sessionCacheTTL = time.Minute
+
+// ErrSessionRevoked is returned by ValidateSession for sessions that have ended
+var ErrSessionRevoked = errors.New("session has been revoked")
+
+type SessionService struct {
+	sessionRepo *repository.SessionRepository
+	refreshRepo *repository.RefreshTokenRepository
+	cache       utils.CacheProvider
+}
+
+func NewSessionService(
+	sessionRepo *repository.SessionRepository,
+	refreshRepo *repository.RefreshTokenRepository,
+	cache utils.CacheProvider,
+) *SessionService {
+	return &SessionService{
+		sessionRepo: sessionRepo,
+		refreshRepo: refreshRepo,
+		cache:       cache,
+	}
+}
+
+// StartSession records a login and returns the new session ID. mfaVerified
+// tells whether the login completed a second factor.
+func (s *SessionService) StartSession(ctx context.Context, userID string, client models.ClientInfo, mfaVerified bool) (string, error) {
+	sessionID, err := utils.NewRandomID()
+	if err != nil {
+		return "", fmt.Errorf("failed to start session: %w", err)
+	}
+
+	device := client.Device
+	if device == "" {
+		device = describeDevice(client.UserAgent)
+	}
+
+	err = s.sessionRepo.CreateSession(ctx, models.Session{
+		SessionID:   sessionID,
+		UserID:      userID,
+		Device:      truncate(device, 200),
+		IP:          client.IP,
+		UserAgent:   client.UserAgent,
+		MFAVerified: mfaVerified,
+	})
+	if err != nil {
+		return "", err
+	}
+	return sessionID, nil
+}
+
+// ValidateSession checks that a token's session is still active. Active
+// sessions are remembered for sessionCacheTTL; revoking one forgets it at once.
+func (s *SessionService) ValidateSession(ctx context.Context, userID, sessionID string) error {
+	if sessionID == "" {
+		return ErrSessionRevoked
+	}
+
+	key := sessionCacheKey(sessionID)
+	if cached, err := s.cache.Get(ctx, key); err == nil && cached == userID {
+		return nil
+	}
+
+	active, err := s.sessionRepo.TouchSession(ctx, userID, sessionID)
+	if err != nil {
+		return err
+	}
+	if !active {
+		return ErrSessionRevoked
+	}
+
+	if err := s.cache.Set(ctx, key, userID, sessionCacheTTL); err != nil && !errors.Is(err, utils.ErrCacheUnavailable) {
+		log.Printf("Failed to cache session %s: %v", sessionID, err)
+	}
+	return nil
+}
+
+// GetSession fetches a session by ID
+func (s *SessionService) GetSession(ctx context.Context, sessionID string) (*models.Session, error) {
+	return s.sessionRepo.GetSession(ctx, sessionID)
+}
+
+// ListSessions returns the user's active sessions, flagging the one making the request
+func (s *SessionService) ListSessions(ctx context.Context, userID, currentSessionID string) ([]models.Session, error) {
+	sessions, err := s.sessionRepo.GetActiveSessions(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range sessions {
+		sessions[i].Current = sessions[i].SessionID == currentSessionID
+	}
+	return sessions, nil
+}
+
+// RevokeSession signs one of the user's sessions out
+func (s *SessionService) RevokeSession(ctx context.Context, userID, sessionID string) error {
+	revoked, err := s.sessionRepo.RevokeSession(ctx, userID, sessionID)
+	if err != nil {
+		return err
+	}
+	if !revoked {
+		return &ServiceError{Status: http.StatusNotFound, Message: "Session not found"}
+	}
+
+	s.forget(ctx, sessionID)
+	return nil
+}
+
+// RevokeAllSessions signs the user out everywhere and returns how many sessions ended
+func (s *SessionService) RevokeAllSessions(ctx context.Context, userID string) (int, error) {
+	return s.RevokeOtherSessions(ctx, userID, "")
+}
+
+// RevokeOtherSessions signs the user out everywhere except the session keepID
+// and returns how many sessions ended
+func (s *SessionService) RevokeOtherSessions(ctx context.Context, userID, keepID string) (int, error) {
+	sessionIDs, err := s.sessionRepo.RevokeUserSessions(ctx, userID, keepID)
+	if err != nil {
+		return 0, err
+	}
+
+	s.forget(ctx, sessionIDs...)
+	return len(sessionIDs), nil
+}
+
+// EndSessionByRefreshToken signs out the session a refresh token belongs to.
+// Unknown tokens are ignored so logging out twice is harmless.
+func (s *SessionService) EndSessionByRefreshToken(ctx context.Context, refreshToken string) error {
+	token, err := s.refreshRepo.FindByHash(ctx, utils.HashOpaqueToken(refreshToken))
+	if err != nil || token == nil {
+		return err
+	}
+
+	if _, err := s.sessionRepo.RevokeSession(ctx, token.UserID, token.FamilyID); err != nil {
+		return err
+	}
+	s.forget(ctx, token.FamilyID)
+	return nil
+}
+
+func (s *SessionService) forget(ctx context.Context, sessionIDs ...string) {
+	for _, id := range sessionIDs {
+		if err := s.cache.Delete(ctx, sessionCacheKey(id)); err != nil {
+			log.Printf("Failed to drop cached session %s: %v", id, err)
+		}
+	}
+}
+
+func sessionCacheKey(sessionID string) string {
+	return "session:" + sessionID
+}
+
+// describeDevice names the browser and platform of a user agent well enough for
+// a user to recognise their own sessions
+func describeDevice(userAgent string) string {
+	browser := ""
+	switch {
+	case strings.Contains(userAgent, "Edg/"):
+		browser = "Edge"
+	case strings.Contains(userAgent, "Firefox/"):
+		browser = "Firefox"
+	case strings.Contains(userAgent, "Chrome/"):
+		browser = "Chrome"
+	case strings.Contains(userAgent, "Safari/"):
+		browser = "Safari"
+	}
+
+	platform := ""
+	switch {
+	case strings.Contains(userAgent, "Android"):
+		platform = "Android"
+	case strings.Contains(userAgent, "iPhone"), strings.Contains(userAgent, "iPad"):
+		platform = "iOS"
+	case strings.Contains(userAgent, "Windows"):
+		platform = "Windows"
+	case strings.Contains(userAgent, "Mac OS X"):
+		platform = "macOS"
+	case strings.Contains(userAgent, "Linux"):
+		platform = "Linux"
+	}
+
+	switch {
+	case browser != "" && platform != "":
+		return browser + " on " + platform
+	case browser != "":
+		return browser
+	case platform != "":
+		return platform
+	case userAgent != "":
+		return truncate(userAgent, 60)
+	default:
+		return "Unknown device"
+	}
+}
+
+// truncate shortens s to at most n characters
+func truncate(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n])
+}
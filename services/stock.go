@@ -0,0 +1,195 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/your-username/golang-ecommerce-app/models"
+	"github.com/your-username/golang-ecommerce-app/repository"
+	"github.com/your-username/golang-ecommerce-app/utils"
+)
+
+const NotificationBackInStock = "back_in_stock"
+
+const (
+	// backInStockBatch is how many notifications are claimed at a time
+	backInStockBatch = 50
+	// backInStockLease is how long a claimed notification is held before
+	// another delivery run may retry it
+	backInStockLease = 5 * time.Minute
+	// backInStockMaxAttempts is how often delivery is tried before giving up
+	// until the product is next restocked
+	backInStockMaxAttempts = 8
+)
+
+type StockService struct {
+	productRepo      *repository.ProductRepository
+	subscriptionRepo *repository.StockSubscriptionRepository
+	notifier         utils.Notifier
+	cache            utils.CacheProvider
+}
+
+func NewStockService(
+	productRepo *repository.ProductRepository,
+	subscriptionRepo *repository.StockSubscriptionRepository,
+	notifier utils.Notifier,
+	cache utils.CacheProvider,
+) *StockService {
+	return &StockService{
+		productRepo:      productRepo,
+		subscriptionRepo: subscriptionRepo,
+		notifier:         notifier,
+		cache:            cache,
+	}
+}
+
+// UpdateStock sets a product's stock level. When a product comes back into
+// stock, notifications to its subscribers are queued with the update and
+// delivery starts in the background; BackInStockJob retries what fails.
+func (s *StockService) UpdateStock(ctx context.Context, productID, stock int) (*models.Product, error) {
+	if productID <= 0 {
+		return nil, errors.New("invalid product ID")
+	}
+	if stock < 0 {
+		return nil, &ServiceError{Status: http.StatusBadRequest, Message: "stock cannot be negative"}
+	}
+
+	product, queued, err := s.productRepo.SetProductStock(ctx, productID, stock)
+	if err != nil {
+		return nil, err
+	}
+	if product == nil {
+		return nil, nil
+	}
+
+	invalidateProductCache(ctx, s.cache, false, productID)
+
+	if queued > 0 {
+		go func() {
+			if _, err := s.DeliverBackInStock(context.WithoutCancel(ctx)); err != nil {
+				log.Printf("Failed to deliver back-in-stock notifications for product %d: %v", productID, err)
+			}
+		}()
+	}
+
+	return product, nil
+}
+
+// Subscribe asks for a notification when an out-of-stock product returns
+func (s *StockService) Subscribe(ctx context.Context, productID int, userID string) (*models.StockSubscription, error) {
+	product, err := s.productRepo.GetProductByID(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+	if product == nil {
+		return nil, &ServiceError{Status: http.StatusNotFound, Message: "Product not found"}
+	}
+	if product.Stock == nil || *product.Stock > 0 {
+		return nil, &ServiceError{Status: http.StatusConflict, Message: "Product is in stock"}
+	}
+
+	return s.subscriptionRepo.Subscribe(ctx, productID, userID)
+}
+
+func (s *StockService) Unsubscribe(ctx context.Context, productID int, userID string) error {
+	removed, err := s.subscriptionRepo.Unsubscribe(ctx, productID, userID)
+	if err != nil {
+		return err
+	}
+	if !removed {
+		return &ServiceError{Status: http.StatusNotFound, Message: "Subscription not found"}
+	}
+	return nil
+}
+
+// DeliverBackInStock sends every queued back-in-stock notification that is due
+// and returns how many were delivered. A notification is marked sent only once
+// the notifier accepts it; failures are retried with backoff.
+func (s *StockService) DeliverBackInStock(ctx context.Context) (int, error) {
+	delivered := 0
+	for {
+		batch, err := s.subscriptionRepo.ClaimQueuedNotifications(ctx, backInStockBatch, backInStockLease, backInStockMaxAttempts)
+		if err != nil {
+			return delivered, err
+		}
+
+		for _, n := range batch {
+			err := s.notifier.Notify(ctx, utils.Notification{
+				Kind:    NotificationBackInStock,
+				UserID:  n.UserID,
+				To:      n.Email,
+				Subject: n.ProductName + " is back in stock",
+				Body:    fmt.Sprintf("Good news! %s is available again.", n.ProductName),
+				Data: map[string]interface{}{
+					"productId": n.ProductID,
+					"stock":     n.Stock,
+				},
+			})
+			if err != nil {
+				log.Printf("Failed to send back-in-stock notification %d (attempt %d): %v", n.SubscriptionID, n.Attempts, err)
+				if err := s.subscriptionRepo.RecordNotifyFailure(ctx, n.SubscriptionID, err.Error(), time.Now().Add(backInStockRetryDelay(n.Attempts))); err != nil {
+					log.Printf("Failed to reschedule notification %d: %v", n.SubscriptionID, err)
+				}
+				continue
+			}
+
+			// If this fails the lease runs out and the subscriber may hear twice, which beats not at all
+			if err := s.subscriptionRepo.MarkNotified(ctx, n.SubscriptionID); err != nil {
+				log.Printf("Failed to mark notification %d sent: %v", n.SubscriptionID, err)
+				continue
+			}
+			delivered++
+		}
+
+		if len(batch) < backInStockBatch {
+			return delivered, nil
+		}
+	}
+}
+
+// backInStockRetryDelay doubles from a minute after each failed attempt, up to an hour
+func backInStockRetryDelay(attempts int) time.Duration {
+	delay := time.Minute
+	for i := 1; i < attempts && delay < time.Hour; i++ {
+		delay *= 2
+	}
+	return min(delay, time.Hour)
+}
+
+// BackInStockJob periodically delivers queued back-in-stock notifications,
+// including ones left behind by a failure or restart
+type BackInStockJob struct {
+	stockService *StockService
+	interval     time.Duration
+}
+
+func NewBackInStockJob(stockService *StockService, interval time.Duration) *BackInStockJob {
+	return &BackInStockJob{stockService: stockService, interval: interval}
+}
+
+// Run delivers due notifications once immediately and then on every tick until ctx is cancelled
+func (j *BackInStockJob) Run(ctx context.Context) {
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+
+	for {
+		delivered, err := j.stockService.DeliverBackInStock(ctx)
+		if err != nil {
+			if ctx.Err() == nil {
+				log.Printf("Back-in-stock job: delivery failed: %v", err)
+			}
+		} else if delivered > 0 {
+			log.Printf("Back-in-stock job: sent %d notifications", delivered)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -2,8 +2,10 @@ package services
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
+	"net/http"
 	"time"
 
 	"github.com/your-username/golang-ecommerce-app/models"
@@ -12,18 +14,46 @@ import (
 )
 
 type UserService struct {
-	userRepo *repository.UserRepository
+	userRepo            *repository.UserRepository
+	refreshRepo         *repository.RefreshTokenRepository
+	sessionService      *SessionService
+	verificationService *EmailVerificationService
+	mfaService          *MFAService
+	throttle            *LoginThrottle
+	roleService         *RoleService
+	accessTTL           time.Duration
+	refreshTTL          time.Duration
 }
 
-func NewUserService(userRepo *repository.UserRepository) *UserService {
+func NewUserService(
+	userRepo *repository.UserRepository,
+	refreshRepo *repository.RefreshTokenRepository,
+	sessionService *SessionService,
+	verificationService *EmailVerificationService,
+	mfaService *MFAService,
+	throttle *LoginThrottle,
+	roleService *RoleService,
+	accessTTL time.Duration,
+	refreshTTL time.Duration,
+) *UserService {
 	return &UserService{
-		userRepo: userRepo,
+		userRepo:            userRepo,
+		refreshRepo:         refreshRepo,
+		sessionService:      sessionService,
+		verificationService: verificationService,
+		mfaService:          mfaService,
+		throttle:            throttle,
+		roleService:         roleService,
+		accessTTL:           accessTTL,
+		refreshTTL:          refreshTTL,
 	}
 }
 
 type ServiceError struct {
 	Status  int
 	Message string
+	// RetryAfter, when set, tells the client how long to wait before retrying
+	RetryAfter time.Duration
 }
 
 func (e *ServiceError) Error() string {
@@ -74,35 +104,128 @@ func (u *UserService) Signup(ctx context.Context, userData models.User) (*models
 	}
 
 	go utils.LogEventToProducer("User Signup", userData.UserId, eventMap)
+	go u.sendVerification(context.WithoutCancel(ctx), result)
 
 	return result, nil
 }
 
-func (u *UserService) Login(ctx context.Context, userId, password string) (string, error) {
+// Login checks the user's password. Accounts with two-factor enabled get an
+// MFA challenge token to finish the login at CompleteMFALogin instead of tokens.
+func (u *UserService) Login(ctx context.Context, userId, password string, client models.ClientInfo) (*models.LoginResult, error) {
+	attempt, err := u.throttle.Begin(ctx, userId, client.IP)
+	if err != nil {
+		return nil, err
+	}
+
 	user, err := u.userRepo.FindByuserId(ctx, userId)
 	if err != nil {
-		return "", &ServiceError{
+		attempt.Release(ctx)
+		return nil, &ServiceError{
 			Status:  401,
 			Message: "Invalid credentials",
 		}
 	}
 	if user == nil {
-		return "", &ServiceError{
+		attempt.Failed()
+		return nil, &ServiceError{
 			Status:  401,
 			Message: "Invalid credentials",
 		}
 	}
 
 	if !utils.ComparePasswords(password, user.Password) {
-		return "", &ServiceError{
+		attempt.Failed()
+		return nil, &ServiceError{
 			Status:  401,
 			Message: "Invalid credentials",
 		}
 	}
 
-	token, err := utils.GenerateToken(user.UserId, user.Role)
+	mfaEnabled, err := u.mfaService.IsEnabled(ctx, user.UserId)
 	if err != nil {
-		return "", err
+		attempt.Release(ctx)
+		return nil, err
+	}
+	if mfaEnabled {
+		// Earlier failures stay until the code is checked too, so failed MFA
+		// codes add up to a lockout like failed passwords do
+		attempt.Release(ctx)
+		mfaToken, expiresIn, err := u.mfaService.NewLoginChallenge(user.UserId)
+		if err != nil {
+			return nil, err
+		}
+		return &models.LoginResult{MFARequired: true, MFAToken: mfaToken, MFAExpiresIn: expiresIn}, nil
+	}
+	attempt.Succeeded(ctx)
+
+	tokens, err := u.startSession(ctx, user, client, false)
+	if err != nil {
+		return nil, err
+	}
+	return &models.LoginResult{AuthTokens: tokens}, nil
+}
+
+// CompleteMFALogin finishes a login with the challenge token from Login and a
+// TOTP or recovery code. Wrong codes count against the user and the IP address
+// in the login throttle.
+func (u *UserService) CompleteMFALogin(ctx context.Context, mfaToken, code string, client models.ClientInfo) (*models.AuthTokens, error) {
+	userId, err := u.mfaService.LoginChallengeSubject(mfaToken)
+	if err != nil {
+		return nil, err
+	}
+	attempt, err := u.throttle.Begin(ctx, userId, client.IP)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := u.mfaService.CompleteLoginChallenge(ctx, mfaToken, code); err != nil {
+		var serviceErr *ServiceError
+		if errors.As(err, &serviceErr) && serviceErr.Status == 401 {
+			attempt.Failed()
+		} else {
+			attempt.Release(ctx)
+		}
+		return nil, err
+	}
+	attempt.Succeeded(ctx)
+
+	user, err := u.userRepo.FindByuserId(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, &ServiceError{
+			Status:  401,
+			Message: "Invalid credentials",
+		}
+	}
+
+	return u.startSession(ctx, user, client, true)
+}
+
+// startSession records the login and issues its first tokens
+func (u *UserService) startSession(ctx context.Context, user *models.User, client models.ClientInfo, mfaVerified bool) (*models.AuthTokens, error) {
+	sessionID, err := u.sessionService.StartSession(ctx, user.UserId, client, mfaVerified)
+	if err != nil {
+		return nil, err
+	}
+	refreshToken, refreshHash, err := utils.NewOpaqueToken()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate refresh token: %w", err)
+	}
+	err = u.refreshRepo.CreateRefreshToken(ctx, models.RefreshToken{
+		TokenHash: refreshHash,
+		FamilyID:  sessionID,
+		UserID:    user.UserId,
+		ExpiresAt: time.Now().Add(u.refreshTTL),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	tokens, err := u.issueTokens(user, sessionID, refreshToken, mfaVerified)
+	if err != nil {
+		return nil, err
 	}
 
 	event := models.UserEvent{
@@ -123,26 +246,156 @@ func (u *UserService) Login(ctx context.Context, userId, password string) (strin
 	}
 	go utils.LogEventToProducer("User Login", user.UserId, eventMap)
 
-	return token, nil
+	return tokens, nil
+}
+
+// RefreshTokens exchanges a refresh token for a new access and refresh token.
+// Each refresh token works once; presenting a used one signs the session out.
+func (u *UserService) RefreshTokens(ctx context.Context, refreshToken string) (*models.AuthTokens, error) {
+	nextToken, nextHash, err := utils.NewOpaqueToken()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate refresh token: %w", err)
+	}
+
+	rotated, err := u.refreshRepo.RotateRefreshToken(ctx, utils.HashOpaqueToken(refreshToken), models.RefreshToken{
+		TokenHash: nextHash,
+		ExpiresAt: time.Now().Add(u.refreshTTL),
+	}, time.Now())
+	if errors.Is(err, repository.ErrRefreshTokenReused) {
+		log.Printf("Refresh token reuse for user %s, revoking session %s", rotated.UserID, rotated.FamilyID)
+		if err := u.sessionService.RevokeSession(ctx, rotated.UserID, rotated.FamilyID); err != nil {
+			log.Printf("Failed to revoke session %s: %v", rotated.FamilyID, err)
+		}
+		go utils.LogEventToProducer("Refresh Token Reuse", rotated.UserID, map[string]interface{}{
+			"Timestamp": time.Now().UTC().Format(time.RFC3339),
+			"sessionId": rotated.FamilyID,
+			"userId":    rotated.UserID,
+		})
+		return nil, &ServiceError{Status: http.StatusUnauthorized, Message: "Refresh token has already been used; please log in again"}
+	}
+	if errors.Is(err, repository.ErrRefreshTokenInvalid) {
+		return nil, &ServiceError{Status: http.StatusUnauthorized, Message: "Invalid or expired refresh token"}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := u.userRepo.FindByuserId(ctx, rotated.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, &ServiceError{Status: http.StatusUnauthorized, Message: "Invalid or expired refresh token"}
+	}
+
+	session, err := u.sessionService.GetSession(ctx, rotated.FamilyID)
+	if err != nil {
+		return nil, err
+	}
+
+	return u.issueTokens(user, rotated.FamilyID, nextToken, session != nil && session.MFAVerified)
+}
+
+// Logout ends the session a refresh token belongs to
+func (u *UserService) Logout(ctx context.Context, refreshToken string) error {
+	return u.sessionService.EndSessionByRefreshToken(ctx, refreshToken)
+}
+
+func (u *UserService) sendVerification(ctx context.Context, user *models.User) {
+	if err := u.verificationService.SendVerification(ctx, user); err != nil {
+		log.Printf("Failed to send verification email to user %s: %v", user.UserId, err)
+	}
+}
+
+// issueTokens pairs a fresh access token for the session with its refresh token
+func (u *UserService) issueTokens(user *models.User, sessionID, refreshToken string, mfaVerified bool) (*models.AuthTokens, error) {
+	amr := []string{utils.AMRPassword}
+	if mfaVerified {
+		amr = append(amr, utils.AMRMFA)
+	}
+
+	accessToken, err := utils.GenerateToken(user.UserId, user.Role, sessionID, amr, u.accessTTL)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.AuthTokens{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    int(u.accessTTL.Seconds()),
+	}, nil
 }
 
-func (u *UserService) UpdateUserService(ctx context.Context, userId string, updates map[string]interface{}) (*models.User, error) {
+// accountScope is the set of accounts a change made through accountForChange may target
+type accountScope int
+
+const (
+	// customerAccounts are accounts with the user role
+	customerAccounts accountScope = iota
+	// staffAccounts are all accounts except superadmins, unless the actor holds "*"
+	staffAccounts
+	// anyAccount includes superadmins, for changes that only protect the
+	// account, such as ending its sessions or lifting a lockout
+	anyAccount
+)
+
+// accountForChange loads the account actor wants to manage with permission
+// and checks they may, and that it lies within scope. Nobody manages their own
+// account this way.
+func (u *UserService) accountForChange(ctx context.Context, actor *models.Principal, permission, userId string, scope accountScope) (*models.User, error) {
+	if actor == nil {
+		return nil, &ServiceError{
+			Status:  401,
+			Message: "Unauthorized",
+		}
+	}
+	if !actor.Can(permission) {
+		return nil, &ServiceError{
+			Status:  403,
+			Message: "You do not have permission to perform this action",
+		}
+	}
+
 	user, err := u.userRepo.FindByuserId(ctx, userId)
-	if err != nil || user == nil {
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
 		return nil, &ServiceError{
 			Status:  404,
 			Message: "User not found",
 		}
 	}
 
-	// check that updating user role is user only
-	if user.Role != "user" {
+	if actor.Is(user.UserId) {
+		return nil, &ServiceError{
+			Status:  403,
+			Message: "Manage your own account from your profile",
+		}
+	}
+	outOfScope := false
+	switch scope {
+	case customerAccounts:
+		outOfScope = user.Role != "user"
+	case staffAccounts:
+		outOfScope = user.Role == "superadmin" && !actor.Can(models.PermAll)
+	}
+	if outOfScope {
 		return nil, &ServiceError{
 			Status:  403,
-			Message: "Unauthorized to update user",
+			Message: "Unauthorized to manage this user",
 		}
 	}
 
+	return user, nil
+}
+
+func (u *UserService) UpdateUserService(ctx context.Context, actor *models.Principal, userId string, updates map[string]interface{}) (*models.User, error) {
+	if _, err := u.accountForChange(ctx, actor, models.PermUsersWrite, userId, customerAccounts); err != nil {
+		return nil, err
+	}
+
 	if email, ok := updates["email"]; ok {
 		if emailStr, ok := email.(string); ok && emailStr != "" {
 			existingUser, err := u.userRepo.FindByEmail(ctx, emailStr)
@@ -190,6 +443,12 @@ func (u *UserService) UpdateUserService(ctx context.Context, userId string, upda
 	// Update user in the repository
 	updatedUser, err := u.userRepo.UpdateUser(ctx, userId, updates)
 	if err != nil {
+		if errors.Is(err, repository.ErrEmailTaken) {
+			return nil, &ServiceError{
+				Status:  409,
+				Message: "Email already in use",
+			}
+		}
 		log.Println("Error updating user:", err)
 		return nil, err
 	}
@@ -197,69 +456,89 @@ func (u *UserService) UpdateUserService(ctx context.Context, userId string, upda
 	return updatedUser, nil
 }
 
-func (u *UserService) DeleteUserService(ctx context.Context, userId string) error {
-	user, err := u.userRepo.FindByuserId(ctx, userId)
-	if err != nil || user == nil {
-		return &ServiceError{
-			Status:  404,
-			Message: "User not found",
-		}
-	}
-	// check that deleting user is user only
-	if user.Role != "user" {
-		return &ServiceError{
-			Status:  403,
-			Message: "Unauthorized to delete user",
-		}
+func (u *UserService) DeleteUserService(ctx context.Context, actor *models.Principal, userId string) error {
+	if _, err := u.accountForChange(ctx, actor, models.PermUsersWrite, userId, customerAccounts); err != nil {
+		return err
 	}
 
-	if _, err := u.userRepo.DeleteUser(ctx, userId); err != nil {
+	// Dropping the cached sessions makes the account's access tokens fail at once
+	_, sessionIDs, err := u.userRepo.DeleteUserAndSessions(ctx, userId)
+	if err != nil {
 		log.Println("Error deleting user:", err)
 		return err
 	}
+	u.sessionService.forget(ctx, sessionIDs...)
 
 	return nil
 }
 
-func (u *UserService) DeleteUserServiceAllAccess(ctx context.Context, userId string) error {
-	user, err := u.userRepo.FindByuserId(ctx, userId)
-	if err != nil || user == nil {
-		return &ServiceError{
-			Status:  404,
-			Message: "User not found",
-		}
-	}
-	// check can't delete superadmin user
-	if user.Role == "superadmin" {
-		return &ServiceError{
-			Status:  403,
-			Message: "Unauthorized to delete user",
-		}
+func (u *UserService) DeleteUserServiceAllAccess(ctx context.Context, actor *models.Principal, userId string) error {
+	if _, err := u.accountForChange(ctx, actor, models.PermUsersDelete, userId, staffAccounts); err != nil {
+		return err
 	}
 
-	if _, err := u.userRepo.DeleteUser(ctx, userId); err != nil {
+	// Dropping the cached sessions makes the account's access tokens fail at once
+	_, sessionIDs, err := u.userRepo.DeleteUserAndSessions(ctx, userId)
+	if err != nil {
 		log.Println("Error deleting user:", err)
 		return err
 	}
+	u.sessionService.forget(ctx, sessionIDs...)
 
 	return nil
 }
 
-func (u *UserService) UpdateUserRoleService(ctx context.Context, userId, role string) (*models.User, error) {
-	user, err := u.userRepo.FindByuserId(ctx, userId)
-	if err != nil || user == nil {
-		return nil, &ServiceError{
+// RevokeUserSessionsService signs the user out of every device and returns
+// how many sessions were ended
+func (u *UserService) RevokeUserSessionsService(ctx context.Context, actor *models.Principal, userId string) (int, error) {
+	if _, err := u.accountForChange(ctx, actor, models.PermUsersWrite, userId, anyAccount); err != nil {
+		return 0, err
+	}
+	return u.sessionService.RevokeAllSessions(ctx, userId)
+}
+
+// UnlockUserService lifts a login lockout or backoff on the user
+func (u *UserService) UnlockUserService(ctx context.Context, actor *models.Principal, userId string) error {
+	if _, err := u.accountForChange(ctx, actor, models.PermUsersWrite, userId, anyAccount); err != nil {
+		return err
+	}
+
+	unlocked, err := u.throttle.Unlock(ctx, userId)
+	if err != nil {
+		return err
+	}
+	if !unlocked {
+		return &ServiceError{
 			Status:  404,
-			Message: "User not found",
+			Message: "User has no failed logins to clear",
 		}
 	}
 
-	if role != "admin" && role != "user" {
-		return nil, &ServiceError{
-			Status:  400,
-			Message: "Invalid role",
+	go utils.LogEventToProducer("Account Unlocked", userId, map[string]interface{}{
+		"Timestamp":  time.Now().UTC().Format(time.RFC3339),
+		"Action":     "account_unlock",
+		"userId":     userId,
+		"unlockedBy": actor.UserID,
+	})
+	return nil
+}
+
+func (u *UserService) UpdateUserRoleService(ctx context.Context, actor *models.Principal, userId, role string) (*models.User, error) {
+	user, err := u.accountForChange(ctx, actor, models.PermUsersAssignRole, userId, staffAccounts)
+	if err != nil {
+		return nil, err
+	}
+
+	// Actors can neither hand out nor take away permissions they do not hold.
+	// Only holders of "*" get this far with a superadmin.
+	if user.Role != "superadmin" {
+		if err := u.roleService.CheckAssignable(ctx, actor, user.Role); err != nil {
+			return nil, err
 		}
 	}
+	if err := u.roleService.CheckAssignable(ctx, actor, role); err != nil {
+		return nil, err
+	}
 
 	updatedUser, err := u.userRepo.UpdateUserRole(ctx, userId, role)
 	if err != nil {
@@ -0,0 +1,95 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/golang-migrate/migrate/v4"
+	_ "github.com/golang-migrate/migrate/v4/database/postgres"
+	_ "github.com/golang-migrate/migrate/v4/source/file"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/your-username/golang-ecommerce-app/models"
+	"github.com/your-username/golang-ecommerce-app/repository"
+	"github.com/your-username/golang-ecommerce-app/utils"
+)
+
+// testPool connects to the database in TEST_DATABASE_URL, a postgres:// URL,
+// and migrates it. Tests that need Postgres are skipped without one.
+func testPool(t *testing.T) *pgxpool.Pool {
+	t.Helper()
+	url := os.Getenv("TEST_DATABASE_URL")
+	if url == "" {
+		t.Skip("TEST_DATABASE_URL not set")
+	}
+
+	m, err := migrate.New("file://../migrations", url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Up(); err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		t.Fatal(err)
+	}
+	m.Close()
+
+	pool, err := pgxpool.New(context.Background(), url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(pool.Close)
+	return pool
+}
+
+func TestDeletedUserSessionsRejectedAtOnce(t *testing.T) {
+	ctx := context.Background()
+	pool := testPool(t)
+	userRepo := repository.NewUserRepository(pool)
+
+	actor := &models.Principal{UserID: "admin", Permissions: []string{models.PermUsersWrite, models.PermUsersDelete}}
+	tests := []struct {
+		name   string
+		delete func(u *UserService, userID string) error
+	}{
+		{"customer delete", func(u *UserService, userID string) error {
+			return u.DeleteUserService(ctx, actor, userID)
+		}},
+		{"delete with all access", func(u *UserService, userID string) error {
+			return u.DeleteUserServiceAllAccess(ctx, actor, userID)
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			userID := "deleted-" + time.Now().Format("150405.000000000")
+			_, err := userRepo.CreateUser(ctx, models.User{UserId: userID, Email: userID + "@example.com", Password: "x", Role: "user"})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			sessions := NewSessionService(
+				repository.NewSessionRepository(pool),
+				repository.NewRefreshTokenRepository(pool),
+				utils.NewMemoryCache(0),
+			)
+			users := &UserService{userRepo: userRepo, sessionService: sessions}
+
+			sessionID, err := sessions.StartSession(ctx, userID, models.ClientInfo{}, false)
+			if err != nil {
+				t.Fatal(err)
+			}
+			// The first check caches the session, as a request with the token would
+			if err := sessions.ValidateSession(ctx, userID, sessionID); err != nil {
+				t.Fatalf("active session rejected: %v", err)
+			}
+
+			if err := tt.delete(users, userID); err != nil {
+				t.Fatal(err)
+			}
+			if err := sessions.ValidateSession(ctx, userID, sessionID); !errors.Is(err, ErrSessionRevoked) {
+				t.Errorf("session of a deleted user: err = %v, want ErrSessionRevoked", err)
+			}
+		})
+	}
+}
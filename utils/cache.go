@@ -2,17 +2,38 @@ package utils
 
 import (
 	"context"
+	"errors"
 	"time"
 
 	"github.com/redis/go-redis/v9"
 )
 
+// ErrCacheMiss is returned by Get when the key is not cached
+var ErrCacheMiss = errors.New("cache miss")
+
 // CacheProvider defines the interface for cache operations
 type CacheProvider interface {
 	Get(ctx context.Context, key string) (string, error)
 	Set(ctx context.Context, key string, value string, expiration time.Duration) error
 	Delete(ctx context.Context, key string) error
 	DeletePattern(ctx context.Context, pattern string) error
+	// SetWithTags stores a value like Set and records the key under each tag so
+	// it can later be dropped by InvalidateTags
+	SetWithTags(ctx context.Context, key string, value string, expiration time.Duration, tags ...string) error
+	// InvalidateTags deletes every key recorded under the given tags
+	InvalidateTags(ctx context.Context, tags ...string) error
+	// ListPushCapped moves value to the head of the list at key, removing any
+	// earlier copy, trims the list to maxLen entries and refreshes its expiration
+	ListPushCapped(ctx context.Context, key string, value string, maxLen int64, expiration time.Duration) error
+	// ListPushCappedIfExists is ListPushCapped for a list that is already
+	// cached. It does nothing and reports false when key is missing.
+	ListPushCappedIfExists(ctx context.Context, key string, value string, maxLen int64, expiration time.Duration) (bool, error)
+	// ListFill creates the list at key holding values in order, in one step,
+	// unless key or any of guards exists. It reports whether the list was created.
+	ListFill(ctx context.Context, key string, values []string, expiration time.Duration, guards ...string) (bool, error)
+	// ListRange returns the list entries between start and stop inclusive; a
+	// missing key yields an empty slice
+	ListRange(ctx context.Context, key string, start, stop int64) ([]string, error)
 }
 
 // RedisCache implements CacheProvider using Redis
@@ -26,7 +47,11 @@ func NewRedisCache(client *redis.Client) CacheProvider {
 }
 
 func (r *RedisCache) Get(ctx context.Context, key string) (string, error) {
-	return r.client.Get(ctx, key).Result()
+	value, err := r.client.Get(ctx, key).Result()
+	if errors.Is(err, redis.Nil) {
+		return "", ErrCacheMiss
+	}
+	return value, err
 }
 
 func (r *RedisCache) Set(ctx context.Context, key string, value string, expiration time.Duration) error {
@@ -37,13 +62,139 @@ func (r *RedisCache) Delete(ctx context.Context, key string) error {
 	return r.client.Del(ctx, key).Err()
 }
 
+// DeletePattern deletes keys matching pattern. It walks the keyspace with SCAN
+// so a large cache does not block Redis the way KEYS would.
 func (r *RedisCache) DeletePattern(ctx context.Context, pattern string) error {
-	keys, err := r.client.Keys(ctx, pattern).Result()
-	if err != nil {
+	iter := r.client.Scan(ctx, 0, pattern, 500).Iterator()
+	batch := make([]string, 0, 500)
+	for iter.Next(ctx) {
+		batch = append(batch, iter.Val())
+		if len(batch) == cap(batch) {
+			if err := r.client.Del(ctx, batch...).Err(); err != nil {
+				return err
+			}
+			batch = batch[:0]
+		}
+	}
+	if err := iter.Err(); err != nil {
 		return err
 	}
-	if len(keys) == 0 {
+	if len(batch) == 0 {
+		return nil
+	}
+	return r.client.Del(ctx, batch...).Err()
+}
+
+// setWithTagsScript sets KEYS[1] and adds it to each tag set in KEYS[2..]. A tag
+// set's TTL is only ever extended, so it outlives every key recorded in it.
+var setWithTagsScript = redis.NewScript(`
+redis.call('SET', KEYS[1], ARGV[1], 'PX', ARGV[2])
+for i = 2, #KEYS do
+	redis.call('SADD', KEYS[i], KEYS[1])
+	local ttl = redis.call('PTTL', KEYS[i])
+	if ttl == -1 or ttl < tonumber(ARGV[2]) then
+		redis.call('PEXPIRE', KEYS[i], ARGV[2])
+	end
+end
+return 1
+`)
+
+// invalidateTagsScript deletes the members of each tag set in KEYS and then the sets themselves
+var invalidateTagsScript = redis.NewScript(`
+local deleted = 0
+for i = 1, #KEYS do
+	local members = redis.call('SMEMBERS', KEYS[i])
+	for j = 1, #members, 500 do
+		deleted = deleted + redis.call('DEL', unpack(members, j, math.min(j + 499, #members)))
+	end
+	redis.call('DEL', KEYS[i])
+end
+return deleted
+`)
+
+func (r *RedisCache) SetWithTags(ctx context.Context, key string, value string, expiration time.Duration, tags ...string) error {
+	keys := make([]string, 0, len(tags)+1)
+	keys = append(keys, key)
+	for _, tag := range tags {
+		keys = append(keys, tagSetKey(tag))
+	}
+	return setWithTagsScript.Run(ctx, r.client, keys, value, expiration.Milliseconds()).Err()
+}
+
+func (r *RedisCache) InvalidateTags(ctx context.Context, tags ...string) error {
+	if len(tags) == 0 {
+		return nil
+	}
+	keys := make([]string, len(tags))
+	for i, tag := range tags {
+		keys[i] = tagSetKey(tag)
+	}
+	return invalidateTagsScript.Run(ctx, r.client, keys).Err()
+}
+
+// tagSetKey is the Redis set that records the keys carrying a tag
+func tagSetKey(tag string) string {
+	return "tag:" + tag
+}
+func (r *RedisCache) ListPushCapped(ctx context.Context, key string, value string, maxLen int64, expiration time.Duration) error {
+	_, err := r.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.LRem(ctx, key, 0, value)
+		pipe.LPush(ctx, key, value)
+		pipe.LTrim(ctx, key, 0, maxLen-1)
+		pipe.Expire(ctx, key, expiration)
 		return nil
+	})
+	return err
+}
+
+// listPushIfExistsScript is ListPushCapped for an existing KEYS[1]. ARGV holds
+// the value, the maximum length and the expiration in milliseconds.
+var listPushIfExistsScript = redis.NewScript(`
+if redis.call('EXISTS', KEYS[1]) == 0 then
+	return 0
+end
+redis.call('LREM', KEYS[1], 0, ARGV[1])
+redis.call('LPUSH', KEYS[1], ARGV[1])
+redis.call('LTRIM', KEYS[1], 0, tonumber(ARGV[2]) - 1)
+if tonumber(ARGV[3]) > 0 then
+	redis.call('PEXPIRE', KEYS[1], ARGV[3])
+end
+return 1
+`)
+
+// listFillScript creates the list KEYS[1] from ARGV[2..] unless any key in KEYS
+// exists. ARGV[1] is the expiration in milliseconds.
+var listFillScript = redis.NewScript(`
+for i = 1, #KEYS do
+	if redis.call('EXISTS', KEYS[i]) == 1 then
+		return 0
+	end
+end
+redis.call('RPUSH', KEYS[1], unpack(ARGV, 2))
+if tonumber(ARGV[1]) > 0 then
+	redis.call('PEXPIRE', KEYS[1], ARGV[1])
+end
+return 1
+`)
+
+func (r *RedisCache) ListPushCappedIfExists(ctx context.Context, key string, value string, maxLen int64, expiration time.Duration) (bool, error) {
+	pushed, err := listPushIfExistsScript.Run(ctx, r.client, []string{key}, value, maxLen, expiration.Milliseconds()).Int()
+	return pushed == 1, err
+}
+
+func (r *RedisCache) ListFill(ctx context.Context, key string, values []string, expiration time.Duration, guards ...string) (bool, error) {
+	if len(values) == 0 {
+		return false, nil
+	}
+	args := make([]interface{}, 0, len(values)+1)
+	args = append(args, expiration.Milliseconds())
+	for _, v := range values {
+		args = append(args, v)
 	}
-	return r.client.Del(ctx, keys...).Err()
-}
\ No newline at end of file
+	filled, err := listFillScript.Run(ctx, r.client, append([]string{key}, guards...), args...).Int()
+	return filled == 1, err
+}
+
+func (r *RedisCache) ListRange(ctx context.Context, key string, start, stop int64) ([]string, error) {
+	return r.client.LRange(ctx, key, start, stop).Result()
+}
@@ -0,0 +1,238 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"log"
+	"math/rand/v2"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// CacheLoadFunc builds the value for a key along with the tags to store it under
+type CacheLoadFunc func(ctx context.Context) (value string, tags []string, err error)
+
+// CacheStats is a snapshot of a CacheLoader's counters since startup
+type CacheStats struct {
+	Hits          int64 `json:"hits"`
+	Misses        int64 `json:"misses"`
+	Stale         int64 `json:"stale"`
+	Coalesced     int64 `json:"coalesced"`
+	Refreshes     int64 `json:"refreshes"`
+	RefreshErrors int64 `json:"refreshErrors"`
+}
+
+// CacheLoader reads through a CacheProvider while protecting the loader behind
+// it from stampedes:
+//   - concurrent misses for the same key share a single load
+//   - entries go stale after a soft TTL but are kept for staleTTL longer, during
+//     which they are still served while one goroutine refreshes them
+//   - soft TTLs are jittered so entries written together do not expire together
+//
+// Invalidations made through Cache are tracked so that a load which started
+// before them cannot write its now outdated value back afterwards.
+type CacheLoader struct {
+	cache    CacheProvider
+	group    singleflight.Group
+	freshTTL time.Duration
+	staleTTL time.Duration
+	jitter   float64
+
+	// generation counts invalidations; invalidated holds the generation at
+	// which each tag was last invalidated while a load was running
+	mu          sync.Mutex
+	generation  uint64
+	loading     int
+	invalidated map[string]uint64
+
+	hits          atomic.Int64
+	misses        atomic.Int64
+	stale         atomic.Int64
+	coalesced     atomic.Int64
+	refreshes     atomic.Int64
+	refreshErrors atomic.Int64
+}
+
+// NewCacheLoader creates a loader whose entries are fresh for freshTTL plus up
+// to jitter (a fraction of freshTTL) and may be served stale for staleTTL after that
+func NewCacheLoader(cache CacheProvider, freshTTL, staleTTL time.Duration, jitter float64) *CacheLoader {
+	return &CacheLoader{
+		cache:       cache,
+		freshTTL:    freshTTL,
+		staleTTL:    staleTTL,
+		jitter:      jitter,
+		invalidated: make(map[string]uint64),
+	}
+}
+
+// Cache returns the loader's cache for writers to invalidate through. Tags
+// invalidated through it are never written back by a load already under way.
+func (l *CacheLoader) Cache() CacheProvider {
+	return &loaderCache{CacheProvider: l.cache, loader: l}
+}
+
+type loaderCache struct {
+	CacheProvider
+	loader *CacheLoader
+}
+
+func (c *loaderCache) InvalidateTags(ctx context.Context, tags ...string) error {
+	c.loader.markInvalidated(tags)
+	return c.CacheProvider.InvalidateTags(ctx, tags...)
+}
+
+// Load returns the cached value for key, calling load on a miss. A stale value
+// is returned as is and refreshed in the background. Errors from load are
+// returned to every caller waiting on it and nothing is cached.
+func (l *CacheLoader) Load(ctx context.Context, key string, load CacheLoadFunc) (string, error) {
+	if raw, err := l.cache.Get(ctx, key); err == nil {
+		if value, freshUntil, ok := decodeCacheEntry(raw); ok {
+			if time.Now().Before(freshUntil) {
+				l.hits.Add(1)
+				return value, nil
+			}
+			l.stale.Add(1)
+			l.refresh(ctx, key, load)
+			return value, nil
+		}
+	}
+
+	l.misses.Add(1)
+	value, err, shared := l.group.Do(key, func() (any, error) {
+		return l.fill(context.WithoutCancel(ctx), key, load)
+	})
+	if shared {
+		l.coalesced.Add(1)
+	}
+	if err != nil {
+		return "", err
+	}
+	return value.(string), nil
+}
+
+// refresh reloads a stale key in the background unless a load for it is
+// already running. The caller does not wait for the result.
+func (l *CacheLoader) refresh(ctx context.Context, key string, load CacheLoadFunc) {
+	l.group.DoChan(key, func() (any, error) {
+		l.refreshes.Add(1)
+		value, err := l.fill(context.WithoutCancel(ctx), key, load)
+		if err != nil {
+			l.refreshErrors.Add(1)
+			log.Printf("Failed to refresh cache key %s: %v", key, err)
+		}
+		return value, err
+	})
+}
+
+func (l *CacheLoader) fill(ctx context.Context, key string, load CacheLoadFunc) (string, error) {
+	started := l.beginLoad()
+	defer l.endLoad()
+
+	value, tags, err := load(ctx)
+	if err != nil {
+		return "", err
+	}
+	// The value may predate an invalidation; serve it to this caller only
+	if l.invalidatedSince(started, tags) {
+		return value, nil
+	}
+
+	fresh := l.jittered(l.freshTTL)
+	entry := encodeCacheEntry(value, time.Now().Add(fresh))
+	if err := l.cache.SetWithTags(ctx, key, entry, fresh+l.staleTTL, tags...); err != nil && !errors.Is(err, ErrCacheUnavailable) {
+		log.Printf("Failed to cache key %s: %v", key, err)
+	}
+	// An invalidation between the check and the write may have run before the
+	// key was recorded under its tags, so it is dropped here instead
+	if l.invalidatedSince(started, tags) {
+		if err := l.cache.Delete(ctx, key); err != nil && !errors.Is(err, ErrCacheUnavailable) {
+			log.Printf("Failed to drop invalidated cache key %s: %v", key, err)
+		}
+	}
+	return value, nil
+}
+
+// beginLoad registers a running load and returns the generation it started at
+func (l *CacheLoader) beginLoad() uint64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.loading++
+	return l.generation
+}
+
+// endLoad forgets invalidations once no load is left that could predate them
+func (l *CacheLoader) endLoad() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.loading--
+	if l.loading == 0 {
+		clear(l.invalidated)
+	}
+}
+
+func (l *CacheLoader) markInvalidated(tags []string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.generation++
+	if l.loading == 0 {
+		return
+	}
+	for _, tag := range tags {
+		l.invalidated[tag] = l.generation
+	}
+}
+
+// invalidatedSince reports whether any of tags was invalidated after generation
+func (l *CacheLoader) invalidatedSince(generation uint64, tags []string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, tag := range tags {
+		if l.invalidated[tag] > generation {
+			return true
+		}
+	}
+	return false
+}
+
+func (l *CacheLoader) jittered(d time.Duration) time.Duration {
+	spread := int64(float64(d) * l.jitter)
+	if spread <= 0 {
+		return d
+	}
+	return d + time.Duration(rand.Int64N(spread))
+}
+
+// Stats returns the current counters
+func (l *CacheLoader) Stats() CacheStats {
+	return CacheStats{
+		Hits:          l.hits.Load(),
+		Misses:        l.misses.Load(),
+		Stale:         l.stale.Load(),
+		Coalesced:     l.coalesced.Load(),
+		Refreshes:     l.refreshes.Load(),
+		RefreshErrors: l.refreshErrors.Load(),
+	}
+}
+
+// Cache entries are stored as "<freshUntil unix millis>|<value>". Anything
+// else, such as a value written before the loader was introduced, is a miss.
+func encodeCacheEntry(value string, freshUntil time.Time) string {
+	return strconv.FormatInt(freshUntil.UnixMilli(), 10) + "|" + value
+}
+
+func decodeCacheEntry(raw string) (string, time.Time, bool) {
+	stamp, value, found := strings.Cut(raw, "|")
+	if !found {
+		return "", time.Time{}, false
+	}
+	millis, err := strconv.ParseInt(stamp, 10, 64)
+	if err != nil {
+		return "", time.Time{}, false
+	}
+	return value, time.UnixMilli(millis), true
+}
@@ -0,0 +1,224 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCacheLoaderLoad(t *testing.T) {
+	errLoad := errors.New("load failed")
+
+	tests := []struct {
+		name      string
+		cached    string // raw entry stored before loading, if any
+		load      func() (string, error)
+		want      string
+		wantErr   error
+		wantLoads int64
+		wantStats CacheStats
+	}{
+		{
+			name:      "miss loads and caches",
+			load:      func() (string, error) { return "fresh", nil },
+			want:      "fresh",
+			wantLoads: 1,
+			wantStats: CacheStats{Misses: 1},
+		},
+		{
+			name:      "fresh hit skips the loader",
+			cached:    encodeCacheEntry("cached", time.Now().Add(time.Hour)),
+			load:      func() (string, error) { return "fresh", nil },
+			want:      "cached",
+			wantStats: CacheStats{Hits: 1},
+		},
+		{
+			name:      "entry without a timestamp is a miss",
+			cached:    "legacy value",
+			load:      func() (string, error) { return "fresh", nil },
+			want:      "fresh",
+			wantLoads: 1,
+			wantStats: CacheStats{Misses: 1},
+		},
+		{
+			name:      "load errors are returned and not cached",
+			load:      func() (string, error) { return "", errLoad },
+			wantErr:   errLoad,
+			wantLoads: 1,
+			wantStats: CacheStats{Misses: 1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			cache := NewMemoryCache(0)
+			if tt.cached != "" {
+				cache.Set(ctx, "key", tt.cached, time.Hour)
+			}
+			loader := NewCacheLoader(cache, time.Hour, time.Minute, 0)
+
+			var loads atomic.Int64
+			got, err := loader.Load(ctx, "key", func(ctx context.Context) (string, []string, error) {
+				loads.Add(1)
+				value, err := tt.load()
+				return value, []string{"tag"}, err
+			})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("value = %q, want %q", got, tt.want)
+			}
+			if loads.Load() != tt.wantLoads {
+				t.Errorf("loads = %d, want %d", loads.Load(), tt.wantLoads)
+			}
+			if stats := loader.Stats(); stats != tt.wantStats {
+				t.Errorf("stats = %+v, want %+v", stats, tt.wantStats)
+			}
+
+			raw, err := cache.Get(ctx, "key")
+			if tt.wantErr != nil {
+				if !errors.Is(err, ErrCacheMiss) {
+					t.Errorf("failed load left %q cached", raw)
+				}
+				return
+			}
+			if value, _, ok := decodeCacheEntry(raw); !ok || value != tt.want {
+				t.Errorf("cached %q, want an entry holding %q", raw, tt.want)
+			}
+		})
+	}
+}
+
+func TestCacheLoaderCoalescesMisses(t *testing.T) {
+	ctx := context.Background()
+	loader := NewCacheLoader(NewMemoryCache(0), time.Hour, time.Minute, 0)
+
+	release := make(chan struct{})
+	var loads atomic.Int64
+	load := func(ctx context.Context) (string, []string, error) {
+		loads.Add(1)
+		<-release
+		return "value", nil, nil
+	}
+
+	const callers = 10
+	var wg sync.WaitGroup
+	for range callers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if got, err := loader.Load(ctx, "key", load); err != nil || got != "value" {
+				t.Errorf("Load = %q, %v", got, err)
+			}
+		}()
+	}
+	// Give every caller time to join the load before it finishes
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if loads.Load() != 1 {
+		t.Errorf("loads = %d, want 1", loads.Load())
+	}
+	// singleflight reports the result as shared to the caller that ran the load too
+	if stats := loader.Stats(); stats.Misses != callers || stats.Coalesced != callers {
+		t.Errorf("stats = %+v, want %d misses, all coalesced", stats, callers)
+	}
+}
+
+func TestCacheLoaderServesStaleWhileRefreshing(t *testing.T) {
+	ctx := context.Background()
+	cache := NewMemoryCache(0)
+	cache.Set(ctx, "key", encodeCacheEntry("old", time.Now().Add(-time.Second)), time.Hour)
+	loader := NewCacheLoader(cache, time.Hour, time.Minute, 0)
+
+	refreshed := make(chan struct{})
+	got, err := loader.Load(ctx, "key", func(ctx context.Context) (string, []string, error) {
+		defer close(refreshed)
+		return "new", nil, nil
+	})
+	if err != nil || got != "old" {
+		t.Fatalf("Load = %q, %v, want the stale value", got, err)
+	}
+
+	<-refreshed
+	waitFor(t, func() bool {
+		raw, _ := cache.Get(ctx, "key")
+		value, _, _ := decodeCacheEntry(raw)
+		return value == "new"
+	})
+	if stats := loader.Stats(); stats.Stale != 1 || stats.Refreshes != 1 {
+		t.Errorf("stats = %+v, want one stale read and one refresh", stats)
+	}
+}
+
+func TestCacheLoaderDropsLoadsOverlappingInvalidation(t *testing.T) {
+	tests := []struct {
+		name       string
+		tags       []string
+		invalidate []string
+		wantCached bool
+	}{
+		{"invalidated tag", []string{"product:1", "products"}, []string{"product:1"}, false},
+		{"unrelated tag", []string{"product:1"}, []string{"product:2"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			cache := NewMemoryCache(0)
+			loader := NewCacheLoader(cache, time.Hour, time.Minute, 0)
+
+			got, err := loader.Load(ctx, "key", func(ctx context.Context) (string, []string, error) {
+				// A writer invalidates while the value is being read
+				if err := loader.Cache().InvalidateTags(ctx, tt.invalidate...); err != nil {
+					t.Fatal(err)
+				}
+				return "value", tt.tags, nil
+			})
+			if err != nil || got != "value" {
+				t.Fatalf("Load = %q, %v", got, err)
+			}
+
+			_, err = cache.Get(ctx, "key")
+			if cached := err == nil; cached != tt.wantCached {
+				t.Errorf("cached = %v, want %v", cached, tt.wantCached)
+			}
+		})
+	}
+}
+
+func TestCacheLoaderForgetsInvalidationsWhenIdle(t *testing.T) {
+	ctx := context.Background()
+	cache := NewMemoryCache(0)
+	loader := NewCacheLoader(cache, time.Hour, time.Minute, 0)
+
+	// Invalidations with no load running must not block later loads
+	loader.Cache().InvalidateTags(ctx, "tag")
+	loader.Load(ctx, "key", func(ctx context.Context) (string, []string, error) {
+		return "value", []string{"tag"}, nil
+	})
+	if _, err := cache.Get(ctx, "key"); err != nil {
+		t.Fatalf("value loaded after the invalidation was not cached: %v", err)
+	}
+	if len(loader.invalidated) != 0 {
+		t.Errorf("%d invalidations still tracked with no load running", len(loader.invalidated))
+	}
+}
+
+// waitFor polls cond for up to a second
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met within a second")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
@@ -0,0 +1,357 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrCacheUnavailable is returned without contacting the cache while the circuit is open
+var ErrCacheUnavailable = errors.New("cache unavailable")
+
+// maxPendingInvalidations bounds the invalidations remembered while the cache is down
+const maxPendingInvalidations = 10000
+
+type breakerState int
+
+const (
+	breakerClosed breakerState = iota
+	breakerOpen
+	breakerHalfOpen
+)
+
+func (s breakerState) String() string {
+	switch s {
+	case breakerOpen:
+		return "open"
+	case breakerHalfOpen:
+		return "half-open"
+	default:
+		return "closed"
+	}
+}
+
+// CacheStatus describes the health of a cache behind a circuit breaker
+type CacheStatus struct {
+	State                string     `json:"state"`
+	ConsecutiveFailures  int        `json:"consecutiveFailures"`
+	LastError            string     `json:"lastError,omitempty"`
+	OpenedAt             *time.Time `json:"openedAt,omitempty"`
+	PendingInvalidations int        `json:"pendingInvalidations"`
+	// PendingCompacted is set once pending invalidations outgrew their bound
+	// and were widened to whole namespaces
+	PendingCompacted bool `json:"pendingCompacted,omitempty"`
+}
+
+// CircuitBreakerCache wraps a CacheProvider so an unreachable cache costs
+// nothing. After threshold consecutive failures the circuit opens and every
+// call fails fast with ErrCacheUnavailable, which callers already treat as a
+// miss. Once cooldown has passed a single call is let through as a probe; if it
+// succeeds the circuit closes again.
+//
+// Invalidations that could not be applied are remembered and replayed on
+// recovery, so the cache does not serve data that changed while it was down.
+// Past maxPendingInvalidations they are widened rather than dropped: keys
+// become patterns over their namespace ("products:detail:1" becomes
+// "products:*") and tags give way to flushing every namespace that has been
+// written with tags.
+type CircuitBreakerCache struct {
+	next      CacheProvider
+	threshold int
+	cooldown  time.Duration
+
+	mu       sync.Mutex
+	state    breakerState
+	failures int
+	lastErr  error
+	openedAt time.Time
+
+	pendingKeys     map[string]struct{}
+	pendingPatterns map[string]struct{}
+	pendingTags     map[string]struct{}
+	// compacted is set once the pending sets overflowed; tags are then no
+	// longer tracked and taggedNamespaces are flushed instead
+	compacted        bool
+	taggedNamespaces map[string]struct{}
+}
+
+// NewCircuitBreakerCache wraps next, opening after threshold consecutive
+// failures and probing again after cooldown
+func NewCircuitBreakerCache(next CacheProvider, threshold int, cooldown time.Duration) *CircuitBreakerCache {
+	return &CircuitBreakerCache{
+		next:             next,
+		threshold:        threshold,
+		cooldown:         cooldown,
+		pendingKeys:      make(map[string]struct{}),
+		pendingPatterns:  make(map[string]struct{}),
+		pendingTags:      make(map[string]struct{}),
+		taggedNamespaces: make(map[string]struct{}),
+	}
+}
+
+func (b *CircuitBreakerCache) Get(ctx context.Context, key string) (string, error) {
+	var value string
+	err := b.do(ctx, func() error {
+		var err error
+		value, err = b.next.Get(ctx, key)
+		return err
+	})
+	return value, err
+}
+
+func (b *CircuitBreakerCache) Set(ctx context.Context, key string, value string, expiration time.Duration) error {
+	return b.do(ctx, func() error {
+		return b.next.Set(ctx, key, value, expiration)
+	})
+}
+
+func (b *CircuitBreakerCache) Delete(ctx context.Context, key string) error {
+	err := b.do(ctx, func() error {
+		return b.next.Delete(ctx, key)
+	})
+	if err != nil {
+		b.remember(&b.pendingKeys, key)
+	}
+	return err
+}
+
+func (b *CircuitBreakerCache) DeletePattern(ctx context.Context, pattern string) error {
+	err := b.do(ctx, func() error {
+		return b.next.DeletePattern(ctx, pattern)
+	})
+	if err != nil {
+		b.remember(&b.pendingPatterns, pattern)
+	}
+	return err
+}
+
+func (b *CircuitBreakerCache) SetWithTags(ctx context.Context, key string, value string, expiration time.Duration, tags ...string) error {
+	if len(tags) > 0 {
+		b.mu.Lock()
+		b.taggedNamespaces[namespacePattern(key)] = struct{}{}
+		b.mu.Unlock()
+	}
+	return b.do(ctx, func() error {
+		return b.next.SetWithTags(ctx, key, value, expiration, tags...)
+	})
+}
+
+func (b *CircuitBreakerCache) InvalidateTags(ctx context.Context, tags ...string) error {
+	err := b.do(ctx, func() error {
+		return b.next.InvalidateTags(ctx, tags...)
+	})
+	if err != nil {
+		b.remember(&b.pendingTags, tags...)
+	}
+	return err
+}
+
+func (b *CircuitBreakerCache) ListPushCapped(ctx context.Context, key string, value string, maxLen int64, expiration time.Duration) error {
+	return b.do(ctx, func() error {
+		return b.next.ListPushCapped(ctx, key, value, maxLen, expiration)
+	})
+}
+
+func (b *CircuitBreakerCache) ListPushCappedIfExists(ctx context.Context, key string, value string, maxLen int64, expiration time.Duration) (bool, error) {
+	var pushed bool
+	err := b.do(ctx, func() error {
+		var err error
+		pushed, err = b.next.ListPushCappedIfExists(ctx, key, value, maxLen, expiration)
+		return err
+	})
+	return pushed, err
+}
+
+func (b *CircuitBreakerCache) ListFill(ctx context.Context, key string, values []string, expiration time.Duration, guards ...string) (bool, error) {
+	var filled bool
+	err := b.do(ctx, func() error {
+		var err error
+		filled, err = b.next.ListFill(ctx, key, values, expiration, guards...)
+		return err
+	})
+	return filled, err
+}
+
+func (b *CircuitBreakerCache) ListRange(ctx context.Context, key string, start, stop int64) ([]string, error) {
+	var items []string
+	err := b.do(ctx, func() error {
+		var err error
+		items, err = b.next.ListRange(ctx, key, start, stop)
+		return err
+	})
+	return items, err
+}
+
+// Status reports the circuit state for health checks
+func (b *CircuitBreakerCache) Status() CacheStatus {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	status := CacheStatus{
+		State:                b.state.String(),
+		ConsecutiveFailures:  b.failures,
+		PendingInvalidations: b.pendingCount(),
+		PendingCompacted:     b.compacted,
+	}
+	if b.lastErr != nil {
+		status.LastError = b.lastErr.Error()
+	}
+	if b.state != breakerClosed {
+		openedAt := b.openedAt
+		status.OpenedAt = &openedAt
+	}
+	return status
+}
+
+func (b *CircuitBreakerCache) do(ctx context.Context, op func() error) error {
+	if !b.allow() {
+		return ErrCacheUnavailable
+	}
+	err := op()
+	b.record(ctx, err)
+	return err
+}
+
+// allow reports whether a call may reach the cache. When the cooldown is over
+// the first caller becomes the probe and the rest keep failing fast until it returns.
+func (b *CircuitBreakerCache) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case breakerOpen:
+		if time.Since(b.openedAt) < b.cooldown {
+			return false
+		}
+		b.state = breakerHalfOpen
+		return true
+	case breakerHalfOpen:
+		return false
+	default:
+		return true
+	}
+}
+
+func (b *CircuitBreakerCache) record(ctx context.Context, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch {
+	case err == nil || errors.Is(err, ErrCacheMiss):
+		recovered := b.state != breakerClosed
+		b.state = breakerClosed
+		b.failures = 0
+		if recovered {
+			b.lastErr = nil
+			log.Println("Cache recovered, closing circuit")
+			go b.replay(context.WithoutCancel(ctx))
+		}
+	case ctx.Err() != nil:
+		// The caller gave up; that says nothing about the cache. A probe that
+		// was cut short leaves the circuit open so the next call probes again.
+		if b.state == breakerHalfOpen {
+			b.state = breakerOpen
+		}
+	default:
+		b.failures++
+		b.lastErr = err
+		if b.state == breakerHalfOpen || b.failures >= b.threshold {
+			if b.state == breakerClosed {
+				log.Printf("Cache failing, opening circuit after %d errors: %v", b.failures, err)
+			}
+			b.state = breakerOpen
+			b.openedAt = time.Now()
+		}
+	}
+}
+
+// remember adds values to one of the pending sets. It takes the field's address
+// because replay swaps the sets out under the lock.
+func (b *CircuitBreakerCache) remember(set *map[string]struct{}, values ...string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for _, v := range values {
+		if !b.compacted && b.pendingCount() >= maxPendingInvalidations {
+			b.compact()
+		}
+		switch {
+		case !b.compacted:
+			(*set)[v] = struct{}{}
+		case set == &b.pendingTags:
+			// Covered by flushing taggedNamespaces on recovery
+		default:
+			b.pendingPatterns[namespacePattern(v)] = struct{}{}
+		}
+	}
+}
+
+// compact widens every pending invalidation to its namespace once there are
+// too many to track one by one. The caller holds mu.
+func (b *CircuitBreakerCache) compact() {
+	log.Printf("Too many cache invalidations pending, widening them to whole namespaces")
+	patterns := make(map[string]struct{})
+	for key := range b.pendingKeys {
+		patterns[namespacePattern(key)] = struct{}{}
+	}
+	for pattern := range b.pendingPatterns {
+		patterns[namespacePattern(pattern)] = struct{}{}
+	}
+	b.pendingKeys = make(map[string]struct{})
+	b.pendingPatterns = patterns
+	b.pendingTags = make(map[string]struct{})
+	b.compacted = true
+}
+
+// pendingCount is the number of remembered invalidations. The caller holds mu.
+func (b *CircuitBreakerCache) pendingCount() int {
+	return len(b.pendingKeys) + len(b.pendingPatterns) + len(b.pendingTags)
+}
+
+// namespacePattern returns a pattern for every key sharing s's namespace, the
+// part before its first ':'. Anything without one is kept as is.
+func namespacePattern(s string) string {
+	if i := strings.IndexByte(s, ':'); i >= 0 {
+		return s[:i+1] + "*"
+	}
+	return s
+}
+
+// replay applies the invalidations missed while the cache was unavailable.
+// Any that fail again are remembered for the next recovery.
+func (b *CircuitBreakerCache) replay(ctx context.Context) {
+	b.mu.Lock()
+	keys, patterns, tags := b.pendingKeys, b.pendingPatterns, b.pendingTags
+	if b.compacted {
+		for namespace := range b.taggedNamespaces {
+			patterns[namespace] = struct{}{}
+		}
+	}
+	b.pendingKeys = make(map[string]struct{})
+	b.pendingPatterns = make(map[string]struct{})
+	b.pendingTags = make(map[string]struct{})
+	b.compacted = false
+	b.mu.Unlock()
+
+	if len(keys)+len(patterns)+len(tags) == 0 {
+		return
+	}
+	log.Printf("Replaying %d cache invalidations", len(keys)+len(patterns)+len(tags))
+
+	if len(tags) > 0 {
+		tagList := make([]string, 0, len(tags))
+		for tag := range tags {
+			tagList = append(tagList, tag)
+		}
+		b.InvalidateTags(ctx, tagList...)
+	}
+	for pattern := range patterns {
+		b.DeletePattern(ctx, pattern)
+	}
+	for key := range keys {
+		b.Delete(ctx, key)
+	}
+}
@@ -0,0 +1,296 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+// flakyCache is a MemoryCache that fails every call while down is set
+type flakyCache struct {
+	*MemoryCache
+	mu    sync.Mutex
+	down  bool
+	calls int
+}
+
+var errCacheDown = errors.New("connection refused")
+
+func (f *flakyCache) setDown(down bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.down = down
+}
+
+func (f *flakyCache) check() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls++
+	if f.down {
+		return errCacheDown
+	}
+	return nil
+}
+
+func (f *flakyCache) callCount() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.calls
+}
+
+func (f *flakyCache) Get(ctx context.Context, key string) (string, error) {
+	if err := f.check(); err != nil {
+		return "", err
+	}
+	return f.MemoryCache.Get(ctx, key)
+}
+
+func (f *flakyCache) Delete(ctx context.Context, key string) error {
+	if err := f.check(); err != nil {
+		return err
+	}
+	return f.MemoryCache.Delete(ctx, key)
+}
+
+func (f *flakyCache) DeletePattern(ctx context.Context, pattern string) error {
+	if err := f.check(); err != nil {
+		return err
+	}
+	return f.MemoryCache.DeletePattern(ctx, pattern)
+}
+
+func (f *flakyCache) SetWithTags(ctx context.Context, key string, value string, expiration time.Duration, tags ...string) error {
+	if err := f.check(); err != nil {
+		return err
+	}
+	return f.MemoryCache.SetWithTags(ctx, key, value, expiration, tags...)
+}
+
+func (f *flakyCache) InvalidateTags(ctx context.Context, tags ...string) error {
+	if err := f.check(); err != nil {
+		return err
+	}
+	return f.MemoryCache.InvalidateTags(ctx, tags...)
+}
+
+func newTestBreaker(cooldown time.Duration) (*CircuitBreakerCache, *flakyCache) {
+	flaky := &flakyCache{MemoryCache: NewMemoryCache(0)}
+	return NewCircuitBreakerCache(flaky, 2, cooldown), flaky
+}
+
+func TestCircuitBreakerStateTransitions(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name      string
+		steps     func(b *CircuitBreakerCache, f *flakyCache)
+		wantState string
+		wantCalls int
+	}{
+		{
+			name: "stays closed below the threshold",
+			steps: func(b *CircuitBreakerCache, f *flakyCache) {
+				f.setDown(true)
+				b.Get(ctx, "k")
+			},
+			wantState: "closed",
+			wantCalls: 1,
+		},
+		{
+			name: "misses are not failures",
+			steps: func(b *CircuitBreakerCache, f *flakyCache) {
+				for range 5 {
+					b.Get(ctx, "missing")
+				}
+			},
+			wantState: "closed",
+			wantCalls: 5,
+		},
+		{
+			name: "opens at the threshold and then fails fast",
+			steps: func(b *CircuitBreakerCache, f *flakyCache) {
+				f.setDown(true)
+				for range 5 {
+					b.Get(ctx, "k")
+				}
+			},
+			wantState: "open",
+			wantCalls: 2,
+		},
+		{
+			name: "a success resets the failure count",
+			steps: func(b *CircuitBreakerCache, f *flakyCache) {
+				f.setDown(true)
+				b.Get(ctx, "k")
+				f.setDown(false)
+				b.Get(ctx, "k")
+				f.setDown(true)
+				b.Get(ctx, "k")
+			},
+			wantState: "closed",
+			wantCalls: 3,
+		},
+		{
+			name: "a failed probe reopens",
+			steps: func(b *CircuitBreakerCache, f *flakyCache) {
+				f.setDown(true)
+				b.Get(ctx, "k")
+				b.Get(ctx, "k")
+				time.Sleep(20 * time.Millisecond)
+				b.Get(ctx, "k")
+				b.Get(ctx, "k")
+			},
+			wantState: "open",
+			wantCalls: 3,
+		},
+		{
+			name: "a successful probe closes",
+			steps: func(b *CircuitBreakerCache, f *flakyCache) {
+				f.setDown(true)
+				b.Get(ctx, "k")
+				b.Get(ctx, "k")
+				f.setDown(false)
+				time.Sleep(20 * time.Millisecond)
+				b.Get(ctx, "k")
+				b.Get(ctx, "k")
+			},
+			wantState: "closed",
+			wantCalls: 4,
+		},
+		{
+			name: "a cancelled call is not a failure",
+			steps: func(b *CircuitBreakerCache, f *flakyCache) {
+				cancelled, cancel := context.WithCancel(ctx)
+				cancel()
+				f.setDown(true)
+				b.Get(cancelled, "k")
+				b.Get(cancelled, "k")
+			},
+			wantState: "closed",
+			wantCalls: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			breaker, flaky := newTestBreaker(10 * time.Millisecond)
+			tt.steps(breaker, flaky)
+
+			if got := breaker.Status().State; got != tt.wantState {
+				t.Errorf("state = %s, want %s", got, tt.wantState)
+			}
+			if got := flaky.callCount(); got != tt.wantCalls {
+				t.Errorf("calls reaching the cache = %d, want %d", got, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestCircuitBreakerFailsFastWhileOpen(t *testing.T) {
+	ctx := context.Background()
+	breaker, flaky := newTestBreaker(time.Hour)
+	flaky.setDown(true)
+	breaker.Get(ctx, "k")
+	breaker.Get(ctx, "k")
+
+	if _, err := breaker.Get(ctx, "k"); !errors.Is(err, ErrCacheUnavailable) {
+		t.Errorf("err = %v, want ErrCacheUnavailable", err)
+	}
+	status := breaker.Status()
+	if status.OpenedAt == nil || status.LastError != errCacheDown.Error() || status.ConsecutiveFailures != 2 {
+		t.Errorf("status = %+v", status)
+	}
+}
+
+func TestCircuitBreakerReplaysInvalidations(t *testing.T) {
+	ctx := context.Background()
+	breaker, flaky := newTestBreaker(10 * time.Millisecond)
+	flaky.MemoryCache.Set(ctx, "key", "v", 0)
+	flaky.MemoryCache.Set(ctx, "products:1", "v", 0)
+	flaky.MemoryCache.SetWithTags(ctx, "tagged", "v", 0, "tag")
+	flaky.MemoryCache.Set(ctx, "kept", "v", 0)
+
+	flaky.setDown(true)
+	breaker.Delete(ctx, "key")
+	breaker.DeletePattern(ctx, "products:*")
+	breaker.InvalidateTags(ctx, "tag")
+	if got := breaker.Status().PendingInvalidations; got != 3 {
+		t.Fatalf("pending = %d, want 3", got)
+	}
+
+	flaky.setDown(false)
+	time.Sleep(20 * time.Millisecond)
+	breaker.Get(ctx, "kept")
+
+	waitFor(t, func() bool { return breaker.Status().PendingInvalidations == 0 })
+	waitFor(t, func() bool {
+		for _, key := range []string{"key", "products:1", "tagged"} {
+			if _, err := flaky.MemoryCache.Get(ctx, key); err == nil {
+				return false
+			}
+		}
+		return true
+	})
+	if _, err := flaky.MemoryCache.Get(ctx, "kept"); err != nil {
+		t.Errorf("unrelated key dropped by replay: %v", err)
+	}
+}
+
+func TestCircuitBreakerCompactsOverflowingInvalidations(t *testing.T) {
+	ctx := context.Background()
+	breaker, flaky := newTestBreaker(10 * time.Millisecond)
+
+	// Written through the breaker so it knows products:* holds tagged keys
+	breaker.SetWithTags(ctx, "products:detail:1", "v", 0, "product:1")
+	flaky.MemoryCache.Set(ctx, "session:a", "v", 0)
+	flaky.MemoryCache.Set(ctx, "login-failures:ip:1", "v", 0)
+
+	flaky.setDown(true)
+	breaker.InvalidateTags(ctx, "product:1")
+	for i := range maxPendingInvalidations {
+		breaker.Delete(ctx, fmt.Sprintf("session:%d", i))
+	}
+
+	status := breaker.Status()
+	if !status.PendingCompacted {
+		t.Fatal("pending invalidations not compacted at the bound")
+	}
+	if status.PendingInvalidations >= maxPendingInvalidations {
+		t.Fatalf("pending = %d after compaction", status.PendingInvalidations)
+	}
+
+	flaky.setDown(false)
+	time.Sleep(20 * time.Millisecond)
+	breaker.Get(ctx, "probe")
+
+	waitFor(t, func() bool {
+		_, detailErr := flaky.MemoryCache.Get(ctx, "products:detail:1")
+		_, sessionErr := flaky.MemoryCache.Get(ctx, "session:a")
+		return detailErr != nil && sessionErr != nil
+	})
+	if _, err := flaky.MemoryCache.Get(ctx, "login-failures:ip:1"); err != nil {
+		t.Errorf("key outside the affected namespaces dropped: %v", err)
+	}
+	if breaker.Status().PendingCompacted {
+		t.Error("compacted flag not cleared after replay")
+	}
+}
+
+func TestNamespacePattern(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"products:detail:1", "products:*"},
+		{"products:*", "products:*"},
+		{"session:abc", "session:*"},
+		{"plain", "plain"},
+	}
+	for _, tt := range tests {
+		if got := namespacePattern(tt.in); got != tt.want {
+			t.Errorf("namespacePattern(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,207 @@
+package utils
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// signingKey is one key of a KeyRing. Retired keys only have the public half.
+type signingKey struct {
+	kid     string
+	method  jwt.SigningMethod
+	private crypto.Signer
+	public  crypto.PublicKey
+}
+
+// KeyRing holds the keys tokens are signed and verified with. Tokens name their
+// key in the kid header, so a new key can take over signing while tokens signed
+// with the previous one stay valid until they expire.
+type KeyRing struct {
+	active *signingKey
+	keys   map[string]*signingKey
+}
+
+// JWK is a public key in JSON Web Key form
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+// JWKSet is the body of /.well-known/jwks.json
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+// LoadKeyRing reads every *.pem file in dir. The file name without extension is
+// the key's kid. Private keys (RSA or Ed25519, PKCS#8 or PKCS#1) can sign;
+// public keys are kept to verify tokens signed by a retired key. The active
+// signing key is activeKid, or when that is empty the private key whose kid
+// sorts last, so naming keys by date rotates to the newest.
+//
+// Keys can be created with:
+//
+//	openssl genpkey -algorithm ed25519 -out 2026-10-18.pem
+//	openssl genpkey -algorithm rsa -pkeyopt rsa_keygen_bits:2048 -out 2026-10-18.pem
+func LoadKeyRing(dir, activeKid string) (*KeyRing, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.pem"))
+	if err != nil {
+		return nil, err
+	}
+
+	ring := &KeyRing{keys: make(map[string]*signingKey)}
+	var signers []string
+	for _, path := range paths {
+		kid := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+		key, err := loadSigningKey(path, kid)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load key %s: %w", path, err)
+		}
+		ring.keys[kid] = key
+		if key.private != nil {
+			signers = append(signers, kid)
+		}
+	}
+
+	if len(signers) == 0 {
+		return nil, fmt.Errorf("no private signing keys found in %s", dir)
+	}
+	if activeKid == "" {
+		sort.Strings(signers)
+		activeKid = signers[len(signers)-1]
+	}
+	active, ok := ring.keys[activeKid]
+	if !ok || active.private == nil {
+		return nil, fmt.Errorf("active key %q has no private key in %s", activeKid, dir)
+	}
+	ring.active = active
+	return ring, nil
+}
+
+func loadSigningKey(path, kid string) (*signingKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM block found")
+	}
+
+	var parsed any
+	switch block.Type {
+	case "PRIVATE KEY":
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PUBLIC KEY":
+		parsed, err = x509.ParsePKIXPublicKey(block.Bytes)
+	case "RSA PUBLIC KEY":
+		parsed, err = x509.ParsePKCS1PublicKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("unsupported PEM block %q", block.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	key := &signingKey{kid: kid}
+	switch k := parsed.(type) {
+	case *rsa.PrivateKey:
+		key.method, key.private, key.public = jwt.SigningMethodRS256, k, &k.PublicKey
+	case *rsa.PublicKey:
+		key.method, key.public = jwt.SigningMethodRS256, k
+	case ed25519.PrivateKey:
+		key.method, key.private, key.public = jwt.SigningMethodEdDSA, k, k.Public()
+	case ed25519.PublicKey:
+		key.method, key.public = jwt.SigningMethodEdDSA, k
+	default:
+		return nil, fmt.Errorf("unsupported key type %T; use RSA or Ed25519", parsed)
+	}
+	return key, nil
+}
+
+// Sign signs claims with the active key
+func (k *KeyRing) Sign(claims jwt.Claims) (string, error) {
+	token := jwt.NewWithClaims(k.active.method, claims)
+	token.Header["kid"] = k.active.kid
+	return token.SignedString(k.active.private)
+}
+
+// Keyfunc resolves the verification key named by a token's kid header. The
+// token's algorithm must match the key's, so a public key can never be used as
+// an HMAC secret.
+func (k *KeyRing) Keyfunc(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	key, ok := k.keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+	if token.Method.Alg() != key.method.Alg() {
+		return nil, fmt.Errorf("unexpected signing method %s", token.Method.Alg())
+	}
+	return key.public, nil
+}
+
+// Methods lists the algorithms of the keys in the ring
+func (k *KeyRing) Methods() []string {
+	seen := map[string]bool{}
+	var methods []string
+	for _, key := range k.keys {
+		if alg := key.method.Alg(); !seen[alg] {
+			seen[alg] = true
+			methods = append(methods, alg)
+		}
+	}
+	return methods
+}
+
+// ActiveKid is the kid new tokens are signed with
+func (k *KeyRing) ActiveKid() string {
+	return k.active.kid
+}
+
+// JWKS returns the public half of every key, ordered by kid
+func (k *KeyRing) JWKS() JWKSet {
+	kids := make([]string, 0, len(k.keys))
+	for kid := range k.keys {
+		kids = append(kids, kid)
+	}
+	sort.Strings(kids)
+
+	set := JWKSet{Keys: make([]JWK, 0, len(kids))}
+	for _, kid := range kids {
+		key := k.keys[kid]
+		jwk := JWK{Kid: kid, Use: "sig", Alg: key.method.Alg()}
+		switch pub := key.public.(type) {
+		case *rsa.PublicKey:
+			jwk.Kty = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(pub.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk.Kty = "OKP"
+			jwk.Crv = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(pub)
+		}
+		set.Keys = append(set.Keys, jwk)
+	}
+	return set
+}
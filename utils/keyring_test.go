@@ -0,0 +1,292 @@
+package utils
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// RFC 8037 appendix A.1: an Ed25519 key and its public JWK "x" value
+const (
+	rfc8037Seed = "nWGxne_9WmC6hEr0kuwsxERJxWl7MmkZcDusAxyuf2A"
+	rfc8037X    = "11qYAYKxCrfVS_7TyWQHOg7hcvPapiMlrwIaaPcHURo"
+)
+
+var (
+	testRSAKeyOnce sync.Once
+	testRSAKey     *rsa.PrivateKey
+)
+
+// rsaTestKey generates one RSA key for the whole run, since generation is slow
+func rsaTestKey(t *testing.T) *rsa.PrivateKey {
+	t.Helper()
+	testRSAKeyOnce.Do(func() {
+		key, err := rsa.GenerateKey(rand.Reader, 2048)
+		if err != nil {
+			t.Fatal(err)
+		}
+		testRSAKey = key
+	})
+	return testRSAKey
+}
+
+func rfc8037Key(t *testing.T) ed25519.PrivateKey {
+	t.Helper()
+	seed, err := base64.RawURLEncoding.DecodeString(rfc8037Seed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ed25519.NewKeyFromSeed(seed)
+}
+
+func writePEM(t *testing.T, dir, name, blockType string, der []byte) {
+	t.Helper()
+	data := pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der})
+	if err := os.WriteFile(filepath.Join(dir, name), data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func writePKCS8(t *testing.T, dir, name string, key any) {
+	t.Helper()
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writePEM(t, dir, name, "PRIVATE KEY", der)
+}
+
+func writePublic(t *testing.T, dir, name string, key any) {
+	t.Helper()
+	der, err := x509.MarshalPKIXPublicKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writePEM(t, dir, name, "PUBLIC KEY", der)
+}
+
+func TestLoadKeyRing(t *testing.T) {
+	tests := []struct {
+		name       string
+		files      func(t *testing.T, dir string)
+		activeKid  string
+		wantActive string
+		wantErr    string
+	}{
+		{
+			name: "newest private key signs by default",
+			files: func(t *testing.T, dir string) {
+				writePKCS8(t, dir, "2026-01-01.pem", rfc8037Key(t))
+				writePEM(t, dir, "2026-06-01.pem", "RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(rsaTestKey(t)))
+				writePublic(t, dir, "2027-01-01.pem", rfc8037Key(t).Public())
+			},
+			wantActive: "2026-06-01",
+		},
+		{
+			name: "explicit active kid",
+			files: func(t *testing.T, dir string) {
+				writePKCS8(t, dir, "2026-01-01.pem", rfc8037Key(t))
+				writePKCS8(t, dir, "2026-06-01.pem", rsaTestKey(t))
+			},
+			activeKid:  "2026-01-01",
+			wantActive: "2026-01-01",
+		},
+		{
+			name: "active kid must have a private key",
+			files: func(t *testing.T, dir string) {
+				writePKCS8(t, dir, "current.pem", rfc8037Key(t))
+				writePublic(t, dir, "retired.pem", rsaTestKey(t).Public())
+			},
+			activeKid: "retired",
+			wantErr:   `active key "retired" has no private key`,
+		},
+		{
+			name: "unknown active kid",
+			files: func(t *testing.T, dir string) {
+				writePKCS8(t, dir, "current.pem", rfc8037Key(t))
+			},
+			activeKid: "missing",
+			wantErr:   `active key "missing" has no private key`,
+		},
+		{
+			name: "public keys alone cannot sign",
+			files: func(t *testing.T, dir string) {
+				writePublic(t, dir, "retired.pem", rfc8037Key(t).Public())
+			},
+			wantErr: "no private signing keys found",
+		},
+		{
+			name:    "empty directory",
+			files:   func(t *testing.T, dir string) {},
+			wantErr: "no private signing keys found",
+		},
+		{
+			name: "file without a PEM block",
+			files: func(t *testing.T, dir string) {
+				os.WriteFile(filepath.Join(dir, "junk.pem"), []byte("not a key"), 0o600)
+			},
+			wantErr: "no PEM block found",
+		},
+		{
+			name: "unsupported PEM block",
+			files: func(t *testing.T, dir string) {
+				writePEM(t, dir, "cert.pem", "CERTIFICATE", []byte{0})
+			},
+			wantErr: `unsupported PEM block "CERTIFICATE"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			tt.files(t, dir)
+
+			ring, err := LoadKeyRing(dir, tt.activeKid)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want one containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := ring.ActiveKid(); got != tt.wantActive {
+				t.Errorf("active kid = %s, want %s", got, tt.wantActive)
+			}
+		})
+	}
+}
+
+func TestKeyRingVerifiesAcrossRotation(t *testing.T) {
+	oldDir := t.TempDir()
+	writePKCS8(t, oldDir, "2026-01-01.pem", rsaTestKey(t))
+	oldRing, err := LoadKeyRing(oldDir, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims := jwt.RegisteredClaims{Subject: "user-1", ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour))}
+	issued, err := oldRing.Sign(claims)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The old key is retired to its public half and a new key takes over
+	newDir := t.TempDir()
+	writePublic(t, newDir, "2026-01-01.pem", rsaTestKey(t).Public())
+	writePKCS8(t, newDir, "2026-06-01.pem", rfc8037Key(t))
+	newRing, err := LoadKeyRing(newDir, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parser := jwt.NewParser(jwt.WithValidMethods(newRing.Methods()))
+	if _, err := parser.ParseWithClaims(issued, &jwt.RegisteredClaims{}, newRing.Keyfunc); err != nil {
+		t.Fatalf("token from the retired key rejected: %v", err)
+	}
+
+	fresh, err := newRing.Sign(claims)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, err := parser.ParseWithClaims(fresh, &jwt.RegisteredClaims{}, newRing.Keyfunc)
+	if err != nil || parsed.Header["kid"] != "2026-06-01" || parsed.Method.Alg() != "EdDSA" {
+		t.Fatalf("token from the new key: header %v, err %v", parsed.Header, err)
+	}
+
+	// The retired key cannot sign, so the old ring cannot verify new tokens
+	if _, err := parser.ParseWithClaims(fresh, &jwt.RegisteredClaims{}, oldRing.Keyfunc); err == nil {
+		t.Error("token from an unknown key accepted")
+	}
+}
+
+func TestKeyRingKeyfuncRejects(t *testing.T) {
+	dir := t.TempDir()
+	writePKCS8(t, dir, "rsa.pem", rsaTestKey(t))
+	writePKCS8(t, dir, "ed.pem", rfc8037Key(t))
+	ring, err := LoadKeyRing(dir, "rsa")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rsaDER, _ := x509.MarshalPKIXPublicKey(rsaTestKey(t).Public())
+	tests := []struct {
+		name   string
+		method jwt.SigningMethod
+		kid    any
+		key    any
+	}{
+		// The classic confusion attack: HMAC keyed with the published public key
+		{"HMAC with the public key", jwt.SigningMethodHS256, "rsa", rsaDER},
+		{"algorithm of another key", jwt.SigningMethodEdDSA, "rsa", rfc8037Key(t)},
+		{"unknown kid", jwt.SigningMethodEdDSA, "other", rfc8037Key(t)},
+		{"missing kid", jwt.SigningMethodEdDSA, nil, rfc8037Key(t)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token := jwt.NewWithClaims(tt.method, jwt.RegisteredClaims{Subject: "user-1"})
+			if tt.kid != nil {
+				token.Header["kid"] = tt.kid
+			}
+			signed, err := token.SignedString(tt.key)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := jwt.Parse(signed, ring.Keyfunc); err == nil {
+				t.Error("forged token accepted")
+			}
+		})
+	}
+}
+
+func TestKeyRingJWKS(t *testing.T) {
+	dir := t.TempDir()
+	writePKCS8(t, dir, "b-ed.pem", rfc8037Key(t))
+	writePublic(t, dir, "a-rsa.pem", rsaTestKey(t).Public())
+	ring, err := LoadKeyRing(dir, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	set := ring.JWKS()
+	if len(set.Keys) != 2 {
+		t.Fatalf("got %d keys, want 2", len(set.Keys))
+	}
+
+	rsaJWK, edJWK := set.Keys[0], set.Keys[1]
+	if rsaJWK.Kid != "a-rsa" || edJWK.Kid != "b-ed" {
+		t.Fatalf("keys not ordered by kid: %s, %s", rsaJWK.Kid, edJWK.Kid)
+	}
+
+	want := JWK{Kty: "OKP", Kid: "b-ed", Use: "sig", Alg: "EdDSA", Crv: "Ed25519", X: rfc8037X}
+	if edJWK != want {
+		t.Errorf("Ed25519 JWK = %+v, want %+v", edJWK, want)
+	}
+
+	if rsaJWK.Kty != "RSA" || rsaJWK.Alg != "RS256" || rsaJWK.Use != "sig" || rsaJWK.E != "AQAB" {
+		t.Errorf("RSA JWK = %+v", rsaJWK)
+	}
+	n, err := base64.RawURLEncoding.DecodeString(rsaJWK.N)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if new(big.Int).SetBytes(n).Cmp(rsaTestKey(t).N) != 0 {
+		t.Error("RSA JWK modulus does not match the key")
+	}
+	if rsaJWK.X != "" || rsaJWK.Crv != "" || edJWK.N != "" || edJWK.E != "" {
+		t.Error("JWK carries fields of another key type")
+	}
+}
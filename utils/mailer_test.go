@@ -0,0 +1,218 @@
+package utils
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"mime"
+	"net"
+	"net/textproto"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeSMTPServer speaks just enough SMTP to accept a message over plain TCP.
+// Recipients listed in rejectRcpt are refused with a 550.
+type fakeSMTPServer struct {
+	listener   net.Listener
+	rejectRcpt map[string]bool
+
+	mu   sync.Mutex
+	auth string
+	from string
+	rcpt []string
+	data string
+}
+
+func newFakeSMTPServer(t *testing.T) *fakeSMTPServer {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &fakeSMTPServer{listener: listener, rejectRcpt: map[string]bool{}}
+	t.Cleanup(func() { listener.Close() })
+	go s.serve()
+	return s
+}
+
+func (s *fakeSMTPServer) addr() string {
+	return s.listener.Addr().String()
+}
+
+func (s *fakeSMTPServer) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		go s.handle(conn)
+	}
+}
+
+func (s *fakeSMTPServer) handle(conn net.Conn) {
+	defer conn.Close()
+	text := textproto.NewConn(conn)
+	text.PrintfLine("220 localhost fake SMTP")
+
+	for {
+		line, err := text.ReadLine()
+		if err != nil {
+			return
+		}
+		verb, arg, _ := strings.Cut(line, " ")
+		switch strings.ToUpper(verb) {
+		case "EHLO":
+			text.PrintfLine("250-localhost")
+			text.PrintfLine("250 AUTH PLAIN")
+		case "AUTH":
+			s.mu.Lock()
+			s.auth = strings.TrimPrefix(arg, "PLAIN ")
+			s.mu.Unlock()
+			text.PrintfLine("235 2.7.0 Authentication successful")
+		case "MAIL":
+			s.mu.Lock()
+			s.from = arg
+			s.mu.Unlock()
+			text.PrintfLine("250 OK")
+		case "RCPT":
+			to := strings.Trim(strings.TrimPrefix(arg, "TO:"), "<>")
+			if s.rejectRcpt[to] {
+				text.PrintfLine("550 5.1.1 No such user")
+				continue
+			}
+			s.mu.Lock()
+			s.rcpt = append(s.rcpt, to)
+			s.mu.Unlock()
+			text.PrintfLine("250 OK")
+		case "DATA":
+			text.PrintfLine("354 End data with <CR><LF>.<CR><LF>")
+			body, err := text.ReadDotBytes()
+			if err != nil {
+				return
+			}
+			s.mu.Lock()
+			s.data = string(body)
+			s.mu.Unlock()
+			text.PrintfLine("250 OK: queued")
+		case "QUIT":
+			text.PrintfLine("221 Bye")
+			return
+		default:
+			text.PrintfLine("502 Command not implemented")
+		}
+	}
+}
+
+func TestSMTPMailerSend(t *testing.T) {
+	ctx := context.Background()
+	server := newFakeSMTPServer(t)
+	server.rejectRcpt["nobody@example.com"] = true
+
+	tests := []struct {
+		name     string
+		username string
+		email    Email
+		wantErr  string
+		wantAuth string
+	}{
+		{
+			name:     "delivers with credentials",
+			username: "mailer",
+			email:    Email{To: "jane@example.com", Subject: "Confirm your email address", Body: "Hi Jane,\n\nOpen the link."},
+			wantAuth: "\x00mailer\x00secret",
+		},
+		{
+			name:  "delivers without credentials",
+			email: Email{To: "jane@example.com", Subject: "Bestätigung", Body: "Hallo"},
+		},
+		{
+			name:    "refused recipient",
+			email:   Email{To: "nobody@example.com", Subject: "Hi", Body: "Hi"},
+			wantErr: "SMTP RCPT TO failed",
+		},
+		{
+			name:    "line break in the recipient",
+			email:   Email{To: "jane@example.com\r\nBcc: all@example.com", Subject: "Hi", Body: "Hi"},
+			wantErr: "must not contain line breaks",
+		},
+		{
+			name:    "line break in the subject",
+			email:   Email{To: "jane@example.com", Subject: "Hi\nBcc: all@example.com", Body: "Hi"},
+			wantErr: "must not contain line breaks",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server.mu.Lock()
+			server.auth, server.from, server.rcpt, server.data = "", "", nil, ""
+			server.mu.Unlock()
+
+			password := ""
+			if tt.username != "" {
+				password = "secret"
+			}
+			mailer := NewSMTPMailer(server.addr(), tt.username, password, "shop@example.com")
+
+			err := mailer.Send(ctx, tt.email)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want one containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			server.mu.Lock()
+			defer server.mu.Unlock()
+
+			auth, _ := base64.StdEncoding.DecodeString(server.auth)
+			if string(auth) != tt.wantAuth {
+				t.Errorf("auth = %q, want %q", auth, tt.wantAuth)
+			}
+			if server.from != "FROM:<shop@example.com>" {
+				t.Errorf("MAIL %s", server.from)
+			}
+			if len(server.rcpt) != 1 || server.rcpt[0] != tt.email.To {
+				t.Errorf("recipients = %v", server.rcpt)
+			}
+
+			headers, err := textproto.NewReader(bufio.NewReader(strings.NewReader(server.data))).ReadMIMEHeader()
+			if err != nil {
+				t.Fatalf("malformed message %q: %v", server.data, err)
+			}
+			if headers.Get("From") != "shop@example.com" || headers.Get("To") != tt.email.To {
+				t.Errorf("headers = %v", headers)
+			}
+			if subject, err := new(mime.WordDecoder).DecodeHeader(headers.Get("Subject")); err != nil || subject != tt.email.Subject {
+				t.Errorf("Subject = %q (%v), want %q", subject, err, tt.email.Subject)
+			}
+			if _, err := time.Parse(time.RFC1123Z, headers.Get("Date")); err != nil {
+				t.Errorf("Date: %v", err)
+			}
+			_, body, _ := strings.Cut(server.data, "\n\n")
+			if want := strings.ReplaceAll(tt.email.Body, "\r\n", "\n") + "\n"; body != want {
+				t.Errorf("body = %q, want %q", body, want)
+			}
+		})
+	}
+}
+
+func TestSMTPMailerUnreachable(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := listener.Addr().String()
+	listener.Close()
+
+	err = NewSMTPMailer(addr, "", "", "shop@example.com").Send(context.Background(), Email{To: "jane@example.com", Subject: "Hi", Body: "Hi"})
+	if err == nil || !strings.Contains(err.Error(), "failed to connect") {
+		t.Fatalf("err = %v, want a connection error", err)
+	}
+}
@@ -0,0 +1,284 @@
+package utils
+
+import (
+	"container/list"
+	"context"
+	"fmt"
+	"path"
+	"sync"
+	"time"
+)
+
+// MemoryCache implements CacheProvider in process. It holds at most maxEntries
+// keys, evicting the least recently used one when full, and expires keys lazily
+// when they are next touched. Patterns follow path.Match, which agrees with
+// Redis globs for keys without a '/'.
+type MemoryCache struct {
+	mu         sync.Mutex
+	maxEntries int
+	lru        *list.List
+	entries    map[string]*list.Element
+	tags       map[string]map[string]struct{}
+}
+
+type memoryEntry struct {
+	key       string
+	value     string
+	items     []string
+	isList    bool
+	tags      []string
+	expiresAt time.Time
+}
+
+// NewMemoryCache creates an in-process cache holding up to maxEntries keys
+func NewMemoryCache(maxEntries int) *MemoryCache {
+	return &MemoryCache{
+		maxEntries: maxEntries,
+		lru:        list.New(),
+		entries:    make(map[string]*list.Element),
+		tags:       make(map[string]map[string]struct{}),
+	}
+}
+
+func (m *MemoryCache) Get(ctx context.Context, key string) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	entry := m.lookup(key)
+	if entry == nil {
+		return "", ErrCacheMiss
+	}
+	if entry.isList {
+		return "", fmt.Errorf("cache key %s holds a list", key)
+	}
+	return entry.value, nil
+}
+
+func (m *MemoryCache) Set(ctx context.Context, key string, value string, expiration time.Duration) error {
+	return m.SetWithTags(ctx, key, value, expiration)
+}
+
+func (m *MemoryCache) Delete(ctx context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if elem, ok := m.entries[key]; ok {
+		m.remove(elem)
+	}
+	return nil
+}
+
+func (m *MemoryCache) DeletePattern(ctx context.Context, pattern string) error {
+	if _, err := path.Match(pattern, ""); err != nil {
+		return fmt.Errorf("invalid cache pattern %q: %w", pattern, err)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for key, elem := range m.entries {
+		if matched, _ := path.Match(pattern, key); matched {
+			m.remove(elem)
+		}
+	}
+	return nil
+}
+
+func (m *MemoryCache) SetWithTags(ctx context.Context, key string, value string, expiration time.Duration, tags ...string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	entry := m.put(key, expiration)
+	entry.value = value
+	entry.items = nil
+	entry.isList = false
+	for _, tag := range tags {
+		m.tag(entry, tag)
+	}
+	return nil
+}
+
+func (m *MemoryCache) InvalidateTags(ctx context.Context, tags ...string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, tag := range tags {
+		for key := range m.tags[tag] {
+			if elem, ok := m.entries[key]; ok {
+				m.remove(elem)
+			}
+		}
+		delete(m.tags, tag)
+	}
+	return nil
+}
+
+func (m *MemoryCache) ListPushCapped(ctx context.Context, key string, value string, maxLen int64, expiration time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.pushCapped(key, value, maxLen, expiration)
+}
+
+func (m *MemoryCache) ListPushCappedIfExists(ctx context.Context, key string, value string, maxLen int64, expiration time.Duration) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.lookup(key) == nil {
+		return false, nil
+	}
+	if err := m.pushCapped(key, value, maxLen, expiration); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (m *MemoryCache) ListFill(ctx context.Context, key string, values []string, expiration time.Duration, guards ...string) (bool, error) {
+	if len(values) == 0 {
+		return false, nil
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, k := range append([]string{key}, guards...) {
+		if m.lookup(k) != nil {
+			return false, nil
+		}
+	}
+
+	entry := m.put(key, expiration)
+	entry.items = append([]string(nil), values...)
+	entry.isList = true
+	return true, nil
+}
+
+// pushCapped implements ListPushCapped. The caller holds mu.
+func (m *MemoryCache) pushCapped(key string, value string, maxLen int64, expiration time.Duration) error {
+	var items []string
+	if entry := m.lookup(key); entry != nil {
+		if !entry.isList {
+			return fmt.Errorf("cache key %s does not hold a list", key)
+		}
+		items = entry.items
+	}
+
+	updated := make([]string, 0, len(items)+1)
+	updated = append(updated, value)
+	for _, item := range items {
+		if item != value {
+			updated = append(updated, item)
+		}
+	}
+	if maxLen >= 0 && int64(len(updated)) > maxLen {
+		updated = updated[:maxLen]
+	}
+
+	entry := m.put(key, expiration)
+	entry.value = ""
+	entry.items = updated
+	entry.isList = true
+	return nil
+}
+
+func (m *MemoryCache) ListRange(ctx context.Context, key string, start, stop int64) ([]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	entry := m.lookup(key)
+	if entry == nil {
+		return []string{}, nil
+	}
+	if !entry.isList {
+		return nil, fmt.Errorf("cache key %s does not hold a list", key)
+	}
+
+	// Negative indexes count from the end, as in LRANGE
+	n := int64(len(entry.items))
+	if start < 0 {
+		start = max(n+start, 0)
+	}
+	if stop < 0 {
+		stop = n + stop
+	}
+	stop = min(stop, n-1)
+	if start > stop {
+		return []string{}, nil
+	}
+
+	result := make([]string, stop-start+1)
+	copy(result, entry.items[start:stop+1])
+	return result, nil
+}
+
+// Flush drops every key
+func (m *MemoryCache) Flush() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.lru.Init()
+	m.entries = make(map[string]*list.Element)
+	m.tags = make(map[string]map[string]struct{})
+}
+
+// lookup returns the live entry for key and marks it recently used. The caller holds mu.
+func (m *MemoryCache) lookup(key string) *memoryEntry {
+	elem, ok := m.entries[key]
+	if !ok {
+		return nil
+	}
+	entry := elem.Value.(*memoryEntry)
+	if !entry.expiresAt.IsZero() && time.Now().After(entry.expiresAt) {
+		m.remove(elem)
+		return nil
+	}
+	m.lru.MoveToFront(elem)
+	return entry
+}
+
+// put returns the entry for key, replacing any previous one, and evicts the
+// least recently used keys beyond maxEntries. An expiration of zero never
+// expires, as in Redis. The caller holds mu.
+func (m *MemoryCache) put(key string, expiration time.Duration) *memoryEntry {
+	if elem, ok := m.entries[key]; ok {
+		m.remove(elem)
+	}
+
+	entry := &memoryEntry{key: key}
+	if expiration > 0 {
+		entry.expiresAt = time.Now().Add(expiration)
+	}
+	m.entries[key] = m.lru.PushFront(entry)
+
+	for m.maxEntries > 0 && m.lru.Len() > m.maxEntries {
+		m.remove(m.lru.Back())
+	}
+	return entry
+}
+
+// tag records entry under tag. The caller holds mu.
+func (m *MemoryCache) tag(entry *memoryEntry, tag string) {
+	keys, ok := m.tags[tag]
+	if !ok {
+		keys = make(map[string]struct{})
+		m.tags[tag] = keys
+	}
+	if _, tagged := keys[entry.key]; !tagged {
+		keys[entry.key] = struct{}{}
+		entry.tags = append(entry.tags, tag)
+	}
+}
+
+// remove drops an entry and its tag memberships. The caller holds mu.
+func (m *MemoryCache) remove(elem *list.Element) {
+	entry := m.lru.Remove(elem).(*memoryEntry)
+	delete(m.entries, entry.key)
+	for _, tag := range entry.tags {
+		if keys, ok := m.tags[tag]; ok {
+			delete(keys, entry.key)
+			if len(keys) == 0 {
+				delete(m.tags, tag)
+			}
+		}
+	}
+}
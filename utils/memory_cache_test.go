@@ -0,0 +1,227 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestMemoryCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	tests := []struct {
+		name    string
+		touch   []string // keys read after a, b and c are written, in order
+		add     string
+		evicted string
+	}{
+		{"oldest write goes first", nil, "d", "a"},
+		{"a read keeps a key", []string{"a"}, "d", "b"},
+		{"reads reorder every key", []string{"a", "b"}, "d", "c"},
+		{"overwriting a key does not evict", nil, "b", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			cache := NewMemoryCache(3)
+			for _, key := range []string{"a", "b", "c"} {
+				cache.Set(ctx, key, key, 0)
+			}
+			for _, key := range tt.touch {
+				cache.Get(ctx, key)
+			}
+			cache.Set(ctx, tt.add, "new", 0)
+
+			for _, key := range []string{"a", "b", "c", "d"} {
+				_, err := cache.Get(ctx, key)
+				want := key != tt.evicted && (key != "d" || tt.add == "d")
+				if got := err == nil; got != want {
+					t.Errorf("%s present = %v, want %v", key, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestMemoryCacheExpiry(t *testing.T) {
+	ctx := context.Background()
+	cache := NewMemoryCache(0)
+
+	cache.Set(ctx, "short", "v", time.Millisecond)
+	cache.Set(ctx, "forever", "v", 0)
+	time.Sleep(5 * time.Millisecond)
+
+	if _, err := cache.Get(ctx, "short"); !errors.Is(err, ErrCacheMiss) {
+		t.Errorf("expired key: err = %v, want ErrCacheMiss", err)
+	}
+	if _, err := cache.Get(ctx, "forever"); err != nil {
+		t.Errorf("key without expiration: %v", err)
+	}
+}
+
+func TestMemoryCacheTagsAndPatterns(t *testing.T) {
+	tests := []struct {
+		name      string
+		drop      func(ctx context.Context, c *MemoryCache) error
+		remaining []string
+	}{
+		{
+			name:      "tag drops only its keys",
+			drop:      func(ctx context.Context, c *MemoryCache) error { return c.InvalidateTags(ctx, "product:1") },
+			remaining: []string{"list", "product:2"},
+		},
+		{
+			name:      "shared tag drops every tagged key",
+			drop:      func(ctx context.Context, c *MemoryCache) error { return c.InvalidateTags(ctx, "products") },
+			remaining: []string{"list"},
+		},
+		{
+			name:      "unknown tag is a no-op",
+			drop:      func(ctx context.Context, c *MemoryCache) error { return c.InvalidateTags(ctx, "missing") },
+			remaining: []string{"list", "product:1", "product:2"},
+		},
+		{
+			name:      "pattern matches like a Redis glob",
+			drop:      func(ctx context.Context, c *MemoryCache) error { return c.DeletePattern(ctx, "product:*") },
+			remaining: []string{"list"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			cache := NewMemoryCache(0)
+			cache.SetWithTags(ctx, "product:1", "v", 0, "product:1", "products")
+			cache.SetWithTags(ctx, "product:2", "v", 0, "product:2", "products")
+			cache.Set(ctx, "list", "v", 0)
+
+			if err := tt.drop(ctx, cache); err != nil {
+				t.Fatal(err)
+			}
+			var remaining []string
+			for _, key := range []string{"list", "product:1", "product:2"} {
+				if _, err := cache.Get(ctx, key); err == nil {
+					remaining = append(remaining, key)
+				}
+			}
+			if !slices.Equal(remaining, tt.remaining) {
+				t.Errorf("remaining = %v, want %v", remaining, tt.remaining)
+			}
+		})
+	}
+}
+
+func TestMemoryCacheRetagAfterOverwrite(t *testing.T) {
+	ctx := context.Background()
+	cache := NewMemoryCache(0)
+	cache.SetWithTags(ctx, "key", "old", 0, "old-tag")
+	cache.SetWithTags(ctx, "key", "new", 0, "new-tag")
+
+	// The overwrite replaced the entry, so the old tag no longer reaches it
+	cache.InvalidateTags(ctx, "old-tag")
+	if got, err := cache.Get(ctx, "key"); err != nil || got != "new" {
+		t.Fatalf("Get = %q, %v, want the new value", got, err)
+	}
+	cache.InvalidateTags(ctx, "new-tag")
+	if _, err := cache.Get(ctx, "key"); !errors.Is(err, ErrCacheMiss) {
+		t.Errorf("key survived invalidation of its tag: %v", err)
+	}
+}
+
+func TestMemoryCacheLists(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("push moves duplicates to the front and caps", func(t *testing.T) {
+		cache := NewMemoryCache(0)
+		for _, v := range []string{"1", "2", "3", "2", "4"} {
+			if err := cache.ListPushCapped(ctx, "list", v, 3, time.Hour); err != nil {
+				t.Fatal(err)
+			}
+		}
+		got, _ := cache.ListRange(ctx, "list", 0, -1)
+		if want := []string{"4", "2", "3"}; !slices.Equal(got, want) {
+			t.Errorf("list = %v, want %v", got, want)
+		}
+	})
+
+	t.Run("push if exists skips missing lists", func(t *testing.T) {
+		cache := NewMemoryCache(0)
+		pushed, err := cache.ListPushCappedIfExists(ctx, "list", "1", 3, time.Hour)
+		if err != nil || pushed {
+			t.Fatalf("push to a missing list = %v, %v, want false", pushed, err)
+		}
+		if got, _ := cache.ListRange(ctx, "list", 0, -1); len(got) != 0 {
+			t.Fatalf("list created by a conditional push: %v", got)
+		}
+
+		cache.ListFill(ctx, "list", []string{"1"}, time.Hour)
+		pushed, err = cache.ListPushCappedIfExists(ctx, "list", "2", 3, time.Hour)
+		if err != nil || !pushed {
+			t.Fatalf("push to a cached list = %v, %v, want true", pushed, err)
+		}
+		got, _ := cache.ListRange(ctx, "list", 0, -1)
+		if want := []string{"2", "1"}; !slices.Equal(got, want) {
+			t.Errorf("list = %v, want %v", got, want)
+		}
+	})
+
+	fills := []struct {
+		name       string
+		setup      func(c *MemoryCache)
+		values     []string
+		wantFilled bool
+		want       []string
+	}{
+		{"fills a missing list", func(c *MemoryCache) {}, []string{"a", "b"}, true, []string{"a", "b"}},
+		{"keeps an existing list", func(c *MemoryCache) { c.ListPushCapped(ctx, "list", "x", 5, time.Hour) }, []string{"a"}, false, []string{"x"}},
+		{"blocked by a guard key", func(c *MemoryCache) { c.Set(ctx, "guard", "1", time.Hour) }, []string{"a"}, false, []string{}},
+		{"nothing to fill", func(c *MemoryCache) {}, nil, false, []string{}},
+	}
+	for _, tt := range fills {
+		t.Run("ListFill "+tt.name, func(t *testing.T) {
+			cache := NewMemoryCache(0)
+			tt.setup(cache)
+			filled, err := cache.ListFill(ctx, "list", tt.values, time.Hour, "guard")
+			if err != nil || filled != tt.wantFilled {
+				t.Fatalf("ListFill = %v, %v, want %v", filled, err, tt.wantFilled)
+			}
+			got, _ := cache.ListRange(ctx, "list", 0, -1)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("list = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("range follows LRANGE indexes", func(t *testing.T) {
+		cache := NewMemoryCache(0)
+		cache.ListFill(ctx, "list", []string{"a", "b", "c", "d"}, time.Hour)
+		ranges := []struct {
+			start, stop int64
+			want        []string
+		}{
+			{0, 1, []string{"a", "b"}},
+			{-2, -1, []string{"c", "d"}},
+			{2, 10, []string{"c", "d"}},
+			{3, 1, []string{}},
+			{-10, 0, []string{"a"}},
+		}
+		for _, r := range ranges {
+			if got, _ := cache.ListRange(ctx, "list", r.start, r.stop); !slices.Equal(got, r.want) {
+				t.Errorf("ListRange(%d, %d) = %v, want %v", r.start, r.stop, got, r.want)
+			}
+		}
+	})
+
+	t.Run("string and list keys do not mix", func(t *testing.T) {
+		cache := NewMemoryCache(0)
+		cache.Set(ctx, "str", "v", 0)
+		cache.ListFill(ctx, "list", []string{"a"}, 0)
+		if _, err := cache.ListRange(ctx, "str", 0, -1); err == nil {
+			t.Error("ListRange on a string key succeeded")
+		}
+		if _, err := cache.Get(ctx, "list"); err == nil {
+			t.Error("Get on a list key succeeded")
+		}
+	})
+}
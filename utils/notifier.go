@@ -0,0 +1,77 @@
+package utils
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+// Notification is a message addressed to a single customer
+type Notification struct {
+	Kind      string                 `json:"kind"`
+	UserID    string                 `json:"userId"`
+	To        string                 `json:"to"`
+	Subject   string                 `json:"subject"`
+	Body      string                 `json:"body"`
+	Data      map[string]interface{} `json:"data,omitempty"`
+	CreatedAt time.Time              `json:"createdAt"`
+}
+
+// Notifier delivers customer notifications, e.g. by email or push
+type Notifier interface {
+	Notify(ctx context.Context, n Notification) error
+}
+
+// LogNotifier writes notifications to the application log. It is meant for local development.
+type LogNotifier struct{}
+
+func NewLogNotifier() Notifier {
+	return LogNotifier{}
+}
+
+func (LogNotifier) Notify(ctx context.Context, n Notification) error {
+	log.Printf("Notification [%s] to %s: %s", n.Kind, n.To, n.Subject)
+	return nil
+}
+
+// FileNotifier appends notifications as JSON lines to a file, so they can be
+// inspected or replayed without a delivery provider
+type FileNotifier struct {
+	mu   sync.Mutex
+	path string
+}
+
+func NewFileNotifier(path string) (Notifier, error) {
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open notification file: %w", err)
+	}
+	f.Close()
+	return &FileNotifier{path: path}, nil
+}
+
+func (f *FileNotifier) Notify(ctx context.Context, n Notification) error {
+	if n.CreatedAt.IsZero() {
+		n.CreatedAt = time.Now()
+	}
+	line, err := json.Marshal(n)
+	if err != nil {
+		return fmt.Errorf("failed to marshal notification: %w", err)
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	file, err := os.OpenFile(f.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open notification file: %w", err)
+	}
+	defer file.Close()
+
+	_, err = file.Write(append(line, '\n'))
+	return err
+}
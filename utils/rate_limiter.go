@@ -0,0 +1,44 @@
+package utils
+
+import (
+	"context"
+	"strconv"
+	"time"
+)
+
+// RateLimiter allows at most limit attempts per key within a sliding window.
+// Attempts are kept in the cache as a capped list of timestamps, so the limit
+// holds across instances that share it.
+type RateLimiter struct {
+	cache  CacheProvider
+	limit  int
+	window time.Duration
+}
+
+func NewRateLimiter(cache CacheProvider, limit int, window time.Duration) *RateLimiter {
+	return &RateLimiter{cache: cache, limit: limit, window: window}
+}
+
+// Allow records an attempt for key and reports whether it is within the limit.
+// It fails open: when the cache is unreachable the attempt is allowed and the
+// error returned for the caller to log.
+func (l *RateLimiter) Allow(ctx context.Context, key string) (bool, error) {
+	now := time.Now()
+	if err := l.cache.ListPushCapped(ctx, key, strconv.FormatInt(now.UnixNano(), 10), int64(l.limit+1), l.window); err != nil {
+		return true, err
+	}
+
+	attempts, err := l.cache.ListRange(ctx, key, 0, -1)
+	if err != nil {
+		return true, err
+	}
+
+	since := now.Add(-l.window).UnixNano()
+	recent := 0
+	for _, attempt := range attempts {
+		if at, err := strconv.ParseInt(attempt, 10, 64); err == nil && at > since {
+			recent++
+		}
+	}
+	return recent <= l.limit, nil
+}
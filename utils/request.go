@@ -0,0 +1,28 @@
+package utils
+
+import (
+	"net"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// trustProxyHeaders enables X-Forwarded-For, which any client can forge unless
+// the app only receives traffic through a proxy that overwrites it
+var trustProxyHeaders = os.Getenv("TRUST_PROXY_HEADERS") == "true"
+
+// ClientIP returns the address of the client that sent r
+func ClientIP(r *http.Request) string {
+	if trustProxyHeaders {
+		if forwarded := r.Header.Get("X-Forwarded-For"); forwarded != "" {
+			first, _, _ := strings.Cut(forwarded, ",")
+			return strings.TrimSpace(first)
+		}
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
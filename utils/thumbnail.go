@@ -0,0 +1,82 @@
+package utils
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/gif"
+	"image/jpeg"
+	_ "image/png"
+
+	"github.com/HugoSmits86/nativewebp"
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+const thumbnailJPEGQuality = 82
+
+// Thumbnail is a resized, re-encoded rendition of a source image
+type Thumbnail struct {
+	Width       int
+	Height      int
+	ContentType string
+	Data        []byte
+}
+
+// DecodeImageConfig returns the dimensions of an encoded image without decoding its pixels
+func DecodeImageConfig(src []byte) (image.Config, error) {
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(src))
+	return cfg, err
+}
+
+// GenerateThumbnails decodes src and renders a JPEG and a WebP thumbnail for every
+// width smaller than the source image. Images are never upscaled.
+func GenerateThumbnails(src []byte, widths []int) ([]Thumbnail, error) {
+	img, _, err := image.Decode(bytes.NewReader(src))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode image: %w", err)
+	}
+
+	bounds := img.Bounds()
+	var thumbs []Thumbnail
+	for _, width := range widths {
+		if width <= 0 || width >= bounds.Dx() {
+			continue
+		}
+		height := bounds.Dy() * width / bounds.Dx()
+		if height < 1 {
+			height = 1
+		}
+
+		resized := image.NewNRGBA(image.Rect(0, 0, width, height))
+		draw.CatmullRom.Scale(resized, resized.Bounds(), img, bounds, draw.Src, nil)
+
+		jpegData, err := encodeJPEG(resized)
+		if err != nil {
+			return nil, err
+		}
+		thumbs = append(thumbs, Thumbnail{Width: width, Height: height, ContentType: "image/jpeg", Data: jpegData})
+
+		var webpBuf bytes.Buffer
+		if err := nativewebp.Encode(&webpBuf, resized, nil); err != nil {
+			return nil, fmt.Errorf("failed to encode webp thumbnail: %w", err)
+		}
+		thumbs = append(thumbs, Thumbnail{Width: width, Height: height, ContentType: "image/webp", Data: webpBuf.Bytes()})
+	}
+
+	return thumbs, nil
+}
+
+// encodeJPEG flattens img onto a white background, since JPEG has no alpha channel
+func encodeJPEG(img image.Image) ([]byte, error) {
+	flat := image.NewRGBA(img.Bounds())
+	draw.Draw(flat, flat.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	draw.Draw(flat, flat.Bounds(), img, img.Bounds().Min, draw.Over)
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, flat, &jpeg.Options{Quality: thumbnailJPEGQuality}); err != nil {
+		return nil, fmt.Errorf("failed to encode jpeg thumbnail: %w", err)
+	}
+	return buf.Bytes(), nil
+}
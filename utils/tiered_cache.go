@@ -0,0 +1,204 @@
+package utils
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"log"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// TieredCache implements CacheProvider with an in-process L1 in front of Redis.
+// Reads are served from L1 when possible and filled from Redis otherwise.
+// Writes go to Redis first and are then announced on a pub/sub channel so
+// other instances drop their L1 copies. A missed announcement can leave an
+// instance serving an old value for at most l1TTL.
+//
+// Lists are always read from and written to Redis, since they back per-user
+// state that is updated on nearly every read.
+type TieredCache struct {
+	l1      *MemoryCache
+	l2      CacheProvider
+	client  *redis.Client
+	channel string
+	l1TTL   time.Duration
+	origin  string
+}
+
+// cacheInvalidation is the message published when this instance changes Redis
+type cacheInvalidation struct {
+	Origin   string   `json:"origin"`
+	Keys     []string `json:"keys,omitempty"`
+	Patterns []string `json:"patterns,omitempty"`
+	Tags     []string `json:"tags,omitempty"`
+}
+
+// NewTieredCache creates a two-tier cache over client. Call Listen to apply
+// invalidations published by other instances.
+func NewTieredCache(client *redis.Client, l1 *MemoryCache, l1TTL time.Duration, channel string) *TieredCache {
+	return &TieredCache{
+		l1:      l1,
+		l2:      NewRedisCache(client),
+		client:  client,
+		channel: channel,
+		l1TTL:   l1TTL,
+		origin:  newCacheOrigin(),
+	}
+}
+
+func newCacheOrigin() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return time.Now().Format(time.RFC3339Nano)
+	}
+	return hex.EncodeToString(b)
+}
+
+func (t *TieredCache) Get(ctx context.Context, key string) (string, error) {
+	if value, err := t.l1.Get(ctx, key); err == nil {
+		return value, nil
+	}
+
+	value, err := t.l2.Get(ctx, key)
+	if err != nil {
+		return "", err
+	}
+	t.l1.Set(ctx, key, value, t.l1TTL)
+	return value, nil
+}
+
+func (t *TieredCache) Set(ctx context.Context, key string, value string, expiration time.Duration) error {
+	return t.SetWithTags(ctx, key, value, expiration)
+}
+
+func (t *TieredCache) Delete(ctx context.Context, key string) error {
+	err := t.l2.Delete(ctx, key)
+	t.l1.Delete(ctx, key)
+	t.publish(ctx, cacheInvalidation{Keys: []string{key}})
+	return err
+}
+
+func (t *TieredCache) DeletePattern(ctx context.Context, pattern string) error {
+	err := t.l2.DeletePattern(ctx, pattern)
+	t.l1.DeletePattern(ctx, pattern)
+	t.publish(ctx, cacheInvalidation{Patterns: []string{pattern}})
+	return err
+}
+
+func (t *TieredCache) SetWithTags(ctx context.Context, key string, value string, expiration time.Duration, tags ...string) error {
+	if err := t.l2.SetWithTags(ctx, key, value, expiration, tags...); err != nil {
+		return err
+	}
+	t.l1.SetWithTags(ctx, key, value, t.localTTL(expiration), tags...)
+	t.publish(ctx, cacheInvalidation{Keys: []string{key}})
+	return nil
+}
+
+// InvalidateTags resolves the tagged keys from Redis before dropping them, since
+// L1 copies filled by Get do not know their tags
+func (t *TieredCache) InvalidateTags(ctx context.Context, tags ...string) error {
+	var keys []string
+	for _, tag := range tags {
+		members, err := t.client.SMembers(ctx, tagSetKey(tag)).Result()
+		if err != nil {
+			return err
+		}
+		keys = append(keys, members...)
+	}
+
+	err := t.l2.InvalidateTags(ctx, tags...)
+	for _, key := range keys {
+		t.l1.Delete(ctx, key)
+	}
+	t.l1.InvalidateTags(ctx, tags...)
+	t.publish(ctx, cacheInvalidation{Keys: keys, Tags: tags})
+	return err
+}
+
+func (t *TieredCache) ListPushCapped(ctx context.Context, key string, value string, maxLen int64, expiration time.Duration) error {
+	return t.l2.ListPushCapped(ctx, key, value, maxLen, expiration)
+}
+
+func (t *TieredCache) ListPushCappedIfExists(ctx context.Context, key string, value string, maxLen int64, expiration time.Duration) (bool, error) {
+	return t.l2.ListPushCappedIfExists(ctx, key, value, maxLen, expiration)
+}
+
+func (t *TieredCache) ListFill(ctx context.Context, key string, values []string, expiration time.Duration, guards ...string) (bool, error) {
+	return t.l2.ListFill(ctx, key, values, expiration, guards...)
+}
+
+func (t *TieredCache) ListRange(ctx context.Context, key string, start, stop int64) ([]string, error) {
+	return t.l2.ListRange(ctx, key, start, stop)
+}
+
+// localTTL keeps L1 copies no longer than l1TTL or the Redis expiration
+func (t *TieredCache) localTTL(expiration time.Duration) time.Duration {
+	if expiration > 0 && expiration < t.l1TTL {
+		return expiration
+	}
+	return t.l1TTL
+}
+
+func (t *TieredCache) publish(ctx context.Context, msg cacheInvalidation) {
+	msg.Origin = t.origin
+	payload, err := json.Marshal(msg)
+	if err != nil {
+		log.Printf("Failed to encode cache invalidation: %v", err)
+		return
+	}
+	if err := t.client.Publish(ctx, t.channel, payload).Err(); err != nil {
+		log.Printf("Failed to publish cache invalidation: %v", err)
+	}
+}
+
+// Listen applies invalidations published by other instances until ctx is
+// cancelled. L1 is flushed whenever the subscription is (re)established, since
+// messages sent while disconnected are lost.
+func (t *TieredCache) Listen(ctx context.Context) {
+	pubsub := t.client.Subscribe(ctx, t.channel)
+	defer pubsub.Close()
+
+	messages := pubsub.ChannelWithSubscriptions()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case m, ok := <-messages:
+			if !ok {
+				return
+			}
+			switch m := m.(type) {
+			case *redis.Subscription:
+				if m.Kind == "subscribe" {
+					t.l1.Flush()
+				}
+			case *redis.Message:
+				t.apply(ctx, m.Payload)
+			}
+		}
+	}
+}
+
+func (t *TieredCache) apply(ctx context.Context, payload string) {
+	var msg cacheInvalidation
+	if err := json.Unmarshal([]byte(payload), &msg); err != nil {
+		log.Printf("Ignoring malformed cache invalidation: %v", err)
+		return
+	}
+	if msg.Origin == t.origin {
+		return
+	}
+
+	for _, key := range msg.Keys {
+		t.l1.Delete(ctx, key)
+	}
+	for _, pattern := range msg.Patterns {
+		t.l1.DeletePattern(ctx, pattern)
+	}
+	if len(msg.Tags) > 0 {
+		t.l1.InvalidateTags(ctx, msg.Tags...)
+	}
+}
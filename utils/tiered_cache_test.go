@@ -0,0 +1,140 @@
+package utils
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// newTestTieredCache builds a TieredCache whose L2 is a second MemoryCache. Its
+// Redis client points nowhere, so publishing fails fast and is only logged.
+func newTestTieredCache(l1TTL time.Duration) (*TieredCache, *MemoryCache, *MemoryCache) {
+	client := redis.NewClient(&redis.Options{Addr: "127.0.0.1:1", MaxRetries: -1, DialTimeout: 50 * time.Millisecond})
+	l1, l2 := NewMemoryCache(10), NewMemoryCache(0)
+	tiered := NewTieredCache(client, l1, l1TTL, "test-invalidations")
+	tiered.l2 = l2
+	return tiered, l1, l2
+}
+
+func TestTieredCacheReadThrough(t *testing.T) {
+	ctx := context.Background()
+	tiered, l1, l2 := newTestTieredCache(time.Minute)
+	defer tiered.client.Close()
+
+	l2.Set(ctx, "key", "value", time.Hour)
+	if got, err := tiered.Get(ctx, "key"); err != nil || got != "value" {
+		t.Fatalf("Get = %q, %v", got, err)
+	}
+	if got, err := l1.Get(ctx, "key"); err != nil || got != "value" {
+		t.Errorf("L1 not filled from L2: %q, %v", got, err)
+	}
+
+	// L1 answers on its own once filled
+	l2.Delete(ctx, "key")
+	if got, err := tiered.Get(ctx, "key"); err != nil || got != "value" {
+		t.Errorf("Get after L2 delete = %q, %v, want the L1 copy", got, err)
+	}
+
+	if _, err := tiered.Get(ctx, "missing"); !errors.Is(err, ErrCacheMiss) {
+		t.Errorf("Get of a missing key: err = %v, want ErrCacheMiss", err)
+	}
+}
+
+func TestTieredCacheLocalTTL(t *testing.T) {
+	tiered, _, _ := newTestTieredCache(time.Minute)
+	defer tiered.client.Close()
+
+	tests := []struct {
+		expiration, want time.Duration
+	}{
+		{time.Second, time.Second},
+		{time.Hour, time.Minute},
+		{0, time.Minute},
+	}
+	for _, tt := range tests {
+		if got := tiered.localTTL(tt.expiration); got != tt.want {
+			t.Errorf("localTTL(%s) = %s, want %s", tt.expiration, got, tt.want)
+		}
+	}
+}
+
+func TestTieredCacheAppliesRemoteInvalidations(t *testing.T) {
+	tests := []struct {
+		name      string
+		msg       cacheInvalidation
+		remaining []string
+	}{
+		{"keys", cacheInvalidation{Origin: "other", Keys: []string{"product:1"}}, []string{"product:2", "list"}},
+		{"patterns", cacheInvalidation{Origin: "other", Patterns: []string{"product:*"}}, []string{"list"}},
+		{"tags", cacheInvalidation{Origin: "other", Tags: []string{"products"}}, []string{"list"}},
+		{"own messages are ignored", cacheInvalidation{Keys: []string{"product:1"}}, []string{"product:1", "product:2", "list"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			tiered, l1, _ := newTestTieredCache(time.Minute)
+			defer tiered.client.Close()
+
+			l1.SetWithTags(ctx, "product:1", "v", 0, "products")
+			l1.SetWithTags(ctx, "product:2", "v", 0, "products")
+			l1.Set(ctx, "list", "v", 0)
+
+			if tt.msg.Origin == "" {
+				tt.msg.Origin = tiered.origin
+			}
+			payload, _ := json.Marshal(tt.msg)
+			tiered.apply(ctx, string(payload))
+
+			for _, key := range []string{"product:1", "product:2", "list"} {
+				_, err := l1.Get(ctx, key)
+				want := false
+				for _, r := range tt.remaining {
+					want = want || r == key
+				}
+				if got := err == nil; got != want {
+					t.Errorf("%s present = %v, want %v", key, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestTieredCacheWritesGoToBothTiers(t *testing.T) {
+	ctx := context.Background()
+	tiered, l1, l2 := newTestTieredCache(time.Minute)
+	defer tiered.client.Close()
+
+	if err := tiered.SetWithTags(ctx, "key", "value", time.Hour, "tag"); err != nil {
+		t.Fatal(err)
+	}
+	for name, tier := range map[string]*MemoryCache{"L1": l1, "L2": l2} {
+		if got, err := tier.Get(ctx, "key"); err != nil || got != "value" {
+			t.Errorf("%s = %q, %v", name, got, err)
+		}
+	}
+
+	if err := tiered.Delete(ctx, "key"); err != nil {
+		t.Fatal(err)
+	}
+	for name, tier := range map[string]*MemoryCache{"L1": l1, "L2": l2} {
+		if _, err := tier.Get(ctx, "key"); !errors.Is(err, ErrCacheMiss) {
+			t.Errorf("%s still holds the deleted key: %v", name, err)
+		}
+	}
+
+	// Lists live only in L2
+	if filled, err := tiered.ListFill(ctx, "list", []string{"a"}, time.Hour); err != nil || !filled {
+		t.Fatalf("ListFill = %v, %v", filled, err)
+	}
+	if got, _ := l1.ListRange(ctx, "list", 0, -1); len(got) != 0 {
+		t.Errorf("list copied to L1: %v", got)
+	}
+	if got, _ := tiered.ListRange(ctx, "list", 0, -1); len(got) != 1 || got[0] != "a" {
+		t.Errorf("ListRange = %v, want [a]", got)
+	}
+}
@@ -1,63 +1,174 @@
 package utils
 
 import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"errors"
 	"time"
-	"os"
 
 	"github.com/golang-jwt/jwt/v5"
 )
 
-var jwtSecret = []byte(os.Getenv("JWT_SECRET"))
+// keyRing signs and verifies tokens; it is set once at startup by UseKeyRing
+var keyRing *KeyRing
+
+// UseKeyRing sets the keys tokens are signed and verified with
+func UseKeyRing(ring *KeyRing) {
+	keyRing = ring
+}
 
 // Claims struct for custom payload
 type Claims struct {
 	UserId string `json:"userId"`
 	Role   string `json:"role"`
+	// Roles are resolved to permissions by the server, so editing a role takes
+	// effect without reissuing tokens
+	Roles     []string `json:"roles,omitempty"`
+	SessionID string   `json:"sid,omitempty"`
+	// AMR lists how the user authenticated (RFC 8176), e.g. "pwd" and "mfa"
+	AMR []string `json:"amr,omitempty"`
 	jwt.RegisteredClaims
 }
 
-// GenerateToken generates a JWT token with a 1-hour expiry
-func GenerateToken(userId, role string) (string, error) {
+// Authentication methods carried in the amr claim
+const (
+	AMRPassword = "pwd"
+	AMRMFA      = "mfa"
+)
+
+// RoleNames returns the roles the token grants, falling back to Role for
+// tokens issued before roles were carried
+func (c *Claims) RoleNames() []string {
+	if len(c.Roles) > 0 {
+		return c.Roles
+	}
+	if c.Role != "" {
+		return []string{c.Role}
+	}
+	return nil
+}
+
+// HasAMR reports whether the token's login used the given method
+func (c *Claims) HasAMR(method string) bool {
+	for _, m := range c.AMR {
+		if m == method {
+			return true
+		}
+	}
+	return false
+}
+
+// GenerateToken generates a JWT access token for a session that expires after ttl
+func GenerateToken(userId, role, sessionID string, amr []string, ttl time.Duration) (string, error) {
+	now := time.Now()
 	claims := &Claims{
-		UserId: userId,
-		Role:   role,
+		UserId:    userId,
+		Role:      role,
+		Roles:     []string{role},
+		SessionID: sessionID,
+		AMR:       amr,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(6 * time.Hour)),
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
 		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString(jwtSecret)
+	return keyRing.Sign(claims)
 }
 
 // VerifyToken verifies and parses a JWT token
 func VerifyToken(tokenString string) (*Claims, error) {
-	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
-		return jwtSecret, nil
-	})
+	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, keyRing.Keyfunc, jwt.WithValidMethods(keyRing.Methods()))
 
 	if err != nil || !token.Valid {
 		return nil, errors.New("invalid or expired token")
 	}
 
 	claims, ok := token.Claims.(*Claims)
-	if !ok {
+	if !ok || claims.UserId == "" {
 		return nil, errors.New("could not parse claims")
 	}
 	return claims, nil
 }
 
-func VerifyTokenWithRoles(tokenString string, allowedRoles []string) (*Claims, error) {
-	claims, err := VerifyToken(tokenString)
+// Purposes of action tokens
+const (
+	ActionVerifyEmail = "verify-email"
+	// ActionMFALogin tokens stand for a password login awaiting its second factor
+	ActionMFALogin = "mfa-login"
+)
+
+// ActionClaims is the payload of a signed one-time link such as an email
+// verification. The subject is the user ID and the ID is what makes it
+// single-use once recorded. Action tokens have no userId claim, so they are
+// never accepted as access tokens.
+type ActionClaims struct {
+	Purpose string `json:"purpose"`
+	Email   string `json:"email,omitempty"`
+	jwt.RegisteredClaims
+}
+
+// GenerateActionToken signs a one-time token for purpose that expires after ttl
+func GenerateActionToken(purpose, userId, email string, ttl time.Duration) (string, error) {
+	jti, err := NewRandomID()
 	if err != nil {
-		return nil, err
+		return "", err
 	}
 
-	for _, role := range allowedRoles {
-		if claims.Role == role {
-			return claims, nil
-		}
+	now := time.Now()
+	return keyRing.Sign(&ActionClaims{
+		Purpose: purpose,
+		Email:   email,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        jti,
+			Subject:   userId,
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+		},
+	})
+}
+
+// VerifyActionToken checks an action token's signature, expiry and purpose.
+// Whether it was already used is up to the caller.
+func VerifyActionToken(tokenString, purpose string) (*ActionClaims, error) {
+	token, err := jwt.ParseWithClaims(tokenString, &ActionClaims{}, keyRing.Keyfunc,
+		jwt.WithValidMethods(keyRing.Methods()), jwt.WithExpirationRequired())
+	if err != nil || !token.Valid {
+		return nil, errors.New("invalid or expired token")
 	}
-	return nil, errors.New("user does not have required privileges")
-}
\ No newline at end of file
+
+	claims, ok := token.Claims.(*ActionClaims)
+	if !ok || claims.Purpose != purpose || claims.Subject == "" || claims.ID == "" {
+		return nil, errors.New("invalid or expired token")
+	}
+	return claims, nil
+}
+
+// NewOpaqueToken returns a random URL-safe token for the client and the hash to
+// store in its place
+func NewOpaqueToken() (token, hash string, err error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", err
+	}
+	token = base64.RawURLEncoding.EncodeToString(b)
+	return token, HashOpaqueToken(token), nil
+}
+
+// HashOpaqueToken returns the hex SHA-256 of a token from NewOpaqueToken. The
+// tokens are random, so a fast unsalted hash is enough to make a leaked table useless.
+func HashOpaqueToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// NewRandomID returns a random hex identifier
+func NewRandomID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
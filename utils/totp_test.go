@@ -0,0 +1,150 @@
+package utils
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+// rfcSecret is the SHA-1 key from RFC 4226 and RFC 6238, "12345678901234567890", in base32
+const rfcSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestHOTPMatchesRFC4226(t *testing.T) {
+	// RFC 4226 appendix D
+	want := []string{"755224", "287082", "359152", "969429", "338314", "254676", "287922", "162583", "399871", "520489"}
+	for counter, code := range want {
+		if got := hotp([]byte("12345678901234567890"), int64(counter)); got != code {
+			t.Errorf("hotp(%d) = %s, want %s", counter, got, code)
+		}
+	}
+}
+
+func TestTOTPCodeMatchesRFC6238(t *testing.T) {
+	// RFC 6238 appendix B, SHA-1, keeping the last six of the eight digits
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+		{20000000000, "353130"},
+	}
+	for _, tt := range tests {
+		got, err := TOTPCode(rfcSecret, time.Unix(tt.unix, 0))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.want {
+			t.Errorf("TOTPCode at %d = %s, want %s", tt.unix, got, tt.want)
+		}
+	}
+
+	// Secrets are accepted in lower case, as some apps display them
+	if got, _ := TOTPCode(strings.ToLower(rfcSecret), time.Unix(59, 0)); got != "287082" {
+		t.Errorf("lower-case secret gave %s", got)
+	}
+	if _, err := TOTPCode("not base32!", time.Now()); err == nil {
+		t.Error("invalid secret accepted")
+	}
+}
+
+func TestValidateTOTP(t *testing.T) {
+	now := time.Unix(1111111111, 0) // step 37037037
+	tests := []struct {
+		name     string
+		code     string
+		secret   string
+		wantStep int64
+		wantOK   bool
+	}{
+		{"current step", "050471", rfcSecret, 37037037, true},
+		{"spaces are ignored", "050 471", rfcSecret, 37037037, true},
+		{"previous step within skew", mustTOTP(t, now.Add(-30*time.Second)), rfcSecret, 37037036, true},
+		{"next step within skew", mustTOTP(t, now.Add(30*time.Second)), rfcSecret, 37037038, true},
+		{"two steps old", mustTOTP(t, now.Add(-60*time.Second)), rfcSecret, 0, false},
+		{"wrong code", "123456", rfcSecret, 0, false},
+		{"too short", "05047", rfcSecret, 0, false},
+		{"too long", "0504710", rfcSecret, 0, false},
+		{"invalid secret", "050471", "not base32!", 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			step, ok := ValidateTOTP(tt.secret, tt.code, now)
+			if ok != tt.wantOK || step != tt.wantStep {
+				t.Errorf("ValidateTOTP(%q) = %d, %v, want %d, %v", tt.code, step, ok, tt.wantStep, tt.wantOK)
+			}
+		})
+	}
+}
+
+func mustTOTP(t *testing.T, at time.Time) string {
+	t.Helper()
+	code, err := TOTPCode(rfcSecret, at)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return code
+}
+
+func TestNewTOTPSecret(t *testing.T) {
+	secret, err := NewTOTPSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := totpEncoding.DecodeString(secret)
+	if err != nil || len(key) != 20 {
+		t.Fatalf("secret %q decodes to %d bytes (%v), want 20", secret, len(key), err)
+	}
+	if other, _ := NewTOTPSecret(); other == secret {
+		t.Error("two secrets were equal")
+	}
+}
+
+func TestTOTPURI(t *testing.T) {
+	uri := TOTPURI("E-commerce App", "jane@example.com", rfcSecret)
+	parsed, err := url.Parse(uri)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parsed.Scheme != "otpauth" || parsed.Host != "totp" || parsed.Path != "/E-commerce App:jane@example.com" {
+		t.Errorf("unexpected URI %s", uri)
+	}
+	if strings.Contains(uri, "+") {
+		t.Errorf("spaces encoded as '+' in %s", uri)
+	}
+	query := parsed.Query()
+	for key, want := range map[string]string{"secret": rfcSecret, "issuer": "E-commerce App", "algorithm": "SHA1", "digits": "6", "period": "30"} {
+		if got := query.Get(key); got != want {
+			t.Errorf("%s = %q, want %q", key, got, want)
+		}
+	}
+}
+
+func TestRecoveryCodes(t *testing.T) {
+	codes, err := NewRecoveryCodes(10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	seen := map[string]bool{}
+	for _, code := range codes {
+		if len(code) != 11 || code[5] != '-' {
+			t.Errorf("code %q is not formatted xxxxx-xxxxx", code)
+		}
+		if seen[code] {
+			t.Errorf("duplicate code %q", code)
+		}
+		seen[code] = true
+	}
+
+	// Users may type codes in any case, with or without the dash
+	hash := HashRecoveryCode("abcde-fghij")
+	for _, typed := range []string{"ABCDE-FGHIJ", "abcdefghij", "abcde fghij"} {
+		if HashRecoveryCode(typed) != hash {
+			t.Errorf("HashRecoveryCode(%q) differs from the canonical form", typed)
+		}
+	}
+}